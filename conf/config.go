@@ -0,0 +1,216 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 10:35:12
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/conf/config.go
+ * @Description: 默认配置和全局配置
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package conf
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 启动时 Init 加载好的配置，之后不会再变，热更新的配置用 Current 或者 OnReload 拿
+var (
+	Server     ServerConf
+	Db         DbConf
+	Redis      RedisConf
+	Cache      CacheConf
+	Keys       KeyConf
+	Jwt        JwtConf
+	Auth       AuthConf
+	Account    AccountConf
+	LoginLimit LoginLimitConf
+	RateLimit  RateLimitConf
+	Email      EmailConf
+	TencentSms TencentSmsConf
+	AliyunSms  AliyunSmsConf
+	Sms        SmsConf
+)
+
+var (
+	current atomic.Pointer[Config]
+
+	hookLock sync.Mutex
+	hooks    []func(old *Config, cur *Config)
+)
+
+func init() {
+	cfg := Default()
+	apply(&cfg)
+}
+
+/**
+ * @description: 不含密钥的默认配置，配置文件、环境变量里没写的字段取这里的值
+ * @return {Config}
+ */
+func Default() Config {
+	return Config{
+		Server: ServerConf{
+			Addr:            ":8080",
+			ReadyTimeout:    time.Second,
+			DrainDelay:      time.Second * 5,
+			ShutdownTimeout: time.Second * 20,
+		},
+		Db: DbConf{
+			Host: "127.0.0.1",
+			Port: "3306",
+			User: "root",
+			Db:   "webook",
+		},
+		Redis: RedisConf{
+			Host: "127.0.0.1",
+			Port: "6379",
+		},
+		Cache: CacheConf{
+			Backend:               "redis",
+			LocalSize:             10 * 1024 * 1024,
+			SessionLocalSize:      4 * 1024 * 1024,
+			CodeLocalSize:         1024 * 1024,
+			LoginAttemptLocalSize: 1024 * 1024,
+			LocalExpiretion:       time.Minute,
+			// 一百万个邮箱 1% 误判率大约占 1.2MB
+			EmailFilter: BloomConf{
+				Capacity:      1000000,
+				FalsePositive: 0.01,
+			},
+		},
+		Jwt: JwtConf{
+			// key 不填的用 keys.authorization_key 和 keys.refresh_key
+			AccessKeys: []JwtKeyConf{
+				{
+					Id:     "access-hs512-v1",
+					Alg:    "HS512",
+					Legacy: true,
+				},
+			},
+			RefreshKeys: []JwtKeyConf{
+				{
+					Id:     "refresh-hs512-v1",
+					Alg:    "HS512",
+					Legacy: true,
+				},
+			},
+		},
+		Auth: AuthConf{
+			ResetPasswordExpiretion: time.Minute * 30,
+			VerifyEmailExpiretion:   time.Hour * 24,
+			EmailSendInterval:       time.Minute,
+		},
+		Account: AccountConf{
+			DeletedRetention: time.Hour * 24 * 30,
+			PurgeInterval:    time.Hour,
+		},
+		LoginLimit: LoginLimitConf{
+			DelayAfter:         3,
+			BaseDelay:          time.Second,
+			MaxDelay:           time.Second * 30,
+			AccountMaxFailures: 10,
+			IpMaxFailures:      50,
+			Lockout:            time.Minute * 15,
+			Window:             time.Hour,
+		},
+		RateLimit: RateLimitConf{
+			Rules: []RateLimitRule{
+				{KeyBy: "ip", Window: time.Second, Threshold: 100},
+				{Path: "/users", KeyBy: "user", Window: time.Second, Threshold: 20},
+				// 注册、登录被脚本刷得最多
+				{Path: "/users/signup", KeyBy: "ip", Window: time.Minute, Threshold: 5},
+				{Path: "/users/login", KeyBy: "ip", Window: time.Minute, Threshold: 20},
+				{Path: "/users/login_sms", KeyBy: "ip", Window: time.Minute, Threshold: 20},
+				{Path: "/users/signup", KeyBy: "route", Window: time.Second, Threshold: 200},
+				{Path: "/users/login", KeyBy: "route", Window: time.Second, Threshold: 500},
+			},
+			FallbackCooldown: time.Second * 5,
+		},
+		Email: EmailConf{
+			Port: 587,
+			From: "小微书 <noreply@gdtengnan.com>",
+		},
+		TencentSms: TencentSmsConf{
+			Region:   "ap-guangzhou",
+			AppId:    "1400842696",
+			SignName: "小微书",
+		},
+		AliyunSms: AliyunSmsConf{
+			SignName: "小微书",
+			Templates: map[string]SmsTemplateConf{
+				"1877556": {
+					Code:   "SMS_462030212",
+					Params: []string{"code"},
+				},
+			},
+		},
+		Sms: SmsConf{
+			Strategy:       "failover",
+			Timeout:        time.Second * 3,
+			Threshold:      3,
+			LimitWindow:    time.Second,
+			LimitThreshold: 100,
+			RetryMax:       3,
+		},
+	}
+}
+
+// Current 当前生效的配置，热更新之后会变，不要修改返回值
+func Current() *Config {
+	return current.Load()
+}
+
+/**
+ * @description: 订阅热更新，只有限流、登录防爆破、功能开关这些配置会热更新
+ * @param {func(old *Config, cur *Config)} fn 在 Watch 的协程里调用，不要阻塞
+ * @return {*}
+ */
+func OnReload(fn func(old *Config, cur *Config)) {
+	hookLock.Lock()
+	defer hookLock.Unlock()
+	hooks = append(hooks, fn)
+}
+
+// apply 启动时把配置写进全局变量
+func apply(cfg *Config) {
+	Server = cfg.Server
+	Db = cfg.Db
+	Redis = cfg.Redis
+	Cache = cfg.Cache
+	Keys = cfg.Keys
+	Jwt = cfg.Jwt
+	Auth = cfg.Auth
+	Account = cfg.Account
+	LoginLimit = cfg.LoginLimit
+	RateLimit = cfg.RateLimit
+	Email = cfg.Email
+	TencentSms = cfg.TencentSms
+	AliyunSms = cfg.AliyunSms
+	Sms = cfg.Sms
+	current.Store(cfg)
+}
+
+/**
+ * @description: 热更新，只替换能热更新的部分，其余的保持启动时的值
+ * @param {*Config} cfg 新加载的配置
+ * @return {bool} 有没有需要重启才能生效的改动
+ */
+func reload(cfg *Config) bool {
+	old := current.Load()
+	next := *old
+	next.LoginLimit = cfg.LoginLimit
+	next.RateLimit = cfg.RateLimit
+	next.Features = cfg.Features
+	current.Store(&next)
+
+	hookLock.Lock()
+	fns := append([]func(old *Config, cur *Config){}, hooks...)
+	hookLock.Unlock()
+	for _, fn := range fns {
+		fn(old, &next)
+	}
+	return !reflect.DeepEqual(*cfg, next)
+}
@@ -0,0 +1,368 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 10:58:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/conf/loader.go
+ * @Description: 从配置文件、密钥目录、环境变量、命令行加载配置
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package conf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix 环境变量 WEBOOK_DB__PASSWORD 对应配置 db.password，两个下划线分隔层级
+const envPrefix = "WEBOOK_"
+
+// legacyEnv 改造之前就在用的环境变量，部署里已经配了，继续认
+var legacyEnv = map[string]string{
+	"SMTP_HOST":                "email.host",
+	"SMTP_USERNAME":            "email.username",
+	"SMTP_PASSWORD":            "email.password",
+	"TENCENTCLOUD_SECRET_ID":   "tencent_sms.secret_id",
+	"TENCENTCLOUD_SECRET_KEY":  "tencent_sms.secret_key",
+	"ALIYUN_ACCESS_KEY_ID":     "aliyun_sms.access_key_id",
+	"ALIYUN_ACCESS_KEY_SECRET": "aliyun_sms.access_key_secret",
+}
+
+// Loader 优先级从低到高：默认值、配置文件、密钥目录、环境变量、命令行 -set
+type Loader struct {
+	file string
+	// k8s Secret 挂载成的目录，文件名是配置路径，比如 keys.refresh_key，内容是值
+	secretDir string
+	sets      []string
+	env       []string
+	// 多久检查一次配置文件和密钥目录有没有变，0 表示不热更新
+	watchInterval time.Duration
+}
+
+type override struct {
+	source string
+	path   string
+	value  string
+}
+
+/**
+ * @description: 解析命令行参数
+ * @param {[]string} args 不含程序名
+ * @param {[]string} env os.Environ() 的格式
+ * @return {*Loader, error}
+ */
+func NewLoader(args []string, env []string) (*Loader, error) {
+	l := &Loader{env: env}
+	fs := flag.NewFlagSet("webook", flag.ContinueOnError)
+	fs.StringVar(&l.file, "config", "config/dev.yaml", "配置文件，支持 .yaml、.yml、.toml，传空字符串只用默认值")
+	fs.StringVar(&l.secretDir, "secrets", "", "密钥目录，文件名是配置路径，内容是值")
+	fs.DurationVar(&l.watchInterval, "watch", time.Second*10, "检查配置变化的间隔，0 表示不热更新")
+	fs.Func("set", "覆盖单个配置，比如 -set db.host=127.0.0.1，可以写多次", func(s string) error {
+		if !strings.Contains(s, "=") {
+			return fmt.Errorf("格式是 路径=值: %s", s)
+		}
+		l.sets = append(l.sets, s)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+/**
+ * @description: 加载并校验配置
+ * @return {*Config, error}
+ */
+func (l *Loader) Load() (*Config, error) {
+	cfg := Default()
+	if l.file != "" {
+		data, err := os.ReadFile(l.file)
+		if err != nil {
+			return nil, err
+		}
+		if err = decodeFile(l.file, data, &cfg); err != nil {
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %w", l.file, err)
+		}
+	}
+
+	overrides, err := l.overrides()
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides) > 0 {
+		var root yaml.Node
+		if err = root.Encode(&cfg); err != nil {
+			return nil, err
+		}
+		for _, o := range overrides {
+			if err = setPath(&root, strings.Split(o.path, "."), o.value); err != nil {
+				return nil, fmt.Errorf("%s 覆盖 %s 失败: %w", o.source, o.path, err)
+			}
+		}
+		cfg = Config{}
+		if err = decodeStrict(&root, &cfg); err != nil {
+			return nil, fmt.Errorf("覆盖配置失败: %w", err)
+		}
+	}
+
+	fillJwtKeys(&cfg)
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+/**
+ * @description: 每隔一段时间重新加载，有变化就热更新，加载失败或者校验不过保持原来的配置
+ * @param {context.Context} ctx
+ * @return {*}
+ */
+func (l *Loader) Watch(ctx context.Context) {
+	if l.watchInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(l.watchInterval)
+	defer ticker.Stop()
+	last := l.fingerprint()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		fp := l.fingerprint()
+		if fp == last {
+			continue
+		}
+		cfg, err := l.Load()
+		if err != nil {
+			log.Printf("配置有变化但是加载失败，继续用原来的配置: %v", err)
+			continue
+		}
+		last = fp
+		if reload(cfg) {
+			log.Println("配置已热更新，限流、登录防爆破、功能开关之外的改动要重启才能生效")
+			continue
+		}
+		log.Println("配置已热更新")
+	}
+}
+
+/**
+ * @description: 密钥目录、环境变量、命令行的覆盖项，按优先级从低到高排
+ * @return {[]override, error}
+ */
+func (l *Loader) overrides() ([]override, error) {
+	var res []override
+	if l.secretDir != "" {
+		entries, err := os.ReadDir(l.secretDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			// k8s 挂载的目录里有 ..data 之类的软链接，不是配置
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(l.secretDir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, override{
+				source: "密钥文件",
+				path:   entry.Name(),
+				value:  strings.TrimRight(string(data), "\r\n"),
+			})
+		}
+	}
+
+	envs := make(map[string]string, len(l.env))
+	for _, kv := range l.env {
+		k, v, _ := strings.Cut(kv, "=")
+		envs[k] = v
+	}
+	legacy := make([]string, 0, len(legacyEnv))
+	for k := range legacyEnv {
+		legacy = append(legacy, k)
+	}
+	sort.Strings(legacy)
+	for _, k := range legacy {
+		if v := envs[k]; v != "" {
+			res = append(res, override{source: "环境变量 " + k, path: legacyEnv[k], value: v})
+		}
+	}
+	var prefixed []string
+	for k := range envs {
+		if strings.HasPrefix(k, envPrefix) {
+			prefixed = append(prefixed, k)
+		}
+	}
+	sort.Strings(prefixed)
+	for _, k := range prefixed {
+		path := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(k, envPrefix), "__", "."))
+		res = append(res, override{source: "环境变量 " + k, path: path, value: envs[k]})
+	}
+
+	for _, s := range l.sets {
+		path, value, _ := strings.Cut(s, "=")
+		res = append(res, override{source: "命令行", path: path, value: value})
+	}
+	return res, nil
+}
+
+// fingerprint 配置文件和密钥目录内容的摘要，k8s 更新 Secret 是换软链接，看修改时间不靠谱
+func (l *Loader) fingerprint() string {
+	h := sha256.New()
+	if l.file != "" {
+		data, _ := os.ReadFile(l.file)
+		h.Write(data)
+	}
+	if l.secretDir != "" {
+		entries, _ := os.ReadDir(l.secretDir)
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			data, _ := os.ReadFile(filepath.Join(l.secretDir, entry.Name()))
+			fmt.Fprintf(h, "%s=%x;", entry.Name(), sha256.Sum256(data))
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+/**
+ * @description: 按扩展名解析配置文件，没写的字段保留 cfg 里原来的值
+ * @param {string} name
+ * @param {[]byte} data
+ * @param {*Config} cfg
+ * @return {error}
+ */
+func decodeFile(name string, data []byte, cfg *Config) error {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+	case ".toml":
+		// 转成 yaml 统一解析，字段名、时长的写法都和 yaml 一样
+		var m map[string]any
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		var err error
+		if data, err = yaml.Marshal(m); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("不支持的配置文件格式 %s", filepath.Ext(name))
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	// 字段名写错直接报错，不然配了不生效很难发现
+	dec.KnownFields(true)
+	err := dec.Decode(cfg)
+	if err == io.EOF {
+		// 空文件
+		return nil
+	}
+	return err
+}
+
+func decodeStrict(node *yaml.Node, cfg *Config) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	return dec.Decode(cfg)
+}
+
+/**
+ * @description: 按路径改 yaml 节点，路径里的数字是列表下标，值的类型在解析进结构体时按字段类型转换
+ * @param {*yaml.Node} node
+ * @param {[]string} path
+ * @param {string} value
+ * @return {error}
+ */
+func setPath(node *yaml.Node, path []string, value string) error {
+	if node.Kind == yaml.DocumentNode {
+		return setPath(node.Content[0], path, value)
+	}
+	if len(path) == 0 {
+		*node = yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		return nil
+	}
+	key := path[0]
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return setPath(node.Content[i+1], path[1:], value)
+			}
+		}
+		// 默认值里没有的 key 先加上，不认识的字段解析时会报错
+		child := &yaml.Node{Kind: yaml.MappingNode}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+		return setPath(child, path[1:], value)
+	case yaml.SequenceNode:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx > len(node.Content) {
+			return fmt.Errorf("列表下标 %s 不对", key)
+		}
+		if idx == len(node.Content) {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.MappingNode})
+		}
+		return setPath(node.Content[idx], path[1:], value)
+	case yaml.ScalarNode:
+		// 默认值是空的 map 或者列表时编码出来是 null
+		if node.Tag == "!!null" {
+			*node = yaml.Node{Kind: yaml.MappingNode}
+			return setPath(node, path, value)
+		}
+	}
+	return fmt.Errorf("%s 不是对象或者列表", key)
+}
+
+// fillJwtKeys 签名key一般只配在 keys 里，jwt 里没填的按用途补上
+func fillJwtKeys(cfg *Config) {
+	for i := range cfg.Jwt.AccessKeys {
+		if cfg.Jwt.AccessKeys[i].Key == "" {
+			cfg.Jwt.AccessKeys[i].Key = cfg.Keys.AuthorizationKey
+		}
+	}
+	for i := range cfg.Jwt.RefreshKeys {
+		if cfg.Jwt.RefreshKeys[i].Key == "" {
+			cfg.Jwt.RefreshKeys[i].Key = cfg.Keys.RefreshKey
+		}
+	}
+}
+
+/**
+ * @description: 启动时加载配置写进全局变量，返回的 Loader 用来热更新
+ * @param {[]string} args 不含程序名的命令行参数
+ * @return {*Loader, error}
+ */
+func Init(args []string) (*Loader, error) {
+	l, err := NewLoader(args, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := l.Load()
+	if err != nil {
+		return nil, err
+	}
+	apply(cfg)
+	return l, nil
+}
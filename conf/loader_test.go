@@ -0,0 +1,288 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 14:02:37
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/conf/loader_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testYaml = `
+db:
+  host: 10.0.0.1
+  password: file
+keys:
+  authorization_key: access-key-0123456789abcdef0123456789abcdef
+  refresh_key: refresh-key-0123456789abcdef0123456789abcdef
+  reset_password_key: reset-key-0123456789abcdef0123456789abcdef
+  verify_email_key: verify-key-0123456789abcdef0123456789abcdef
+auth:
+  legacy_token_deadline: 2026-10-25T00:00:00+08:00
+  reset_password_url: http://localhost/reset?token=%s
+  verify_email_url: http://localhost/verify?token=%s
+email:
+  backend: log
+sms:
+  backend: log
+`
+
+func TestLoader_Load(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		file     string
+		secrets  map[string]string
+		args     []string
+		env      []string
+		wantErr  bool
+		check    func(t *testing.T, cfg *Config)
+	}{
+		{
+			name:     "没写的用默认值",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "10.0.0.1", cfg.Db.Host)
+				assert.Equal(t, "3306", cfg.Db.Port)
+				assert.Equal(t, time.Second*30, cfg.LoginLimit.MaxDelay)
+				assert.True(t, cfg.Auth.LegacyTokenDeadline.Equal(time.Date(2026, 10, 24, 16, 0, 0, 0, time.UTC)))
+			},
+		},
+		{
+			name:     "旧token截止时间没配",
+			fileName: "webook.yaml",
+			file:     strings.Replace(testYaml, "  legacy_token_deadline: 2026-10-25T00:00:00+08:00\n", "", 1),
+			wantErr:  true,
+		},
+		{
+			name:     "旧token截止时间按发版覆盖",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			args:     []string{"-set", "auth.legacy_token_deadline=2026-11-01T00:00:00+08:00"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Auth.LegacyTokenDeadline.Equal(time.Date(2026, 10, 31, 16, 0, 0, 0, time.UTC)))
+			},
+		},
+		{
+			name:     "jwt没配key用keys里的",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, cfg.Keys.AuthorizationKey, cfg.Jwt.AccessKeys[0].Key)
+				assert.Equal(t, cfg.Keys.RefreshKey, cfg.Jwt.RefreshKeys[0].Key)
+			},
+		},
+		{
+			name:     "优先级",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			secrets:  map[string]string{"db.password": "secret\n", "db.user": "webook"},
+			env:      []string{"WEBOOK_DB__PASSWORD=env", "WEBOOK_REDIS__DB=3"},
+			args:     []string{"-set", "redis.db=5"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "webook", cfg.Db.User)
+				assert.Equal(t, "env", cfg.Db.Password)
+				assert.Equal(t, 5, cfg.Redis.Db)
+			},
+		},
+		{
+			name:     "以前的环境变量",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			env:      []string{"SMTP_HOST=smtp.qq.com", "ALIYUN_ACCESS_KEY_SECRET=aliyun"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "smtp.qq.com", cfg.Email.Host)
+				assert.Equal(t, "aliyun", cfg.AliyunSms.AccessKeySecret)
+			},
+		},
+		{
+			name:     "改列表里的一项",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			args:     []string{"-set", "rate_limit.rules.1.threshold=1", "-set", "login_limit.lockout=1m"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 1, cfg.RateLimit.Rules[1].Threshold)
+				assert.Equal(t, "/users", cfg.RateLimit.Rules[1].Path)
+				assert.Equal(t, time.Minute, cfg.LoginLimit.Lockout)
+			},
+		},
+		{
+			name:     "toml",
+			fileName: "webook.toml",
+			file: `
+[db]
+host = "10.0.0.2"
+[redis]
+db = 2
+[keys]
+authorization_key = "access-key-0123456789abcdef0123456789abcdef"
+refresh_key = "refresh-key-0123456789abcdef0123456789abcdef"
+reset_password_key = "reset-key-0123456789abcdef0123456789abcdef"
+verify_email_key = "verify-key-0123456789abcdef0123456789abcdef"
+[auth]
+legacy_token_deadline = 2026-10-25T00:00:00+08:00
+reset_password_url = "http://localhost/reset?token=%s"
+verify_email_url = "http://localhost/verify?token=%s"
+[email]
+backend = "log"
+[sms]
+backend = "log"
+[login_limit]
+lockout = "5m"
+`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "10.0.0.2", cfg.Db.Host)
+				assert.Equal(t, 2, cfg.Redis.Db)
+				assert.Equal(t, time.Minute*5, cfg.LoginLimit.Lockout)
+			},
+		},
+		{
+			name:     "可信代理写错",
+			fileName: "webook.yaml",
+			file:     testYaml + "server:\n  trusted_proxies:\n    - 10.0.0.0/33\n",
+			wantErr:  true,
+		},
+		{
+			name:     "没写发信方式",
+			fileName: "webook.yaml",
+			file:     strings.Replace(testYaml, "email:\n  backend: log\n", "", 1),
+			wantErr:  true,
+		},
+		{
+			name:     "短信走服务商但没配密钥",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			args:     []string{"-set", "sms.backend=cloud"},
+			wantErr:  true,
+		},
+		{
+			name:     "邮件走smtp",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			secrets:  map[string]string{"email.host": "smtp.qq.com"},
+			args:     []string{"-set", "email.backend=smtp"},
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "smtp", cfg.Email.Backend)
+				assert.Equal(t, "smtp.qq.com", cfg.Email.Host)
+			},
+		},
+		{
+			name:     "长短token用同一把key",
+			fileName: "webook.yaml",
+			file:     strings.Replace(testYaml, "refresh-key-0123456789abcdef0123456789abcdef", "access-key-0123456789abcdef0123456789abcdef", 1),
+			wantErr:  true,
+		},
+		{
+			name:     "长短token的kid重复",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			args:     []string{"-set", "jwt.refresh_keys.0.id=access-hs512-v1"},
+			wantErr:  true,
+		},
+		{
+			name:     "字段名写错",
+			fileName: "webook.yaml",
+			file:     testYaml + "featrues:\n  disable_signup: true\n",
+			wantErr:  true,
+		},
+		{
+			name:     "覆盖的字段不存在",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			env:      []string{"WEBOOK_DB__PASSWD=env"},
+			wantErr:  true,
+		},
+		{
+			name:     "值的类型不对",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			args:     []string{"-set", "redis.db=one"},
+			wantErr:  true,
+		},
+		{
+			name:     "校验不过",
+			fileName: "webook.yaml",
+			file:     testYaml,
+			args:     []string{"-set", "keys.reset_password_key=short"},
+			wantErr:  true,
+		},
+		{
+			name:     "不支持的格式",
+			fileName: "webook.json",
+			file:     "{}",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, tt.fileName)
+			assert.NoError(t, os.WriteFile(file, []byte(tt.file), 0o600))
+			args := append([]string{"-config", file}, tt.args...)
+			if tt.secrets != nil {
+				secretDir := filepath.Join(dir, "secrets")
+				assert.NoError(t, os.Mkdir(secretDir, 0o700))
+				for name, value := range tt.secrets {
+					assert.NoError(t, os.WriteFile(filepath.Join(secretDir, name), []byte(value), 0o600))
+				}
+				// k8s 挂载 Secret 时带的隐藏文件要跳过
+				assert.NoError(t, os.WriteFile(filepath.Join(secretDir, "..data"), []byte("x"), 0o600))
+				args = append(args, "-secrets", secretDir)
+			}
+
+			l, err := NewLoader(args, tt.env)
+			assert.NoError(t, err)
+			cfg, err := l.Load()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	origin := Current()
+	defer apply(origin)
+
+	cfg := Default()
+	apply(&cfg)
+	var gotOld, gotCur *Config
+	OnReload(func(old *Config, cur *Config) {
+		gotOld, gotCur = old, cur
+	})
+
+	next := Default()
+	next.Db.Host = "10.0.0.3"
+	next.LoginLimit.Lockout = time.Minute
+	next.Features.DisableSignup = true
+	restart := reload(&next)
+
+	assert.True(t, restart)
+	assert.Equal(t, time.Minute*15, gotOld.LoginLimit.Lockout)
+	assert.Equal(t, time.Minute, gotCur.LoginLimit.Lockout)
+	assert.True(t, Current().Features.DisableSignup)
+	// 要重启才生效的不变
+	assert.Equal(t, "127.0.0.1", Current().Db.Host)
+	assert.Equal(t, "127.0.0.1", Db.Host)
+
+	// 只改了能热更新的不用重启
+	next.Db.Host = "127.0.0.1"
+	next.Features.DisableSignup = false
+	assert.False(t, reload(&next))
+	assert.False(t, Current().Features.DisableSignup)
+}
@@ -9,22 +9,211 @@
  */
 package conf
 
+import "time"
+
 type DbConf struct {
-	Host     string
-	User     string
-	Port     string
-	Password string
-	Db       string
+	Host     string `yaml:"host"`
+	User     string `yaml:"user"`
+	Port     string `yaml:"port"`
+	Password string `yaml:"password"`
+	Db       string `yaml:"db"`
 }
 
 type RedisConf struct {
-	Host     string
-	Port     string
-	Password string
-	Db       int
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Password string `yaml:"password"`
+	Db       int    `yaml:"db"`
 }
 
 type KeyConf struct {
-	AuthorizationKey string
-	EncryptKey       string
+	AuthorizationKey string `yaml:"authorization_key"`
+	// 长token用单独的key签名，短token的key泄露也换不到新token
+	RefreshKey string `yaml:"refresh_key"`
+	EncryptKey string `yaml:"encrypt_key"`
+	// 重置密码链接的签名key
+	ResetPasswordKey string `yaml:"reset_password_key"`
+	// 注册验证邮箱链接的签名key
+	VerifyEmailKey string `yaml:"verify_email_key"`
+}
+
+type JwtKeyConf struct {
+	// 写进token头部的 kid
+	Id string `yaml:"id"`
+	// HS512、RS256、EdDSA
+	Alg string `yaml:"alg"`
+	// HS512 是密钥本身，RS256/EdDSA 是PEM编码的私钥，只配公钥的key只能用来验证
+	Key string `yaml:"key"`
+	// 引入 kid 之前签发的token没有 kid，用这把key验证
+	Legacy bool `yaml:"legacy"`
+}
+
+type JwtConf struct {
+	// 第一把key用来签发，其余只用来验证，轮换时新key放第一位
+	AccessKeys  []JwtKeyConf `yaml:"access_keys"`
+	RefreshKeys []JwtKeyConf `yaml:"refresh_keys"`
+}
+
+type AuthConf struct {
+	// 只带邮箱、没有会话的旧token在这个时间之前照常放行，跟发版时间走，必须在配置里写
+	LegacyTokenDeadline time.Time `yaml:"legacy_token_deadline"`
+	// 前端重置密码页面，%s 替换成 token
+	ResetPasswordUrl string `yaml:"reset_password_url"`
+	// 重置密码链接的有效期
+	ResetPasswordExpiretion time.Duration `yaml:"reset_password_expiretion"`
+	// 前端验证邮箱页面，%s 替换成 token
+	VerifyEmailUrl string `yaml:"verify_email_url"`
+	// 验证邮箱链接的有效期
+	VerifyEmailExpiretion time.Duration `yaml:"verify_email_expiretion"`
+	// 同一个邮箱两封同类邮件之间至少隔这么久
+	EmailSendInterval time.Duration `yaml:"email_send_interval"`
+	// 会话存储挂了时照常放行，默认返回 503；打开后这段时间里退出登录、改过密码的token又能用
+	SessionFailOpen bool `yaml:"session_fail_open"`
+}
+
+type ServerConf struct {
+	Addr string `yaml:"addr"`
+	// 就绪检查 ping 依赖的超时时间
+	ReadyTimeout time.Duration `yaml:"ready_timeout"`
+	// 收到 SIGTERM 后先让就绪检查失败，等这么久 k8s 把流量摘掉再停止接收请求
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// 等进行中的请求处理完的最长时间，DrainDelay 加上它要小于 terminationGracePeriodSeconds
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// 只有从这些网段（ingress 所在的网段）过来的请求才认 X-Forwarded-For，不配就一律按连接的对端地址算，
+	// 不然客户端自己带个 X-Forwarded-For 就能换 IP，按IP的限流、登录锁定都没用了
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// 前面是 Cloudflare 这类会带可信头的平台时填头名，比如 CF-Connecting-IP，优先于 TrustedProxies
+	TrustedPlatform string `yaml:"trusted_platform"`
+}
+
+type AccountConf struct {
+	// 注销的账号保留多久再彻底删除
+	DeletedRetention time.Duration `yaml:"deleted_retention"`
+	// 多久检查一次要彻底删除的账号
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+type LoginLimitConf struct {
+	// 账号连续失败几次之后开始要求等待，等待时间从 BaseDelay 开始翻倍，最多 MaxDelay
+	DelayAfter int           `yaml:"delay_after"`
+	BaseDelay  time.Duration `yaml:"base_delay"`
+	MaxDelay   time.Duration `yaml:"max_delay"`
+	// 账号、IP各失败几次锁定
+	AccountMaxFailures int `yaml:"account_max_failures"`
+	IpMaxFailures      int `yaml:"ip_max_failures"`
+	// 锁定多久
+	Lockout time.Duration `yaml:"lockout"`
+	// 多久没有新的失败清掉计数
+	Window time.Duration `yaml:"window"`
+}
+
+type RateLimitRule struct {
+	// 路由前缀，空的表示所有接口
+	Path string `yaml:"path"`
+	// 按什么计数：ip、user、route
+	KeyBy     string        `yaml:"key_by"`
+	Window    time.Duration `yaml:"window"`
+	Threshold int           `yaml:"threshold"`
+}
+
+type RateLimitConf struct {
+	// 一个请求命中的规则都要检查
+	Rules []RateLimitRule `yaml:"rules"`
+	// redis 出错后多久内直接用本地令牌桶
+	FallbackCooldown time.Duration `yaml:"fallback_cooldown"`
+}
+
+type EmailConf struct {
+	// smtp 真的发信；log 只把邮件打到日志里，只给本地调试用，要明确写出来，没有默认值
+	Backend  string `yaml:"backend"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+type TencentSmsConf struct {
+	SecretId  string `yaml:"secret_id"`
+	SecretKey string `yaml:"secret_key"`
+	Region    string `yaml:"region"`
+	AppId     string `yaml:"app_id"`
+	SignName  string `yaml:"sign_name"`
+}
+
+type AliyunSmsConf struct {
+	AccessKeyId     string `yaml:"access_key_id"`
+	AccessKeySecret string `yaml:"access_key_secret"`
+	SignName        string `yaml:"sign_name"`
+	// 业务模板id到阿里云模板的映射
+	Templates map[string]SmsTemplateConf `yaml:"templates"`
+}
+
+type SmsTemplateConf struct {
+	Code   string   `yaml:"code"`
+	Params []string `yaml:"params"`
+}
+
+type SmsConf struct {
+	// cloud 走腾讯云、阿里云，至少配一家；log 只把验证码打到日志里，只给本地调试用，没有默认值
+	Backend string `yaml:"backend"`
+	// failover 轮询，失败换下一家；timeout_failover 固定一家，连续超时才切换
+	Strategy string `yaml:"strategy"`
+	// 单个服务商的超时时间
+	Timeout time.Duration `yaml:"timeout"`
+	// timeout_failover 连续超时多少次切换
+	Threshold int32 `yaml:"threshold"`
+	// 滑动窗口内最多同步发送多少条，超过的转异步发送
+	LimitWindow    time.Duration `yaml:"limit_window"`
+	LimitThreshold int           `yaml:"limit_threshold"`
+	// 异步发送最多重试次数
+	RetryMax int `yaml:"retry_max"`
+}
+
+type CacheConf struct {
+	// redis 所有实例共用redis；two_level 用户数据先查本地再查redis，其余同 redis；
+	// memory 全部放本地，只能跑一个实例；none 不缓存用户数据，每次查库，验证码、会话这些状态还是放redis
+	Backend string `yaml:"backend"`
+	// 本地缓存的大小，单位字节
+	LocalSize int `yaml:"local_size"`
+	// memory 方案下作废会话的记录单独放一块，不跟用户数据抢空间，满了最早的作废记录会被挤掉，对应的token又能用了
+	SessionLocalSize int `yaml:"session_local_size"`
+	// memory 方案下验证码、登录失败次数也各自单独一块，被挤掉的话验证码失效、登录锁定提前解除
+	CodeLocalSize         int `yaml:"code_local_size"`
+	LoginAttemptLocalSize int `yaml:"login_attempt_local_size"`
+	// two_level 本地副本的有效期，漏收失效消息时最多旧这么久
+	LocalExpiretion time.Duration `yaml:"local_expiretion"`
+	// 注册过的邮箱的布隆过滤器，memory 放本地，其余放redis，none 不过滤
+	EmailFilter BloomConf `yaml:"email_filter"`
+}
+
+type BloomConf struct {
+	// 预计元素个数，超过之后误判率会变高，0 表示不用过滤器
+	Capacity uint64 `yaml:"capacity"`
+	// 误判率，越低占的空间越大
+	FalsePositive float64 `yaml:"false_positive"`
+}
+
+type FeatureConf struct {
+	// 被脚本刷注册的时候临时关掉注册，已有账号照常登录
+	DisableSignup bool `yaml:"disable_signup"`
+}
+
+// Config 配置文件的完整结构
+type Config struct {
+	Server     ServerConf     `yaml:"server"`
+	Db         DbConf         `yaml:"db"`
+	Redis      RedisConf      `yaml:"redis"`
+	Cache      CacheConf      `yaml:"cache"`
+	Keys       KeyConf        `yaml:"keys"`
+	Jwt        JwtConf        `yaml:"jwt"`
+	Auth       AuthConf       `yaml:"auth"`
+	Account    AccountConf    `yaml:"account"`
+	LoginLimit LoginLimitConf `yaml:"login_limit"`
+	RateLimit  RateLimitConf  `yaml:"rate_limit"`
+	Features   FeatureConf    `yaml:"features"`
+	Email      EmailConf      `yaml:"email"`
+	TencentSms TencentSmsConf `yaml:"tencent_sms"`
+	AliyunSms  AliyunSmsConf  `yaml:"aliyun_sms"`
+	Sms        SmsConf        `yaml:"sms"`
 }
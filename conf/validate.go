@@ -0,0 +1,118 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 11:26:09
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/conf/validate.go
+ * @Description: 配置校验
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// minKeyLen 签名key太短等于没有，HS512 建议至少 64 字节，这里只拦明显不对的
+const minKeyLen = 32
+
+/**
+ * @description: 校验配置，把所有问题一起返回，启动失败时一次看全
+ * @return {error}
+ */
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr 不能为空")
+	check(c.Server.ReadyTimeout > 0, "server.ready_timeout 要大于0")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout 要大于0")
+	for i, proxy := range c.Server.TrustedProxies {
+		check(validProxy(proxy), "server.trusted_proxies.%d 不是合法的IP或网段: %s", i, proxy)
+	}
+
+	check(c.Db.Host != "" && c.Db.Port != "" && c.Db.User != "" && c.Db.Db != "", "db 的 host、port、user、db 都要配")
+	check(c.Redis.Host != "" && c.Redis.Port != "", "redis 的 host、port 都要配")
+	// 可选的缓存方案在 ioc 里注册，启动时再检查
+	check(c.Cache.Backend != "", "cache.backend 不能为空")
+	check(c.Cache.LocalSize >= 512*1024, "cache.local_size 至少 512KB")
+	check(c.Cache.SessionLocalSize >= 512*1024, "cache.session_local_size 至少 512KB")
+	check(c.Cache.CodeLocalSize >= 512*1024, "cache.code_local_size 至少 512KB")
+	check(c.Cache.LoginAttemptLocalSize >= 512*1024, "cache.login_attempt_local_size 至少 512KB")
+	check(c.Cache.LocalExpiretion >= time.Second, "cache.local_expiretion 至少 1s")
+	filter := c.Cache.EmailFilter
+	check(filter.Capacity == 0 || filter.FalsePositive > 0 && filter.FalsePositive < 1,
+		"cache.email_filter.false_positive 要在 0 到 1 之间")
+
+	check(len(c.Keys.ResetPasswordKey) >= minKeyLen, "keys.reset_password_key 至少 %d 位", minKeyLen)
+	check(len(c.Keys.VerifyEmailKey) >= minKeyLen, "keys.verify_email_key 至少 %d 位", minKeyLen)
+	check(len(c.Jwt.AccessKeys) > 0, "jwt.access_keys 至少要有一把key")
+	check(len(c.Jwt.RefreshKeys) > 0, "jwt.refresh_keys 至少要有一把key")
+	for i, key := range c.Jwt.AccessKeys {
+		check(key.Key != "", "jwt.access_keys.%d.key 没配，也没有 keys.authorization_key 可以用", i)
+	}
+	for i, key := range c.Jwt.RefreshKeys {
+		check(key.Key != "", "jwt.refresh_keys.%d.key 没配，也没有 keys.refresh_key 可以用", i)
+	}
+	// 两种token共用 kid 或者key的话，长token拿去当短token也能验过
+	accessKids := make(map[string]bool, len(c.Jwt.AccessKeys))
+	accessKeys := make(map[string]bool, len(c.Jwt.AccessKeys))
+	for _, key := range c.Jwt.AccessKeys {
+		accessKids[key.Id] = true
+		accessKeys[strings.TrimSpace(key.Key)] = true
+	}
+	for i, key := range c.Jwt.RefreshKeys {
+		check(!accessKids[key.Id], "jwt.refresh_keys.%d.id 跟 jwt.access_keys 里的重复了: %s", i, key.Id)
+		check(key.Key == "" || !accessKeys[strings.TrimSpace(key.Key)], "jwt.refresh_keys.%d.key 跟 jwt.access_keys 里的一把key一样", i)
+	}
+
+	check(!c.Auth.LegacyTokenDeadline.IsZero(), "auth.legacy_token_deadline 不能为空，旧token不再放行就配一个过去的时间")
+	check(c.Auth.ResetPasswordUrl != "", "auth.reset_password_url 不能为空")
+	check(c.Auth.VerifyEmailUrl != "", "auth.verify_email_url 不能为空")
+	check(c.Auth.EmailSendInterval > 0, "auth.email_send_interval 要大于0")
+
+	check(c.LoginLimit.AccountMaxFailures > 0 && c.LoginLimit.IpMaxFailures > 0, "login_limit 的失败次数上限要大于0")
+	check(c.LoginLimit.BaseDelay > 0 && c.LoginLimit.MaxDelay >= c.LoginLimit.BaseDelay,
+		"login_limit.max_delay 要大于等于 base_delay，且都大于0")
+	for i, rule := range c.RateLimit.Rules {
+		check(rule.KeyBy == "ip" || rule.KeyBy == "user" || rule.KeyBy == "route",
+			"rate_limit.rules.%d.key_by 只能是 ip、user、route", i)
+		check(rule.Window > 0 && rule.Threshold > 0, "rate_limit.rules.%d 的 window、threshold 要大于0", i)
+	}
+
+	// 发信方式不给默认值，免得线上漏配密钥悄悄变成只打日志
+	switch c.Email.Backend {
+	case "smtp":
+		check(c.Email.Host != "" && c.Email.Port > 0 && c.Email.From != "", "email.backend 是 smtp 时 host、port、from 都要配")
+	case "log":
+	default:
+		check(false, "email.backend 只能是 smtp、log，当前是 %q", c.Email.Backend)
+	}
+	switch c.Sms.Backend {
+	case "cloud":
+		check(c.TencentSms.SecretId != "" || c.AliyunSms.AccessKeyId != "",
+			"sms.backend 是 cloud 时 tencent_sms.secret_id、aliyun_sms.access_key_id 至少配一个")
+	case "log":
+	default:
+		check(false, "sms.backend 只能是 cloud、log，当前是 %q", c.Sms.Backend)
+	}
+	check(c.Sms.Strategy == "failover" || c.Sms.Strategy == "timeout_failover", "sms.strategy 只能是 failover、timeout_failover")
+	return errors.Join(errs...)
+}
+
+// validProxy 跟 gin 的 SetTrustedProxies 一样，单个IP或者CIDR都行
+func validProxy(proxy string) bool {
+	if strings.Contains(proxy, "/") {
+		_, _, err := net.ParseCIDR(proxy)
+		return err == nil
+	}
+	return net.ParseIP(proxy) != nil
+}
@@ -0,0 +1,24 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 18:02:11
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/domain/sms.go
+ * @Description: 短信领域
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package domain
+
+// AsyncSms 待异步重发的短信
+type AsyncSms struct {
+	Id      uint64   `json:"id"`
+	Numbers []string `json:"numbers"`
+	Tpl     string   `json:"tpl"`
+	Args    []string `json:"args"`
+	// 已经重试过的次数
+	RetryCnt int `json:"retry_cnt"`
+	// 最多重试次数
+	RetryMax int `json:"retry_max"`
+	// 抢占时拿到的版本号，上报结果时带上，证明还是自己抢到的
+	Version int64 `json:"version"`
+}
@@ -9,24 +9,103 @@
  */
 package domain
 
-import jwt "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
 
 type User struct {
-	Id       uint64  `json:"id"`
-	Email    string  `json:"email"`
-	Password string  `json:"password"`
-	Profile  Profile `json:"profile"`
+	Id       uint64     `json:"id"`
+	Email    string     `json:"email"`
+	Phone    string     `json:"phone"`
+	Password string     `json:"password"`
+	Status   UserStatus `json:"status"`
+	Profile  Profile    `json:"profile"`
 }
 
+type UserStatus uint8
+
+const (
+	// UserStatusActive 正常，取零值是为了老数据和手机号注册的用户不用迁移
+	UserStatusActive UserStatus = iota
+	// UserStatusPending 邮箱注册后还没点验证链接
+	UserStatusPending
+	// UserStatusDisabled 被禁用，不能登录
+	UserStatusDisabled
+	// UserStatusDeleted 已注销
+	UserStatusDeleted
+)
+
 type Profile struct {
 	UserId      uint64 `json:"user_id"`
 	NickName    string `json:"nick_name"`
 	BirthDay    int64  `json:"birth_day"`
 	Description string `json:"description"`
+	// 生日、邮箱别人能不能看到，昵称和简介总是公开的
+	BirthdayVisibility Visibility `json:"birthday_visibility"`
+	EmailVisibility    Visibility `json:"email_visibility"`
+}
+
+type Visibility uint8
+
+const (
+	// VisibilityPrivate 只有自己能看到，默认值
+	VisibilityPrivate Visibility = iota
+	VisibilityPublic
+)
+
+/**
+ * @description: 判断字段对查看的人是否可见，自己总能看到自己的
+ * @param {bool} self 是否本人在看
+ * @return {bool}
+ */
+func (v Visibility) VisibleTo(self bool) bool {
+	return self || v == VisibilityPublic
 }
 
 type UserClaims struct {
 	jwt.RegisteredClaims
-	Email     string
+	Uid   uint64
+	Email string
+	// 同一次登录签发的短token和长token共用一个 Ssid，登出时按 Ssid 作废
+	Ssid      string
 	UserAgent string
+	// 毫秒的签发时间，iat 只到秒，判断是不是作废之后签发的用这个，旧token没有
+	IssuedAtMilli int64
+}
+
+// IssuedTime 签发时间，没有的返回零值，按很早以前签发的处理
+func (c UserClaims) IssuedTime() time.Time {
+	return issuedTime(c.IssuedAt, c.IssuedAtMilli)
+}
+
+// RefreshClaims 长token只用来换短token，同一次登录的会话共用一个 Ssid
+type RefreshClaims struct {
+	jwt.RegisteredClaims
+	Uid           uint64
+	Email         string
+	Ssid          string
+	UserAgent     string
+	IssuedAtMilli int64
+}
+
+func (c RefreshClaims) IssuedTime() time.Time {
+	return issuedTime(c.IssuedAt, c.IssuedAtMilli)
+}
+
+func issuedTime(iat *jwt.NumericDate, milli int64) time.Time {
+	if milli != 0 {
+		return time.UnixMilli(milli)
+	}
+	if iat == nil {
+		return time.Time{}
+	}
+	return iat.Time
+}
+
+// LoginAttempt 某个账号或者IP的登录失败记录
+type LoginAttempt struct {
+	Failures    int
+	LastFailure time.Time
 }
@@ -13,7 +13,7 @@ import "gorm.io/gorm"
 
 type User struct {
 	gorm.Model
-	Id       uint   `gorm:"primarykey,autoIncrement`
-	Email    string `gorm:"column:email`
-	Password string `gorm:"column:password`
+	Id       uint   `gorm:"primarykey,autoIncrement"`
+	Email    string `gorm:"column:email"`
+	Password string `gorm:"column:password"`
 }
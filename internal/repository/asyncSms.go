@@ -0,0 +1,137 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 18:21:30
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/asyncSms.go
+ * @Description: 异步短信数据抽象层
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository/dao"
+)
+
+var (
+	ErrAsyncSmsNotFound = dao.ErrAsyncSmsNotFound
+	ErrAsyncSmsLost     = dao.ErrAsyncSmsLost
+)
+
+type AsyncSmsRepositoryInstance struct {
+	dao dao.AsyncSmsDAO
+	// 发送中超过这个时间没上报结果，认为发送的实例挂了
+	sendingTimeout time.Duration
+}
+
+func NewAsyncSmsRepository(dao dao.AsyncSmsDAO) AsyncSmsRepository {
+	return &AsyncSmsRepositoryInstance{
+		dao:            dao,
+		sendingTimeout: time.Minute,
+	}
+}
+
+type asyncSmsConfig struct {
+	Numbers []string `json:"numbers"`
+	Tpl     string   `json:"tpl"`
+	// 发完之后 dao 按 $.args 把参数删掉，改 json 名要一起改
+	Args []string `json:"args"`
+}
+
+/**
+ * @description: 加入异步发送队列
+ * @param {context.Context} ctx
+ * @param {domain.AsyncSms} sms
+ * @return {error}
+ */
+func (r *AsyncSmsRepositoryInstance) Add(ctx context.Context, sms domain.AsyncSms) error {
+	config, err := json.Marshal(asyncSmsConfig{
+		Numbers: sms.Numbers,
+		Tpl:     sms.Tpl,
+		Args:    sms.Args,
+	})
+	if err != nil {
+		return err
+	}
+	return r.dao.Insert(ctx, dao.AsyncSms{
+		Config:        string(config),
+		RetryMax:      sms.RetryMax,
+		Status:        dao.AsyncSmsStatusWaiting,
+		NextRetryTime: time.Now().UnixMilli(),
+	})
+}
+
+/**
+ * @description: 抢占一条待发送的短信
+ * @param {context.Context} ctx
+ * @return {domain.AsyncSms, error}
+ */
+func (r *AsyncSmsRepositoryInstance) PreemptWaitingSms(ctx context.Context) (domain.AsyncSms, error) {
+	sms, err := r.dao.Preempt(ctx, time.Now().UnixMilli(), r.sendingTimeout.Milliseconds())
+	if err != nil {
+		return domain.AsyncSms{}, err
+	}
+	var config asyncSmsConfig
+	if err = json.Unmarshal([]byte(sms.Config), &config); err != nil {
+		return domain.AsyncSms{}, err
+	}
+	return domain.AsyncSms{
+		Id:       sms.Id,
+		Numbers:  config.Numbers,
+		Tpl:      config.Tpl,
+		Args:     config.Args,
+		RetryCnt: sms.RetryCnt,
+		RetryMax: sms.RetryMax,
+		Version:  sms.Version,
+	}, nil
+}
+
+/**
+ * @description: 上报发送成功
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version
+ * @return {error}
+ */
+func (r *AsyncSmsRepositoryInstance) ReportSuccess(ctx context.Context, id uint64, version int64) error {
+	return r.dao.MarkSuccess(ctx, id, version)
+}
+
+/**
+ * @description: 上报发送失败，到时间后重试
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version
+ * @param {time.Time} nextRetryTime
+ * @return {error}
+ */
+func (r *AsyncSmsRepositoryInstance) ReportRetry(ctx context.Context, id uint64, version int64, nextRetryTime time.Time) error {
+	return r.dao.MarkRetry(ctx, id, version, nextRetryTime.UnixMilli())
+}
+
+/**
+ * @description: 上报发送失败，不再重试
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version
+ * @return {error}
+ */
+func (r *AsyncSmsRepositoryInstance) ReportFailed(ctx context.Context, id uint64, version int64) error {
+	return r.dao.MarkFailed(ctx, id, version)
+}
+
+/**
+ * @description: 放回队列，其他实例马上可以抢
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version
+ * @return {error}
+ */
+func (r *AsyncSmsRepositoryInstance) Release(ctx context.Context, id uint64, version int64) error {
+	return r.dao.MarkWaiting(ctx, id, version)
+}
@@ -0,0 +1,132 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 10:31:02
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/codeMemory.go
+ * @Description: 本地缓存验证码
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coocood/freecache"
+)
+
+// CodeMemoryCache 跟 CodeRedisCache 的 lua 脚本保持一样的语义，
+// 只在单机部署的时候用，用锁保证检查和写入是原子的
+type CodeMemoryCache struct {
+	cache        *freecache.Cache
+	lock         sync.Mutex
+	expiretion   time.Duration
+	interval     time.Duration
+	maxVerifyCnt int
+}
+
+type codeItem struct {
+	Code string `json:"code"`
+	Cnt  int    `json:"cnt"`
+}
+
+func NewCodeMemoryCache(client *freecache.Cache) CodeCache {
+	return &CodeMemoryCache{
+		cache:        client,
+		expiretion:   time.Minute * 10,
+		interval:     time.Minute,
+		maxVerifyCnt: 3,
+	}
+}
+
+/**
+ * @description: 保存验证码，重发间隔内不允许重复发送
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} phone
+ * @param {string} code
+ * @return {error}
+ */
+func (c *CodeMemoryCache) Set(ctx context.Context, biz string, phone string, code string) error {
+	key := c.getCodeCacheKey(biz, phone)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ttl, err := c.cache.TTL(key)
+	if err != nil && err != freecache.ErrNotFound {
+		return err
+	}
+	if err == nil && time.Duration(ttl)*time.Second > c.expiretion-c.interval {
+		return ErrCodeSendTooMany
+	}
+	item, err := json.Marshal(codeItem{
+		Code: code,
+		Cnt:  c.maxVerifyCnt,
+	})
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(key, item, int(c.expiretion.Seconds()))
+}
+
+/**
+ * @description: 校验验证码，校验通过后验证码失效
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} phone
+ * @param {string} inputCode
+ * @return {bool, error}
+ */
+func (c *CodeMemoryCache) Verify(ctx context.Context, biz string, phone string, inputCode string) (bool, error) {
+	key := c.getCodeCacheKey(biz, phone)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	result, err := c.cache.Get(key)
+	if err != nil {
+		if err == freecache.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	var item codeItem
+	err = json.Unmarshal(result, &item)
+	if err != nil {
+		return false, err
+	}
+	if item.Cnt <= 0 {
+		return false, ErrCodeVerifyTooManyTimes
+	}
+	if item.Code == inputCode {
+		c.cache.Del(key)
+		return true, nil
+	}
+
+	item.Cnt--
+	ttl, err := c.cache.TTL(key)
+	if err != nil || ttl == 0 {
+		// 刚好过期了，freecache 里过期时间传 0 是永不过期，不能写回去
+		return false, nil
+	}
+	result, err = json.Marshal(item)
+	if err != nil {
+		return false, err
+	}
+	return false, c.cache.Set(key, result, int(ttl))
+}
+
+/**
+ * @description: 验证码缓存key
+ * @param {string} biz
+ * @param {string} phone
+ * @return {[]byte}
+ */
+func (c *CodeMemoryCache) getCodeCacheKey(biz string, phone string) []byte {
+	return []byte(fmt.Sprintf("webook:code:%s:%s", biz, phone))
+}
@@ -0,0 +1,74 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 14:32:17
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/codeMemory_test.go
+ * @Description: 本地缓存验证码
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coocood/freecache"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCodeMemoryCache_Set(t *testing.T) {
+	c := NewCodeMemoryCache(freecache.NewCache(1024 * 1024))
+
+	err := c.Set(context.Background(), "login", "13800138000", "123456")
+	assert.Equal(t, nil, err)
+
+	// 一分钟内重发
+	err = c.Set(context.Background(), "login", "13800138000", "654321")
+	assert.Equal(t, ErrCodeSendTooMany, err)
+
+	// 不同业务互不影响
+	err = c.Set(context.Background(), "reset_password", "13800138000", "654321")
+	assert.Equal(t, nil, err)
+}
+
+func TestCodeMemoryCache_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []string
+		wantOk  []bool
+		wantErr []error
+	}{
+		{
+			name:    "一次通过后失效",
+			inputs:  []string{"123456", "123456"},
+			wantOk:  []bool{true, false},
+			wantErr: []error{nil, nil},
+		},
+		{
+			name:    "输错两次还能通过",
+			inputs:  []string{"000000", "000001", "123456"},
+			wantOk:  []bool{false, false, true},
+			wantErr: []error{nil, nil, nil},
+		},
+		{
+			name:    "输错三次后锁定",
+			inputs:  []string{"000000", "000001", "000002", "123456"},
+			wantOk:  []bool{false, false, false, false},
+			wantErr: []error{nil, nil, nil, ErrCodeVerifyTooManyTimes},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCodeMemoryCache(freecache.NewCache(1024 * 1024))
+			err := c.Set(context.Background(), "login", "13800138000", "123456")
+			assert.Equal(t, nil, err)
+
+			for i, input := range tt.inputs {
+				ok, err := c.Verify(context.Background(), "login", "13800138000", input)
+				assert.Equal(t, tt.wantOk[i], ok)
+				assert.Equal(t, tt.wantErr[i], err)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 10:12:37
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/codeRedis.go
+ * @Description: 验证码缓存
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+var (
+	//go:embed lua/set_code.lua
+	luaSetCode string
+	//go:embed lua/verify_code.lua
+	luaVerifyCode string
+)
+
+type CodeRedisCache struct {
+	cache        redis.Cmdable
+	expiretion   time.Duration
+	interval     time.Duration
+	maxVerifyCnt int
+}
+
+func NewCodeRedisCache(client redis.Cmdable) CodeCache {
+	return &CodeRedisCache{
+		cache:        client,
+		expiretion:   time.Minute * 10,
+		interval:     time.Minute,
+		maxVerifyCnt: 3,
+	}
+}
+
+/**
+ * @description: 保存验证码，重发间隔内不允许重复发送
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} phone
+ * @param {string} code
+ * @return {error}
+ */
+func (c *CodeRedisCache) Set(ctx context.Context, biz string, phone string, code string) error {
+	res, err := c.cache.Eval(ctx, luaSetCode, []string{c.getCodeCacheKey(biz, phone)},
+		code, int(c.expiretion.Seconds()), int(c.interval.Seconds()), c.maxVerifyCnt).Int()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case 0:
+		return nil
+	case -1:
+		return ErrCodeSendTooMany
+	default:
+		return ErrCodeUnknown
+	}
+}
+
+/**
+ * @description: 校验验证码，校验通过后验证码失效
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} phone
+ * @param {string} inputCode
+ * @return {bool, error}
+ */
+func (c *CodeRedisCache) Verify(ctx context.Context, biz string, phone string, inputCode string) (bool, error) {
+	res, err := c.cache.Eval(ctx, luaVerifyCode, []string{c.getCodeCacheKey(biz, phone)}, inputCode).Int()
+	if err != nil {
+		return false, err
+	}
+	switch res {
+	case 0:
+		return true, nil
+	case -1:
+		return false, ErrCodeVerifyTooManyTimes
+	default:
+		// 验证码不存在或者不对
+		return false, nil
+	}
+}
+
+/**
+ * @description: 验证码缓存key
+ * @param {string} biz
+ * @param {string} phone
+ * @return {string}
+ */
+func (c *CodeRedisCache) getCodeCacheKey(biz string, phone string) string {
+	return fmt.Sprintf("webook:code:%s:%s", biz, phone)
+}
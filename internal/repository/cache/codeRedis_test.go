@@ -0,0 +1,157 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 14:05:51
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/codeRedis_test.go
+ * @Description: 验证码缓存
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	redismocks "github.com/gz4z2b/go-webook/internal/repository/cache/mocks/redismocks"
+	redis "github.com/redis/go-redis/v9"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCodeRedisCache_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) redis.Cmdable
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(0))
+				mock.EXPECT().Eval(gomock.Any(), luaSetCode, []string{"webook:code:login:13800138000"},
+					"123456", 600, 60, 3).Return(cmd)
+				return mock
+			},
+			wantErr: nil,
+		},
+		{
+			name: "发送太频繁",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(-1))
+				mock.EXPECT().Eval(gomock.Any(), luaSetCode, gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			wantErr: ErrCodeSendTooMany,
+		},
+		{
+			name: "key没有过期时间",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(-2))
+				mock.EXPECT().Eval(gomock.Any(), luaSetCode, gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			wantErr: ErrCodeUnknown,
+		},
+		{
+			name: "缓存炸了",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetErr(errors.New("缓存炸了"))
+				mock.EXPECT().Eval(gomock.Any(), luaSetCode, gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			wantErr: errors.New("缓存炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c := NewCodeRedisCache(tt.mock(ctrl))
+			err := c.Set(context.Background(), "login", "13800138000", "123456")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestCodeRedisCache_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) redis.Cmdable
+		wantOk  bool
+		wantErr error
+	}{
+		{
+			name: "验证通过",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(0))
+				mock.EXPECT().Eval(gomock.Any(), luaVerifyCode, []string{"webook:code:login:13800138000"}, "123456").Return(cmd)
+				return mock
+			},
+			wantOk:  true,
+			wantErr: nil,
+		},
+		{
+			name: "验证码不对",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(-3))
+				mock.EXPECT().Eval(gomock.Any(), luaVerifyCode, gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			wantOk:  false,
+			wantErr: nil,
+		},
+		{
+			name: "验证码不存在",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(-2))
+				mock.EXPECT().Eval(gomock.Any(), luaVerifyCode, gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			wantOk:  false,
+			wantErr: nil,
+		},
+		{
+			name: "验证次数太多",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(-1))
+				mock.EXPECT().Eval(gomock.Any(), luaVerifyCode, gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			wantOk:  false,
+			wantErr: ErrCodeVerifyTooManyTimes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c := NewCodeRedisCache(tt.mock(ctrl))
+			ok, err := c.Verify(context.Background(), "login", "13800138000", "123456")
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 18:05:33
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/expiry.go
+ * @Description: 用户缓存的过期策略，软过期之后还能用一阵，过期时间加随机抖动
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+var (
+	// ErrCacheStale 过了软过期，返回的值还能用，调用方要去刷新
+	ErrCacheStale = errors.New("缓存已过软过期")
+	// ErrCacheNotFound 缓存里记着数据库没有这条数据，不用再查库
+	ErrCacheNotFound = errors.New("缓存记录数据不存在")
+)
+
+// cacheEntry 缓存里存的值，带上软过期时间
+type cacheEntry struct {
+	Value json.RawMessage `json:"v"`
+	// 毫秒时间戳，0 是加软过期之前写的旧格式
+	SoftExpireAt int64 `json:"s"`
+	// 数据库里没有，Value 是空的
+	NotFound bool `json:"n,omitempty"`
+}
+
+type expiry struct {
+	// 软过期时间，过了之后返回旧值并刷新
+	expiretion time.Duration
+	// 软过期之后还能用多久，真正从缓存里删掉是 expiretion + stale
+	stale time.Duration
+	// 0.1 表示 expiretion 上下随机浮动 10%，大量key同时写进来的时候不会同时过期
+	jitter float64
+	// 数据库里没有的记多久，要短，新建的数据在这之后一定查得到
+	notFound time.Duration
+	now      func() time.Time
+}
+
+// userExpiry 用户和档案改得少，15分钟后后台刷新，再过5分钟还没人读才真正过期；
+// 不存在的用户只记1分钟，挡住随机邮箱、遍历id这种反复查库
+func userExpiry() expiry {
+	return expiry{
+		expiretion: time.Minute * 15,
+		stale:      time.Minute * 5,
+		jitter:     0.1,
+		notFound:   time.Minute,
+		now:        time.Now,
+	}
+}
+
+/**
+ * @description: 编码要写进缓存的值
+ * @param {any} val
+ * @return {[]byte, time.Duration, error} 编码后的值和缓存的过期时间
+ */
+func (e expiry) encode(val any) ([]byte, time.Duration, error) {
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return nil, 0, err
+	}
+	soft := e.expiretion
+	if e.jitter > 0 {
+		soft += time.Duration((rand.Float64()*2 - 1) * e.jitter * float64(e.expiretion))
+	}
+	data, err := json.Marshal(cacheEntry{
+		Value:        raw,
+		SoftExpireAt: e.now().Add(soft).UnixMilli(),
+	})
+	return data, soft + e.stale, err
+}
+
+/**
+ * @description: 编码数据不存在的标记，不留旧值
+ * @return {[]byte, time.Duration, error} 编码后的值和缓存的过期时间
+ */
+func (e expiry) encodeNotFound() ([]byte, time.Duration, error) {
+	data, err := json.Marshal(cacheEntry{
+		SoftExpireAt: e.now().Add(e.notFound).UnixMilli(),
+		NotFound:     true,
+	})
+	return data, e.notFound, err
+}
+
+/**
+ * @description: 解码缓存里的值
+ * @param {[]byte} data
+ * @param {any} val
+ * @return {error} 过了软过期返回 ErrCacheStale，val 照样填好；记着数据不存在返回 ErrCacheNotFound；旧格式当作没有缓存
+ */
+func (e expiry) decode(data []byte, val any) error {
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+	if entry.SoftExpireAt == 0 {
+		return ErrCacheNotExist
+	}
+	if entry.NotFound {
+		return ErrCacheNotFound
+	}
+	if err := json.Unmarshal(entry.Value, val); err != nil {
+		return err
+	}
+	if e.now().UnixMilli() >= entry.SoftExpireAt {
+		return ErrCacheStale
+	}
+	return nil
+}
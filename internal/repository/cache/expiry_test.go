@@ -0,0 +1,88 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 18:40:17
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/expiry_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/repository/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiry_decode(t *testing.T) {
+	now := time.Now()
+	e := userExpiry()
+	e.now = func() time.Time { return now }
+	data, _, err := e.encode(user)
+	assert.NoError(t, err)
+	legacy, _ := json.Marshal(user)
+	notFound, ttl, err := e.encodeNotFound()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		after   time.Duration
+		wantId  uint64
+		wantErr error
+	}{
+		{
+			name:   "没过期",
+			data:   data,
+			wantId: 1,
+		},
+		{
+			name:    "过了软过期还能用",
+			data:    data,
+			after:   time.Minute * 17,
+			wantId:  1,
+			wantErr: ErrCacheStale,
+		},
+		{
+			name:    "记着不存在",
+			data:    notFound,
+			wantErr: ErrCacheNotFound,
+		},
+		{
+			name:    "旧格式当作没有",
+			data:    legacy,
+			wantErr: ErrCacheNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e
+			d.now = func() time.Time { return now.Add(tt.after) }
+			var got dao.User
+			err := d.decode(tt.data, &got)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantId, got.Id)
+		})
+	}
+}
+
+func TestExpiry_encodeJitter(t *testing.T) {
+	now := time.Now()
+	e := userExpiry()
+	e.now = func() time.Time { return now }
+	for i := 0; i < 100; i++ {
+		data, ttl, err := e.encode(profile)
+		assert.NoError(t, err)
+		var entry cacheEntry
+		assert.NoError(t, json.Unmarshal(data, &entry))
+		soft := time.UnixMilli(entry.SoftExpireAt).Sub(now)
+		// 15分钟上下浮动10%，真正过期再多5分钟
+		assert.True(t, soft >= time.Second*810-time.Millisecond && soft <= time.Second*990)
+		assert.InDelta(t, float64(soft+time.Minute*5), float64(ttl), float64(time.Millisecond))
+	}
+}
@@ -3,7 +3,7 @@
  * @Date: 2023-09-15 17:05:51
  * @LastEditors: p_hanxichen
  * @FilePath: /go/src/webook/internal/repository/cache/interface.go
- * @Description: 用户缓存接口
+ * @Description: 缓存接口
  *
  * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
  */
@@ -12,18 +12,63 @@ package cache
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/gz4z2b/go-webook/internal/repository/dao"
 )
 
 var (
-	ErrCacheNotExist error = errors.New("缓存key不存在")
+	ErrCacheNotExist          error = errors.New("缓存key不存在")
+	ErrCodeSendTooMany        error = errors.New("验证码发送太频繁")
+	ErrCodeVerifyTooManyTimes error = errors.New("验证码验证次数太多")
+	ErrCodeUnknown            error = errors.New("验证码缓存异常")
 )
 
 type UserCache interface {
 	FindUserById(ctx context.Context, id uint64) (dao.User, error)
 	FindUserByEmail(ctx context.Context, email string) (dao.User, error)
+	FindUserByPhone(ctx context.Context, phone string) (dao.User, error)
 	FindProfileByUser(ctx context.Context, user dao.User) (dao.Profile, error)
 	SetUser(ctx context.Context, user dao.User) error
 	SetProfile(ctx context.Context, profile dao.Profile) error
+	// SetUserNotFound 短时间记住数据库里没有的用户，user 只填查询用的id、邮箱或手机号，之后再查返回 ErrCacheNotFound
+	SetUserNotFound(ctx context.Context, user dao.User) error
+	SetProfileNotFound(ctx context.Context, userId uint64) error
+	// DeleteUser 删掉按id、邮箱、手机号缓存的用户
+	DeleteUser(ctx context.Context, user dao.User) error
+	DeleteProfile(ctx context.Context, userId uint64) error
+}
+
+// normalizeEmail 邮箱列是不区分大小写的排序规则，Foo@x.com 和 foo@x.com 是同一个用户，缓存key也要是同一个
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+type CodeCache interface {
+	Set(ctx context.Context, biz string, phone string, code string) error
+	Verify(ctx context.Context, biz string, phone string, inputCode string) (bool, error)
+}
+
+type SessionCache interface {
+	// Revoke 作废会话，expiretion 取会话里最长的token有效期
+	Revoke(ctx context.Context, ssid string, expiretion time.Duration) error
+	IsRevoked(ctx context.Context, ssid string) (bool, error)
+	// RevokeUser 作废用户在 before 之前签发的所有token，改密码之类的场景用
+	RevokeUser(ctx context.Context, uid uint64, before time.Time, expiretion time.Duration) error
+	// UserRevokedBefore 没有作废过返回零值
+	UserRevokedBefore(ctx context.Context, uid uint64) (time.Time, error)
+}
+
+type PasswordResetCache interface {
+	Set(ctx context.Context, uid uint64, nonce string, expiretion time.Duration) error
+	Consume(ctx context.Context, uid uint64, nonce string) (bool, error)
+}
+
+type LoginAttemptCache interface {
+	// Incr 记一次登录失败，返回累计次数，expiretion 从这次失败开始算
+	Incr(ctx context.Context, target string, now time.Time, expiretion time.Duration) (int, error)
+	// Get 没有失败记录返回 0 次
+	Get(ctx context.Context, target string) (int, time.Time, error)
+	Delete(ctx context.Context, target string) error
 }
@@ -0,0 +1,106 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 22:21:05
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/loginAttemptMemory.go
+ * @Description: 本地缓存记录登录失败次数
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coocood/freecache"
+)
+
+// LoginAttemptMemoryCache 值是 次数:最后失败的毫秒时间戳，用锁保证读改写是原子的
+type LoginAttemptMemoryCache struct {
+	cache *freecache.Cache
+	lock  sync.Mutex
+}
+
+func NewLoginAttemptMemoryCache(client *freecache.Cache) LoginAttemptCache {
+	return &LoginAttemptMemoryCache{
+		cache: client,
+	}
+}
+
+/**
+ * @description: 记一次失败
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @param {time.Time} now
+ * @param {time.Duration} expiretion
+ * @return {int, error} 累计失败次数
+ */
+func (l *LoginAttemptMemoryCache) Incr(ctx context.Context, target string, now time.Time, expiretion time.Duration) (int, error) {
+	key := l.getLoginAttemptKey(target)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	cnt, _, err := l.get(key)
+	if err != nil {
+		return 0, err
+	}
+	cnt++
+	val := fmt.Sprintf("%d:%d", cnt, now.UnixMilli())
+	// freecache 过期时间按秒算，不足一秒的往上取
+	err = l.cache.Set(key, []byte(val), int((expiretion+time.Second-1)/time.Second))
+	if err != nil {
+		return 0, err
+	}
+	return cnt, nil
+}
+
+/**
+ * @description: 查失败次数和最后一次失败的时间
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @return {int, time.Time, error}
+ */
+func (l *LoginAttemptMemoryCache) Get(ctx context.Context, target string) (int, time.Time, error) {
+	return l.get(l.getLoginAttemptKey(target))
+}
+
+/**
+ * @description: 清掉失败记录
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @return {error}
+ */
+func (l *LoginAttemptMemoryCache) Delete(ctx context.Context, target string) error {
+	l.cache.Del(l.getLoginAttemptKey(target))
+	return nil
+}
+
+func (l *LoginAttemptMemoryCache) get(key []byte) (int, time.Time, error) {
+	val, err := l.cache.Get(key)
+	if err == freecache.ErrNotFound {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	cntStr, lastStr, _ := strings.Cut(string(val), ":")
+	cnt, err := strconv.Atoi(cntStr)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	last, err := strconv.ParseInt(lastStr, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return cnt, time.UnixMilli(last), nil
+}
+
+func (l *LoginAttemptMemoryCache) getLoginAttemptKey(target string) []byte {
+	return []byte(fmt.Sprintf("webook:login_attempt:%s", target))
+}
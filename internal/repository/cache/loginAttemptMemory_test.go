@@ -0,0 +1,55 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 23:05:18
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/loginAttemptMemory_test.go
+ * @Description: 本地缓存记录登录失败次数
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coocood/freecache"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLoginAttemptMemoryCache(t *testing.T) {
+	c := NewLoginAttemptMemoryCache(freecache.NewCache(1024 * 1024))
+	ctx := context.Background()
+	first := time.UnixMilli(1760760000000)
+
+	// 没有记录
+	cnt, last, err := c.Get(ctx, "email:gz4z2b@163.com")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, cnt)
+	assert.Equal(t, time.Time{}, last)
+
+	cnt, err = c.Incr(ctx, "email:gz4z2b@163.com", first, time.Minute)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, cnt)
+	cnt, err = c.Incr(ctx, "email:gz4z2b@163.com", first.Add(time.Second), time.Minute)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, cnt)
+
+	// 记的是最后一次失败的时间
+	cnt, last, err = c.Get(ctx, "email:gz4z2b@163.com")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, cnt)
+	assert.Equal(t, first.Add(time.Second), last)
+
+	// 不同的 target 分开计数
+	cnt, _, err = c.Get(ctx, "ip:192.0.2.1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, cnt)
+
+	err = c.Delete(ctx, "email:gz4z2b@163.com")
+	assert.Equal(t, nil, err)
+	cnt, _, err = c.Get(ctx, "email:gz4z2b@163.com")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, cnt)
+}
@@ -0,0 +1,87 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 22:14:27
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/loginAttemptRedis.go
+ * @Description: redis记录登录失败次数
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/incr_login_failure.lua
+var luaIncrLoginFailure string
+
+type LoginAttemptRedisCache struct {
+	cache redis.Cmdable
+}
+
+func NewLoginAttemptRedisCache(client redis.Cmdable) LoginAttemptCache {
+	return &LoginAttemptRedisCache{
+		cache: client,
+	}
+}
+
+/**
+ * @description: 记一次失败，次数和最后失败时间放在同一个hash里
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @param {time.Time} now
+ * @param {time.Duration} expiretion
+ * @return {int, error} 累计失败次数
+ */
+func (l *LoginAttemptRedisCache) Incr(ctx context.Context, target string, now time.Time, expiretion time.Duration) (int, error) {
+	return l.cache.Eval(ctx, luaIncrLoginFailure, []string{l.getLoginAttemptKey(target)},
+		now.UnixMilli(), expiretion.Milliseconds()).Int()
+}
+
+/**
+ * @description: 查失败次数和最后一次失败的时间
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @return {int, time.Time, error}
+ */
+func (l *LoginAttemptRedisCache) Get(ctx context.Context, target string) (int, time.Time, error) {
+	vals, err := l.cache.HMGet(ctx, l.getLoginAttemptKey(target), "cnt", "last").Result()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	cntStr, _ := vals[0].(string)
+	lastStr, _ := vals[1].(string)
+	if cntStr == "" {
+		return 0, time.Time{}, nil
+	}
+	cnt, err := strconv.Atoi(cntStr)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	last, err := strconv.ParseInt(lastStr, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return cnt, time.UnixMilli(last), nil
+}
+
+/**
+ * @description: 清掉失败记录
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @return {error}
+ */
+func (l *LoginAttemptRedisCache) Delete(ctx context.Context, target string) error {
+	return l.cache.Del(ctx, l.getLoginAttemptKey(target)).Err()
+}
+
+func (l *LoginAttemptRedisCache) getLoginAttemptKey(target string) string {
+	return fmt.Sprintf("webook:login_attempt:%s", target)
+}
@@ -0,0 +1,10 @@
+local key = KEYS[1]
+local nonce = ARGV[1]
+
+if redis.call("get", key) == nonce then
+    -- 一次性的，用过就删
+    redis.call("del", key)
+    return 1
+end
+-- 没有、过期了、或者已经被新链接顶掉
+return 0
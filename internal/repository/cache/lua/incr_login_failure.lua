@@ -0,0 +1,9 @@
+-- 记一次登录失败，过期时间从最后一次失败开始算
+local key = KEYS[1]
+local now = ARGV[1]
+local expiretion = tonumber(ARGV[2])
+
+local cnt = redis.call("hincrby", key, "cnt", 1)
+redis.call("hset", key, "last", now)
+redis.call("pexpire", key, expiretion)
+return cnt
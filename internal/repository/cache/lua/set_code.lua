@@ -0,0 +1,24 @@
+-- 验证码key，形如 webook:code:login:13800138000
+local key = KEYS[1]
+-- 剩余可验证次数
+local cntKey = key..":cnt"
+local code = ARGV[1]
+-- 有效期，秒
+local expiration = tonumber(ARGV[2])
+-- 重发间隔，秒
+local interval = tonumber(ARGV[3])
+local maxCnt = tonumber(ARGV[4])
+
+local ttl = tonumber(redis.call("ttl", key))
+if ttl == -1 then
+    -- key存在但是没有过期时间，被人手动改过
+    return -2
+elseif ttl == -2 or ttl < expiration - interval then
+    -- 不存在，或者距离上次发送已经超过重发间隔
+    redis.call("set", key, code, "EX", expiration)
+    redis.call("set", cntKey, maxCnt, "EX", expiration)
+    return 0
+else
+    -- 发送太频繁
+    return -1
+end
@@ -0,0 +1,24 @@
+local key = KEYS[1]
+local cntKey = key..":cnt"
+local inputCode = ARGV[1]
+
+local cnt = tonumber(redis.call("get", cntKey))
+if cnt == nil then
+    -- 没发过或者已经过期
+    return -2
+end
+if cnt <= 0 then
+    -- 验证次数用完了
+    return -1
+end
+
+local code = redis.call("get", key)
+if code == inputCode then
+    -- 验证通过，验证码作废
+    redis.call("del", key)
+    redis.call("del", cntKey)
+    return 0
+else
+    redis.call("decr", cntKey)
+    return -3
+end
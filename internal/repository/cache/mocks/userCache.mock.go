@@ -0,0 +1,438 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/cache/interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/repository/cache/interface.go -package=cachemocks -destination=./internal/repository/cache/mocks/userCache.mock.go
+//
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	dao "github.com/gz4z2b/go-webook/internal/repository/dao"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserCache is a mock of UserCache interface.
+type MockUserCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserCacheMockRecorder
+}
+
+// MockUserCacheMockRecorder is the mock recorder for MockUserCache.
+type MockUserCacheMockRecorder struct {
+	mock *MockUserCache
+}
+
+// NewMockUserCache creates a new mock instance.
+func NewMockUserCache(ctrl *gomock.Controller) *MockUserCache {
+	mock := &MockUserCache{ctrl: ctrl}
+	mock.recorder = &MockUserCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserCache) EXPECT() *MockUserCacheMockRecorder {
+	return m.recorder
+}
+
+// DeleteProfile mocks base method.
+func (m *MockUserCache) DeleteProfile(ctx context.Context, userId uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProfile", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProfile indicates an expected call of DeleteProfile.
+func (mr *MockUserCacheMockRecorder) DeleteProfile(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProfile", reflect.TypeOf((*MockUserCache)(nil).DeleteProfile), ctx, userId)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUserCache) DeleteUser(ctx context.Context, user dao.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserCacheMockRecorder) DeleteUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserCache)(nil).DeleteUser), ctx, user)
+}
+
+// FindProfileByUser mocks base method.
+func (m *MockUserCache) FindProfileByUser(ctx context.Context, user dao.User) (dao.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProfileByUser", ctx, user)
+	ret0, _ := ret[0].(dao.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindProfileByUser indicates an expected call of FindProfileByUser.
+func (mr *MockUserCacheMockRecorder) FindProfileByUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProfileByUser", reflect.TypeOf((*MockUserCache)(nil).FindProfileByUser), ctx, user)
+}
+
+// FindUserByEmail mocks base method.
+func (m *MockUserCache) FindUserByEmail(ctx context.Context, email string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserByEmail", ctx, email)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserByEmail indicates an expected call of FindUserByEmail.
+func (mr *MockUserCacheMockRecorder) FindUserByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByEmail", reflect.TypeOf((*MockUserCache)(nil).FindUserByEmail), ctx, email)
+}
+
+// FindUserById mocks base method.
+func (m *MockUserCache) FindUserById(ctx context.Context, id uint64) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserById", ctx, id)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserById indicates an expected call of FindUserById.
+func (mr *MockUserCacheMockRecorder) FindUserById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserById", reflect.TypeOf((*MockUserCache)(nil).FindUserById), ctx, id)
+}
+
+// FindUserByPhone mocks base method.
+func (m *MockUserCache) FindUserByPhone(ctx context.Context, phone string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserByPhone", ctx, phone)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserByPhone indicates an expected call of FindUserByPhone.
+func (mr *MockUserCacheMockRecorder) FindUserByPhone(ctx, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByPhone", reflect.TypeOf((*MockUserCache)(nil).FindUserByPhone), ctx, phone)
+}
+
+// SetProfile mocks base method.
+func (m *MockUserCache) SetProfile(ctx context.Context, profile dao.Profile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProfile", ctx, profile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProfile indicates an expected call of SetProfile.
+func (mr *MockUserCacheMockRecorder) SetProfile(ctx, profile any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProfile", reflect.TypeOf((*MockUserCache)(nil).SetProfile), ctx, profile)
+}
+
+// SetProfileNotFound mocks base method.
+func (m *MockUserCache) SetProfileNotFound(ctx context.Context, userId uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProfileNotFound", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProfileNotFound indicates an expected call of SetProfileNotFound.
+func (mr *MockUserCacheMockRecorder) SetProfileNotFound(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProfileNotFound", reflect.TypeOf((*MockUserCache)(nil).SetProfileNotFound), ctx, userId)
+}
+
+// SetUser mocks base method.
+func (m *MockUserCache) SetUser(ctx context.Context, user dao.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUser indicates an expected call of SetUser.
+func (mr *MockUserCacheMockRecorder) SetUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUser", reflect.TypeOf((*MockUserCache)(nil).SetUser), ctx, user)
+}
+
+// SetUserNotFound mocks base method.
+func (m *MockUserCache) SetUserNotFound(ctx context.Context, user dao.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserNotFound", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserNotFound indicates an expected call of SetUserNotFound.
+func (mr *MockUserCacheMockRecorder) SetUserNotFound(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserNotFound", reflect.TypeOf((*MockUserCache)(nil).SetUserNotFound), ctx, user)
+}
+
+// MockCodeCache is a mock of CodeCache interface.
+type MockCodeCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockCodeCacheMockRecorder
+}
+
+// MockCodeCacheMockRecorder is the mock recorder for MockCodeCache.
+type MockCodeCacheMockRecorder struct {
+	mock *MockCodeCache
+}
+
+// NewMockCodeCache creates a new mock instance.
+func NewMockCodeCache(ctrl *gomock.Controller) *MockCodeCache {
+	mock := &MockCodeCache{ctrl: ctrl}
+	mock.recorder = &MockCodeCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCodeCache) EXPECT() *MockCodeCacheMockRecorder {
+	return m.recorder
+}
+
+// Set mocks base method.
+func (m *MockCodeCache) Set(ctx context.Context, biz, phone, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, biz, phone, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockCodeCacheMockRecorder) Set(ctx, biz, phone, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCodeCache)(nil).Set), ctx, biz, phone, code)
+}
+
+// Verify mocks base method.
+func (m *MockCodeCache) Verify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, biz, phone, inputCode)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockCodeCacheMockRecorder) Verify(ctx, biz, phone, inputCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockCodeCache)(nil).Verify), ctx, biz, phone, inputCode)
+}
+
+// MockSessionCache is a mock of SessionCache interface.
+type MockSessionCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionCacheMockRecorder
+}
+
+// MockSessionCacheMockRecorder is the mock recorder for MockSessionCache.
+type MockSessionCacheMockRecorder struct {
+	mock *MockSessionCache
+}
+
+// NewMockSessionCache creates a new mock instance.
+func NewMockSessionCache(ctrl *gomock.Controller) *MockSessionCache {
+	mock := &MockSessionCache{ctrl: ctrl}
+	mock.recorder = &MockSessionCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionCache) EXPECT() *MockSessionCacheMockRecorder {
+	return m.recorder
+}
+
+// IsRevoked mocks base method.
+func (m *MockSessionCache) IsRevoked(ctx context.Context, ssid string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", ctx, ssid)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockSessionCacheMockRecorder) IsRevoked(ctx, ssid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockSessionCache)(nil).IsRevoked), ctx, ssid)
+}
+
+// Revoke mocks base method.
+func (m *MockSessionCache) Revoke(ctx context.Context, ssid string, expiretion time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, ssid, expiretion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockSessionCacheMockRecorder) Revoke(ctx, ssid, expiretion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockSessionCache)(nil).Revoke), ctx, ssid, expiretion)
+}
+
+// RevokeUser mocks base method.
+func (m *MockSessionCache) RevokeUser(ctx context.Context, uid uint64, before time.Time, expiretion time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUser", ctx, uid, before, expiretion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUser indicates an expected call of RevokeUser.
+func (mr *MockSessionCacheMockRecorder) RevokeUser(ctx, uid, before, expiretion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUser", reflect.TypeOf((*MockSessionCache)(nil).RevokeUser), ctx, uid, before, expiretion)
+}
+
+// UserRevokedBefore mocks base method.
+func (m *MockSessionCache) UserRevokedBefore(ctx context.Context, uid uint64) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserRevokedBefore", ctx, uid)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserRevokedBefore indicates an expected call of UserRevokedBefore.
+func (mr *MockSessionCacheMockRecorder) UserRevokedBefore(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserRevokedBefore", reflect.TypeOf((*MockSessionCache)(nil).UserRevokedBefore), ctx, uid)
+}
+
+// MockPasswordResetCache is a mock of PasswordResetCache interface.
+type MockPasswordResetCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetCacheMockRecorder
+}
+
+// MockPasswordResetCacheMockRecorder is the mock recorder for MockPasswordResetCache.
+type MockPasswordResetCacheMockRecorder struct {
+	mock *MockPasswordResetCache
+}
+
+// NewMockPasswordResetCache creates a new mock instance.
+func NewMockPasswordResetCache(ctrl *gomock.Controller) *MockPasswordResetCache {
+	mock := &MockPasswordResetCache{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetCache) EXPECT() *MockPasswordResetCacheMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockPasswordResetCache) Consume(ctx context.Context, uid uint64, nonce string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, uid, nonce)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockPasswordResetCacheMockRecorder) Consume(ctx, uid, nonce any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockPasswordResetCache)(nil).Consume), ctx, uid, nonce)
+}
+
+// Set mocks base method.
+func (m *MockPasswordResetCache) Set(ctx context.Context, uid uint64, nonce string, expiretion time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, uid, nonce, expiretion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockPasswordResetCacheMockRecorder) Set(ctx, uid, nonce, expiretion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockPasswordResetCache)(nil).Set), ctx, uid, nonce, expiretion)
+}
+
+// MockLoginAttemptCache is a mock of LoginAttemptCache interface.
+type MockLoginAttemptCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptCacheMockRecorder
+}
+
+// MockLoginAttemptCacheMockRecorder is the mock recorder for MockLoginAttemptCache.
+type MockLoginAttemptCacheMockRecorder struct {
+	mock *MockLoginAttemptCache
+}
+
+// NewMockLoginAttemptCache creates a new mock instance.
+func NewMockLoginAttemptCache(ctrl *gomock.Controller) *MockLoginAttemptCache {
+	mock := &MockLoginAttemptCache{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttemptCache) EXPECT() *MockLoginAttemptCacheMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockLoginAttemptCache) Delete(ctx context.Context, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockLoginAttemptCacheMockRecorder) Delete(ctx, target any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockLoginAttemptCache)(nil).Delete), ctx, target)
+}
+
+// Get mocks base method.
+func (m *MockLoginAttemptCache) Get(ctx context.Context, target string) (int, time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, target)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLoginAttemptCacheMockRecorder) Get(ctx, target any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLoginAttemptCache)(nil).Get), ctx, target)
+}
+
+// Incr mocks base method.
+func (m *MockLoginAttemptCache) Incr(ctx context.Context, target string, now time.Time, expiretion time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, target, now, expiretion)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockLoginAttemptCacheMockRecorder) Incr(ctx, target, now, expiretion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockLoginAttemptCache)(nil).Incr), ctx, target, now, expiretion)
+}
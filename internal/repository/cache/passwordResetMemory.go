@@ -0,0 +1,76 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:26:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/passwordResetMemory.go
+ * @Description: 重置密码链接的一次性凭证，单机版
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coocood/freecache"
+)
+
+// PasswordResetMemoryCache 用锁保证比较和删除是原子的，跟 lua 脚本的语义一样
+type PasswordResetMemoryCache struct {
+	cache *freecache.Cache
+	lock  sync.Mutex
+}
+
+func NewPasswordResetMemoryCache(client *freecache.Cache) PasswordResetCache {
+	return &PasswordResetMemoryCache{
+		cache: client,
+	}
+}
+
+/**
+ * @description: 保存凭证，每个用户只保留最新的一个，旧链接自动失效
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @param {string} nonce
+ * @param {time.Duration} expiretion
+ * @return {error}
+ */
+func (p *PasswordResetMemoryCache) Set(ctx context.Context, uid uint64, nonce string, expiretion time.Duration) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.cache.Set(p.getPasswordResetKey(uid), []byte(nonce), int(expiretion.Seconds()))
+}
+
+/**
+ * @description: 使用凭证，对得上就删掉并返回 true
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @param {string} nonce
+ * @return {bool, error}
+ */
+func (p *PasswordResetMemoryCache) Consume(ctx context.Context, uid uint64, nonce string) (bool, error) {
+	key := p.getPasswordResetKey(uid)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	val, err := p.cache.Get(key)
+	if err == freecache.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if string(val) != nonce {
+		return false, nil
+	}
+	p.cache.Del(key)
+	return true, nil
+}
+
+func (p *PasswordResetMemoryCache) getPasswordResetKey(uid uint64) []byte {
+	return []byte(fmt.Sprintf("webook:password_reset:%d", uid))
+}
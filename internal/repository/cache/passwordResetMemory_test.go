@@ -0,0 +1,48 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 19:32:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/passwordResetMemory_test.go
+ * @Description: 本地缓存重置密码凭证
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coocood/freecache"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestPasswordResetMemoryCache_Consume(t *testing.T) {
+	c := NewPasswordResetMemoryCache(freecache.NewCache(1024 * 1024))
+	ctx := context.Background()
+
+	err := c.Set(ctx, 1, "old", time.Minute)
+	assert.Equal(t, nil, err)
+	// 重新发邮件，旧链接作废
+	err = c.Set(ctx, 1, "new", time.Minute)
+	assert.Equal(t, nil, err)
+
+	ok, err := c.Consume(ctx, 1, "old")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ok)
+
+	// 别的用户的凭证不能用
+	ok, err = c.Consume(ctx, 2, "new")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ok)
+
+	ok, err = c.Consume(ctx, 1, "new")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	// 只能用一次
+	ok, err = c.Consume(ctx, 1, "new")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ok)
+}
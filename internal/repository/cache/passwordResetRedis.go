@@ -0,0 +1,63 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:20:14
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/passwordResetRedis.go
+ * @Description: 重置密码链接的一次性凭证
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/consume_password_reset.lua
+var luaConsumePasswordReset string
+
+type PasswordResetRedisCache struct {
+	cache redis.Cmdable
+}
+
+func NewPasswordResetRedisCache(client redis.Cmdable) PasswordResetCache {
+	return &PasswordResetRedisCache{
+		cache: client,
+	}
+}
+
+/**
+ * @description: 保存凭证，每个用户只保留最新的一个，旧链接自动失效
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @param {string} nonce
+ * @param {time.Duration} expiretion
+ * @return {error}
+ */
+func (p *PasswordResetRedisCache) Set(ctx context.Context, uid uint64, nonce string, expiretion time.Duration) error {
+	return p.cache.Set(ctx, p.getPasswordResetKey(uid), nonce, expiretion).Err()
+}
+
+/**
+ * @description: 使用凭证，对得上就删掉并返回 true
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @param {string} nonce
+ * @return {bool, error}
+ */
+func (p *PasswordResetRedisCache) Consume(ctx context.Context, uid uint64, nonce string) (bool, error) {
+	res, err := p.cache.Eval(ctx, luaConsumePasswordReset, []string{p.getPasswordResetKey(uid)}, nonce).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (p *PasswordResetRedisCache) getPasswordResetKey(uid uint64) string {
+	return fmt.Sprintf("webook:password_reset:%d", uid)
+}
@@ -0,0 +1,98 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 19:55:12
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/sessionMemory.go
+ * @Description: 本地缓存登录会话
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coocood/freecache"
+)
+
+type SessionMemoryCache struct {
+	cache *freecache.Cache
+}
+
+func NewSessionMemoryCache(client *freecache.Cache) SessionCache {
+	return &SessionMemoryCache{
+		cache: client,
+	}
+}
+
+/**
+ * @description: 作废会话
+ * @param {context.Context} ctx
+ * @param {string} ssid
+ * @param {time.Duration} expiretion
+ * @return {error}
+ */
+func (s *SessionMemoryCache) Revoke(ctx context.Context, ssid string, expiretion time.Duration) error {
+	return s.cache.Set([]byte(s.getSessionRevokedKey(ssid)), []byte("1"), int(expiretion.Seconds()))
+}
+
+/**
+ * @description: 会话是否已经作废
+ * @param {context.Context} ctx
+ * @param {string} ssid
+ * @return {bool, error}
+ */
+func (s *SessionMemoryCache) IsRevoked(ctx context.Context, ssid string) (bool, error) {
+	_, err := s.cache.Get([]byte(s.getSessionRevokedKey(ssid)))
+	if err == freecache.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+/**
+ * @description: 作废用户在 before 之前签发的所有token，记到毫秒，跟 jwt 的签发时间精度一致
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @param {time.Time} before
+ * @param {time.Duration} expiretion
+ * @return {error}
+ */
+func (s *SessionMemoryCache) RevokeUser(ctx context.Context, uid uint64, before time.Time, expiretion time.Duration) error {
+	return s.cache.Set([]byte(s.getUserRevokedKey(uid)), []byte(strconv.FormatInt(before.UnixMilli(), 10)), int(expiretion.Seconds()))
+}
+
+/**
+ * @description: 用户的token在什么时间之前签发的都已作废
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @return {time.Time, error}
+ */
+func (s *SessionMemoryCache) UserRevokedBefore(ctx context.Context, uid uint64) (time.Time, error) {
+	val, err := s.cache.Get([]byte(s.getUserRevokedKey(uid)))
+	if err == freecache.ErrNotFound {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	before, err := strconv.ParseInt(string(val), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(before), nil
+}
+
+func (s *SessionMemoryCache) getUserRevokedKey(uid uint64) string {
+	return fmt.Sprintf("webook:session:user_revoked:%d", uid)
+}
+
+func (s *SessionMemoryCache) getSessionRevokedKey(ssid string) string {
+	return fmt.Sprintf("webook:session:revoked:%s", ssid)
+}
@@ -0,0 +1,102 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 19:48:30
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/sessionRedis.go
+ * @Description: 登录会话缓存
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+type SessionRedisCache struct {
+	cache redis.Cmdable
+}
+
+func NewSessionRedisCache(client redis.Cmdable) SessionCache {
+	return &SessionRedisCache{
+		cache: client,
+	}
+}
+
+/**
+ * @description: 作废会话
+ * @param {context.Context} ctx
+ * @param {string} ssid
+ * @param {time.Duration} expiretion
+ * @return {error}
+ */
+func (s *SessionRedisCache) Revoke(ctx context.Context, ssid string, expiretion time.Duration) error {
+	return s.cache.Set(ctx, s.getSessionRevokedKey(ssid), "1", expiretion).Err()
+}
+
+/**
+ * @description: 会话是否已经作废
+ * @param {context.Context} ctx
+ * @param {string} ssid
+ * @return {bool, error}
+ */
+func (s *SessionRedisCache) IsRevoked(ctx context.Context, ssid string) (bool, error) {
+	cnt, err := s.cache.Exists(ctx, s.getSessionRevokedKey(ssid)).Result()
+	if err != nil {
+		return false, err
+	}
+	return cnt > 0, nil
+}
+
+/**
+ * @description: 作废用户在 before 之前签发的所有token，记到毫秒，跟 jwt 的签发时间精度一致
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @param {time.Time} before
+ * @param {time.Duration} expiretion
+ * @return {error}
+ */
+func (s *SessionRedisCache) RevokeUser(ctx context.Context, uid uint64, before time.Time, expiretion time.Duration) error {
+	return s.cache.Set(ctx, s.getUserRevokedKey(uid), before.UnixMilli(), expiretion).Err()
+}
+
+/**
+ * @description: 用户的token在什么时间之前签发的都已作废
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @return {time.Time, error}
+ */
+func (s *SessionRedisCache) UserRevokedBefore(ctx context.Context, uid uint64) (time.Time, error) {
+	before, err := s.cache.Get(ctx, s.getUserRevokedKey(uid)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return revokedTime(before), nil
+}
+
+func (s *SessionRedisCache) getUserRevokedKey(uid uint64) string {
+	return fmt.Sprintf("webook:session:user_revoked:%d", uid)
+}
+
+func (s *SessionRedisCache) getSessionRevokedKey(ssid string) string {
+	return fmt.Sprintf("webook:session:revoked:%s", ssid)
+}
+
+/**
+ * @description: 以前存的是秒，升级前写进去的记录还没过期，按数量级区分
+ * @param {int64} before
+ * @return {time.Time}
+ */
+func revokedTime(before int64) time.Time {
+	if before < 1e12 {
+		return time.Unix(before, 0)
+	}
+	return time.UnixMilli(before)
+}
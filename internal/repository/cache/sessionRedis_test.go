@@ -0,0 +1,119 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 20:52:09
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/sessionRedis_test.go
+ * @Description: 登录会话缓存
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	redismocks "github.com/gz4z2b/go-webook/internal/repository/cache/mocks/redismocks"
+	redis "github.com/redis/go-redis/v9"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSessionRedisCache_IsRevoked(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) redis.Cmdable
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "已作废",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewIntCmd(context.Background())
+				cmd.SetVal(1)
+				mock.EXPECT().Exists(gomock.Any(), "webook:session:revoked:ssid-1").Return(cmd)
+				return mock
+			},
+			want: true,
+		},
+		{
+			name: "没作废",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewIntCmd(context.Background())
+				cmd.SetVal(0)
+				mock.EXPECT().Exists(gomock.Any(), "webook:session:revoked:ssid-1").Return(cmd)
+				return mock
+			},
+			want: false,
+		},
+		{
+			name: "缓存炸了",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewIntCmd(context.Background())
+				cmd.SetErr(errors.New("缓存炸了"))
+				mock.EXPECT().Exists(gomock.Any(), "webook:session:revoked:ssid-1").Return(cmd)
+				return mock
+			},
+			want:    false,
+			wantErr: errors.New("缓存炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c := NewSessionRedisCache(tt.mock(ctrl))
+			revoked, err := c.IsRevoked(context.Background(), "ssid-1")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, revoked)
+		})
+	}
+}
+
+func TestSessionRedisCache_UserRevokedBefore(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		err     error
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name: "毫秒",
+			val:  "1760760000123",
+			want: time.UnixMilli(1760760000123),
+		},
+		{
+			name: "升级前存的秒",
+			val:  "1760760000",
+			want: time.Unix(1760760000, 0),
+		},
+		{
+			name: "没作废过",
+			err:  redis.Nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := redismocks.NewMockCmdable(ctrl)
+			cmd := redis.NewStringCmd(context.Background())
+			cmd.SetVal(tt.val)
+			cmd.SetErr(tt.err)
+			mock.EXPECT().Get(gomock.Any(), "webook:session:user_revoked:1").Return(cmd)
+
+			c := NewSessionRedisCache(mock)
+			before, err := c.UserRevokedBefore(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, before)
+		})
+	}
+}
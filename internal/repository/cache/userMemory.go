@@ -11,23 +11,21 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/coocood/freecache"
 	"github.com/gz4z2b/go-webook/internal/repository/dao"
 )
 
 type UserMemoryCache struct {
-	cache      *freecache.Cache
-	expiretion time.Duration
+	cache  *freecache.Cache
+	expiry expiry
 }
 
 func NewUserMemoryCache(client *freecache.Cache) UserCache {
 	return &UserMemoryCache{
-		cache:      client,
-		expiretion: time.Minute * 15,
+		cache:  client,
+		expiry: userExpiry(),
 	}
 }
 
@@ -47,7 +45,7 @@ func (u *UserMemoryCache) FindUserById(ctx context.Context, id uint64) (dao.User
 		return dao.User{}, err
 	}
 	var user dao.User
-	err = json.Unmarshal(result, &user)
+	err = u.expiry.decode(result, &user)
 	return user, err
 }
 
@@ -67,7 +65,27 @@ func (u *UserMemoryCache) FindUserByEmail(ctx context.Context, email string) (da
 		return dao.User{}, err
 	}
 	var user dao.User
-	err = json.Unmarshal(result, &user)
+	err = u.expiry.decode(result, &user)
+	return user, err
+}
+
+/**
+ * @description: 根据手机号获取用户
+ * @param {context.Context} ctx
+ * @param {string} phone
+ * @return {dao.User, error}
+ */
+func (u *UserMemoryCache) FindUserByPhone(ctx context.Context, phone string) (dao.User, error) {
+	key := u.getUserCachePhoneKey(phone)
+	result, err := u.cache.Get(key)
+	if err != nil {
+		if err == freecache.ErrNotFound {
+			return dao.User{}, ErrCacheNotExist
+		}
+		return dao.User{}, err
+	}
+	var user dao.User
+	err = u.expiry.decode(result, &user)
 	return user, err
 }
 
@@ -87,7 +105,7 @@ func (u *UserMemoryCache) FindProfileByUser(ctx context.Context, user dao.User)
 		return dao.Profile{}, err
 	}
 	var profile dao.Profile
-	err = json.Unmarshal(result, &profile)
+	err = u.expiry.decode(result, &profile)
 	return profile, err
 }
 
@@ -98,17 +116,25 @@ func (u *UserMemoryCache) FindProfileByUser(ctx context.Context, user dao.User)
  * @return {error}
  */
 func (u *UserMemoryCache) SetUser(ctx context.Context, user dao.User) error {
-	setStr, err := json.Marshal(user)
+	setStr, expiretion, err := u.expiry.encode(user)
 	if err != nil {
 		return err
 	}
-	err = u.cache.Set(u.getUserCacheKey(user.Id), setStr, int(u.expiretion.Seconds()))
+	err = u.cache.Set(u.getUserCacheKey(user.Id), setStr, int(expiretion.Seconds()))
 	if err != nil {
 		return err
 	}
-	err = u.cache.Set(u.getUserCacheEmailKey(user.Email), setStr, int(u.expiretion.Seconds()))
-	if err != nil {
-		return err
+	if user.Email.Valid {
+		err = u.cache.Set(u.getUserCacheEmailKey(user.Email.String), setStr, int(expiretion.Seconds()))
+		if err != nil {
+			return err
+		}
+	}
+	if user.Phone.Valid {
+		err = u.cache.Set(u.getUserCachePhoneKey(user.Phone.String), setStr, int(expiretion.Seconds()))
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -120,14 +146,91 @@ func (u *UserMemoryCache) SetUser(ctx context.Context, user dao.User) error {
  * @return {error}
  */
 func (u *UserMemoryCache) SetProfile(ctx context.Context, profile dao.Profile) error {
-	setStr, err := json.Marshal(profile)
+	setStr, expiretion, err := u.expiry.encode(profile)
+	if err != nil {
+		return err
+	}
+	err = u.cache.Set(u.getProfileCacheUserKey(profile.UserId), setStr, int(expiretion.Seconds()))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+/**
+ * @description: 记下数据库里没有这个用户，只写 user 里填了的id、邮箱、手机号，新建用户时 SetUser 会覆盖掉
+ * @param {context.Context} ctx
+ * @param {dao.User} user 只填查询用的字段
+ * @return {error}
+ */
+func (u *UserMemoryCache) SetUserNotFound(ctx context.Context, user dao.User) error {
+	setStr, expiretion, err := u.expiry.encodeNotFound()
 	if err != nil {
 		return err
 	}
-	err = u.cache.Set(u.getProfileCacheUserKey(profile.UserId), setStr, int(u.expiretion.Seconds()))
+	if user.Id != 0 {
+		err = u.cache.Set(u.getUserCacheKey(user.Id), setStr, int(expiretion.Seconds()))
+		if err != nil {
+			return err
+		}
+	}
+	if user.Email.Valid {
+		err = u.cache.Set(u.getUserCacheEmailKey(user.Email.String), setStr, int(expiretion.Seconds()))
+		if err != nil {
+			return err
+		}
+	}
+	if user.Phone.Valid {
+		err = u.cache.Set(u.getUserCachePhoneKey(user.Phone.String), setStr, int(expiretion.Seconds()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * @description: 记下这个用户还没有档案，添加档案时 SetProfile 会覆盖掉
+ * @param {context.Context} ctx
+ * @param {uint64} userId
+ * @return {error}
+ */
+func (u *UserMemoryCache) SetProfileNotFound(ctx context.Context, userId uint64) error {
+	setStr, expiretion, err := u.expiry.encodeNotFound()
 	if err != nil {
 		return err
 	}
+	return u.cache.Set(u.getProfileCacheUserKey(userId), setStr, int(expiretion.Seconds()))
+}
+
+/**
+ * @description: 删除用户缓存，用户信息改了之后调用
+ * @param {context.Context} ctx
+ * @param {dao.User} user
+ * @return {error}
+ */
+func (u *UserMemoryCache) DeleteUser(ctx context.Context, user dao.User) error {
+	keys := [][]byte{u.getUserCacheKey(user.Id)}
+	if user.Email.Valid {
+		keys = append(keys, u.getUserCacheEmailKey(user.Email.String))
+	}
+	if user.Phone.Valid {
+		keys = append(keys, u.getUserCachePhoneKey(user.Phone.String))
+	}
+	for _, key := range keys {
+		u.cache.Del(key)
+	}
+	return nil
+}
+
+/**
+ * @description: 删除用户档案缓存
+ * @param {context.Context} ctx
+ * @param {uint64} userId
+ * @return {error}
+ */
+func (u *UserMemoryCache) DeleteProfile(ctx context.Context, userId uint64) error {
+	u.cache.Del(u.getProfileCacheUserKey(userId))
 	return nil
 }
 
@@ -140,7 +243,10 @@ func (u *UserMemoryCache) getUserCacheKey(id uint64) []byte {
 	return []byte(fmt.Sprintf("webook:user:getusercachekey:%d", id))
 }
 func (u *UserMemoryCache) getUserCacheEmailKey(email string) []byte {
-	return []byte(fmt.Sprintf("webook:user:getusercacheemailkey:%s", email))
+	return []byte(fmt.Sprintf("webook:user:getusercacheemailkey:%s", normalizeEmail(email)))
+}
+func (u *UserMemoryCache) getUserCachePhoneKey(phone string) []byte {
+	return []byte(fmt.Sprintf("webook:user:getusercachephonekey:%s", phone))
 }
 func (u *UserMemoryCache) getProfileCacheUserKey(userId uint64) []byte {
 	return []byte(fmt.Sprintf("webook:user:getprofilecacheuserkey:%d", userId))
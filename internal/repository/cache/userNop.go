@@ -0,0 +1,63 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 15:12:08
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/userNop.go
+ * @Description: 不缓存用户，排查缓存不一致的时候用
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+
+	"github.com/gz4z2b/go-webook/internal/repository/dao"
+)
+
+// UserNopCache 读一律不存在，写、删什么都不做，每次都查库
+type UserNopCache struct{}
+
+func NewUserNopCache() UserCache {
+	return UserNopCache{}
+}
+
+func (UserNopCache) FindUserById(ctx context.Context, id uint64) (dao.User, error) {
+	return dao.User{}, ErrCacheNotExist
+}
+
+func (UserNopCache) FindUserByEmail(ctx context.Context, email string) (dao.User, error) {
+	return dao.User{}, ErrCacheNotExist
+}
+
+func (UserNopCache) FindUserByPhone(ctx context.Context, phone string) (dao.User, error) {
+	return dao.User{}, ErrCacheNotExist
+}
+
+func (UserNopCache) FindProfileByUser(ctx context.Context, user dao.User) (dao.Profile, error) {
+	return dao.Profile{}, ErrCacheNotExist
+}
+
+func (UserNopCache) SetUser(ctx context.Context, user dao.User) error {
+	return nil
+}
+
+func (UserNopCache) SetProfile(ctx context.Context, profile dao.Profile) error {
+	return nil
+}
+
+func (UserNopCache) SetUserNotFound(ctx context.Context, user dao.User) error {
+	return nil
+}
+
+func (UserNopCache) SetProfileNotFound(ctx context.Context, userId uint64) error {
+	return nil
+}
+
+func (UserNopCache) DeleteUser(ctx context.Context, user dao.User) error {
+	return nil
+}
+
+func (UserNopCache) DeleteProfile(ctx context.Context, userId uint64) error {
+	return nil
+}
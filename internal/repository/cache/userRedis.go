@@ -11,23 +11,21 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/gz4z2b/go-webook/internal/repository/dao"
 	redis "github.com/redis/go-redis/v9"
 )
 
 type UserRedisCache struct {
-	cache      redis.Cmdable
-	expiretion time.Duration
+	cache  redis.Cmdable
+	expiry expiry
 }
 
 func NewUserRedisCache(client redis.Cmdable) UserCache {
 	return &UserRedisCache{
-		cache:      client,
-		expiretion: time.Minute * 15,
+		cache:  client,
+		expiry: userExpiry(),
 	}
 }
 
@@ -47,7 +45,7 @@ func (u *UserRedisCache) FindUserById(ctx context.Context, id uint64) (dao.User,
 		return dao.User{}, err
 	}
 	var user dao.User
-	err = json.Unmarshal(result, &user)
+	err = u.expiry.decode(result, &user)
 	return user, err
 }
 
@@ -67,7 +65,27 @@ func (u *UserRedisCache) FindUserByEmail(ctx context.Context, email string) (dao
 		return dao.User{}, err
 	}
 	var user dao.User
-	err = json.Unmarshal(result, &user)
+	err = u.expiry.decode(result, &user)
+	return user, err
+}
+
+/**
+ * @description: 根据手机号获取用户
+ * @param {context.Context} ctx
+ * @param {string} phone
+ * @return {dao.User, error}
+ */
+func (u *UserRedisCache) FindUserByPhone(ctx context.Context, phone string) (dao.User, error) {
+	key := u.getUserCachePhoneKey(phone)
+	result, err := u.cache.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return dao.User{}, ErrCacheNotExist
+		}
+		return dao.User{}, err
+	}
+	var user dao.User
+	err = u.expiry.decode(result, &user)
 	return user, err
 }
 
@@ -87,7 +105,7 @@ func (u *UserRedisCache) FindProfileByUser(ctx context.Context, user dao.User) (
 		return dao.Profile{}, err
 	}
 	var profile dao.Profile
-	err = json.Unmarshal(result, &profile)
+	err = u.expiry.decode(result, &profile)
 	return profile, err
 }
 
@@ -98,17 +116,25 @@ func (u *UserRedisCache) FindProfileByUser(ctx context.Context, user dao.User) (
  * @return {error}
  */
 func (u *UserRedisCache) SetUser(ctx context.Context, user dao.User) error {
-	setStr, err := json.Marshal(user)
+	setStr, expiretion, err := u.expiry.encode(user)
 	if err != nil {
 		return err
 	}
-	err = u.cache.Set(ctx, u.getUserCacheKey(user.Id), setStr, u.expiretion).Err()
+	err = u.cache.Set(ctx, u.getUserCacheKey(user.Id), setStr, expiretion).Err()
 	if err != nil {
 		return err
 	}
-	err = u.cache.Set(ctx, u.getUserCacheEmailKey(user.Email), setStr, u.expiretion).Err()
-	if err != nil {
-		return err
+	if user.Email.Valid {
+		err = u.cache.Set(ctx, u.getUserCacheEmailKey(user.Email.String), setStr, expiretion).Err()
+		if err != nil {
+			return err
+		}
+	}
+	if user.Phone.Valid {
+		err = u.cache.Set(ctx, u.getUserCachePhoneKey(user.Phone.String), setStr, expiretion).Err()
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -120,17 +146,90 @@ func (u *UserRedisCache) SetUser(ctx context.Context, user dao.User) error {
  * @return {error}
  */
 func (u *UserRedisCache) SetProfile(ctx context.Context, profile dao.Profile) error {
-	setStr, err := json.Marshal(profile)
+	setStr, expiretion, err := u.expiry.encode(profile)
 	if err != nil {
 		return err
 	}
-	err = u.cache.Set(ctx, u.getProfileCacheUserKey(profile.UserId), setStr, u.expiretion).Err()
+	err = u.cache.Set(ctx, u.getProfileCacheUserKey(profile.UserId), setStr, expiretion).Err()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+/**
+ * @description: 记下数据库里没有这个用户，只写 user 里填了的id、邮箱、手机号，新建用户时 SetUser 会覆盖掉
+ * @param {context.Context} ctx
+ * @param {dao.User} user 只填查询用的字段
+ * @return {error}
+ */
+func (u *UserRedisCache) SetUserNotFound(ctx context.Context, user dao.User) error {
+	setStr, expiretion, err := u.expiry.encodeNotFound()
+	if err != nil {
+		return err
+	}
+	if user.Id != 0 {
+		err = u.cache.Set(ctx, u.getUserCacheKey(user.Id), setStr, expiretion).Err()
+		if err != nil {
+			return err
+		}
+	}
+	if user.Email.Valid {
+		err = u.cache.Set(ctx, u.getUserCacheEmailKey(user.Email.String), setStr, expiretion).Err()
+		if err != nil {
+			return err
+		}
+	}
+	if user.Phone.Valid {
+		err = u.cache.Set(ctx, u.getUserCachePhoneKey(user.Phone.String), setStr, expiretion).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * @description: 记下这个用户还没有档案，添加档案时 SetProfile 会覆盖掉
+ * @param {context.Context} ctx
+ * @param {uint64} userId
+ * @return {error}
+ */
+func (u *UserRedisCache) SetProfileNotFound(ctx context.Context, userId uint64) error {
+	setStr, expiretion, err := u.expiry.encodeNotFound()
+	if err != nil {
+		return err
+	}
+	return u.cache.Set(ctx, u.getProfileCacheUserKey(userId), setStr, expiretion).Err()
+}
+
+/**
+ * @description: 删除用户缓存，用户信息改了之后调用
+ * @param {context.Context} ctx
+ * @param {dao.User} user
+ * @return {error}
+ */
+func (u *UserRedisCache) DeleteUser(ctx context.Context, user dao.User) error {
+	keys := []string{u.getUserCacheKey(user.Id)}
+	if user.Email.Valid {
+		keys = append(keys, u.getUserCacheEmailKey(user.Email.String))
+	}
+	if user.Phone.Valid {
+		keys = append(keys, u.getUserCachePhoneKey(user.Phone.String))
+	}
+	return u.cache.Del(ctx, keys...).Err()
+}
+
+/**
+ * @description: 删除用户档案缓存
+ * @param {context.Context} ctx
+ * @param {uint64} userId
+ * @return {error}
+ */
+func (u *UserRedisCache) DeleteProfile(ctx context.Context, userId uint64) error {
+	return u.cache.Del(ctx, u.getProfileCacheUserKey(userId)).Err()
+}
+
 /**
  * @description: 用户信息缓存key
  * @param {uint64} id
@@ -140,7 +239,10 @@ func (u *UserRedisCache) getUserCacheKey(id uint64) string {
 	return fmt.Sprintf("webook:user:getusercachekey:%d", id)
 }
 func (u *UserRedisCache) getUserCacheEmailKey(email string) string {
-	return fmt.Sprintf("webook:user:getusercacheemailkey:%s", email)
+	return fmt.Sprintf("webook:user:getusercacheemailkey:%s", normalizeEmail(email))
+}
+func (u *UserRedisCache) getUserCachePhoneKey(phone string) string {
+	return fmt.Sprintf("webook:user:getusercachephonekey:%s", phone)
 }
 func (u *UserRedisCache) getProfileCacheUserKey(userId uint64) string {
 	return fmt.Sprintf("webook:user:getprofilecacheuserkey:%d", userId)
@@ -11,6 +11,7 @@ package cache
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"testing"
@@ -25,7 +26,7 @@ import (
 
 var user = dao.User{
 	Id:         1,
-	Email:      "gz4z2b@163.com",
+	Email:      sql.NullString{String: "gz4z2b@163.com", Valid: true},
 	Password:   "19890821Xi_",
 	Createtime: time.Now().Unix(),
 	Updatetime: time.Now().Unix(),
@@ -43,6 +44,12 @@ var profile = dao.Profile{
 	Deletetime:  0,
 }
 
+// testEntry 按缓存里的格式编码，还没到软过期
+func testEntry(val any) ([]byte, error) {
+	data, _, err := userExpiry().encode(val)
+	return data, err
+}
+
 func TestUserRedisCache_FindUserById(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -57,9 +64,9 @@ func TestUserRedisCache_FindUserById(t *testing.T) {
 			inputId: uint64(1),
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				mock := redismocks.NewMockCmdable(ctrl)
-				result, _ := json.Marshal(dao.User{
+				result, _ := testEntry(dao.User{
 					Id:    1,
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				})
 				resultStr := redis.NewStringCmd(context.Background())
 				resultStr.SetVal(string(result))
@@ -69,7 +76,7 @@ func TestUserRedisCache_FindUserById(t *testing.T) {
 			},
 			wantUser: dao.User{
 				Id:    1,
-				Email: "gz4z2b@163.com",
+				Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 			},
 			wantErr: nil,
 		},
@@ -134,7 +141,7 @@ func TestUserRedisCache_FindUserByEmail(t *testing.T) {
 			inputEmail: "gz4z2b@163.com",
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				cacheMock := redismocks.NewMockCmdable(ctrl)
-				result, _ := json.Marshal(user)
+				result, _ := testEntry(user)
 				resultCmd := redis.NewStringCmd(context.Background())
 				resultCmd.SetVal(string(result))
 
@@ -219,7 +226,7 @@ func TestUserRedisCache_FindProfileByUser(t *testing.T) {
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				mock := redismocks.NewMockCmdable(ctrl)
 				resultCmd := redis.NewStringCmd(context.Background())
-				result, _ := json.Marshal(profile)
+				result, _ := testEntry(profile)
 				resultCmd.SetVal(string(result))
 				mock.EXPECT().Get(context.Background(), gomock.Any()).Return(resultCmd)
 
@@ -298,11 +305,10 @@ func TestUserRedisCache_SetUser(t *testing.T) {
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				mock := redismocks.NewMockCmdable(ctrl)
 
-				userStr, _ := json.Marshal(user)
 				statusCmd := redis.NewStatusCmd(context.Background())
 
-				mock.EXPECT().Set(context.Background(), gomock.Any(), userStr, gomock.Any()).Return(statusCmd)
-				mock.EXPECT().Set(context.Background(), gomock.Any(), userStr, gomock.Any()).Return(statusCmd)
+				mock.EXPECT().Set(context.Background(), gomock.Any(), gomock.Any(), gomock.Any()).Return(statusCmd)
+				mock.EXPECT().Set(context.Background(), gomock.Any(), gomock.Any(), gomock.Any()).Return(statusCmd)
 
 				return mock
 			},
@@ -317,11 +323,10 @@ func TestUserRedisCache_SetUser(t *testing.T) {
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				mock := redismocks.NewMockCmdable(ctrl)
 
-				userStr, _ := json.Marshal(user)
 				statusCmd := redis.NewStatusCmd(context.Background())
 				statusCmd.SetErr(errors.New("用户缓存炸了"))
 
-				mock.EXPECT().Set(context.Background(), gomock.Any(), userStr, gomock.Any()).Return(statusCmd)
+				mock.EXPECT().Set(context.Background(), gomock.Any(), gomock.Any(), gomock.Any()).Return(statusCmd)
 
 				return mock
 			},
@@ -336,13 +341,12 @@ func TestUserRedisCache_SetUser(t *testing.T) {
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				mock := redismocks.NewMockCmdable(ctrl)
 
-				userStr, _ := json.Marshal(user)
 				statusCmd := redis.NewStatusCmd(context.Background())
 				byEmailStatusCmd := redis.NewStatusCmd(context.Background())
 				byEmailStatusCmd.SetErr(errors.New("用户byemail缓存炸了"))
 
-				mock.EXPECT().Set(context.Background(), gomock.Any(), userStr, gomock.Any()).Return(statusCmd)
-				mock.EXPECT().Set(context.Background(), gomock.Any(), userStr, gomock.Any()).Return(byEmailStatusCmd)
+				mock.EXPECT().Set(context.Background(), gomock.Any(), gomock.Any(), gomock.Any()).Return(statusCmd)
+				mock.EXPECT().Set(context.Background(), gomock.Any(), gomock.Any(), gomock.Any()).Return(byEmailStatusCmd)
 
 				return mock
 			},
@@ -382,10 +386,8 @@ func TestUserRedisCache_SetProfile(t *testing.T) {
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				mock := redismocks.NewMockCmdable(ctrl)
 
-				resultStr, _ := json.Marshal(profile)
-
 				statusCmd := redis.NewStatusCmd(context.Background())
-				mock.EXPECT().Set(context.Background(), gomock.Any(), resultStr, gomock.Any()).Return(statusCmd)
+				mock.EXPECT().Set(context.Background(), gomock.Any(), gomock.Any(), gomock.Any()).Return(statusCmd)
 
 				return mock
 			},
@@ -400,11 +402,9 @@ func TestUserRedisCache_SetProfile(t *testing.T) {
 			mock: func(ctrl *gomock.Controller) redis.Cmdable {
 				mock := redismocks.NewMockCmdable(ctrl)
 
-				resultStr, _ := json.Marshal(profile)
-
 				statusCmd := redis.NewStatusCmd(context.Background())
 				statusCmd.SetErr(errors.New("缓存炸了"))
-				mock.EXPECT().Set(context.Background(), gomock.Any(), resultStr, gomock.Any()).Return(statusCmd)
+				mock.EXPECT().Set(context.Background(), gomock.Any(), gomock.Any(), gomock.Any()).Return(statusCmd)
 
 				return mock
 			},
@@ -426,3 +426,66 @@ func TestUserRedisCache_SetProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestUserRedisCache_SetUserNotFound(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    dao.User
+		mock    func(ctrl *gomock.Controller) redis.Cmdable
+		wantErr error
+	}{
+		{
+			name: "按id",
+			user: dao.User{Id: 1},
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				mock.EXPECT().Set(context.Background(), "webook:user:getusercachekey:1", gomock.Any(), time.Minute).
+					Return(redis.NewStatusCmd(context.Background()))
+				return mock
+			},
+		},
+		{
+			name: "按邮箱",
+			user: dao.User{Email: sql.NullString{String: "nobody@163.com", Valid: true}},
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				mock.EXPECT().Set(context.Background(), "webook:user:getusercacheemailkey:nobody@163.com", gomock.Any(), time.Minute).
+					Return(redis.NewStatusCmd(context.Background()))
+				return mock
+			},
+		},
+		{
+			name: "邮箱大小写不同用同一个key",
+			user: dao.User{Email: sql.NullString{String: " NoBody@163.com", Valid: true}},
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				mock.EXPECT().Set(context.Background(), "webook:user:getusercacheemailkey:nobody@163.com", gomock.Any(), time.Minute).
+					Return(redis.NewStatusCmd(context.Background()))
+				return mock
+			},
+		},
+		{
+			name: "缓存炸了",
+			user: dao.User{Phone: sql.NullString{String: "13800138000", Valid: true}},
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				statusCmd := redis.NewStatusCmd(context.Background())
+				statusCmd.SetErr(errors.New("缓存炸了"))
+				mock.EXPECT().Set(context.Background(), "webook:user:getusercachephonekey:13800138000", gomock.Any(), time.Minute).
+					Return(statusCmd)
+				return mock
+			},
+			wantErr: errors.New("缓存炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			u := NewUserRedisCache(tt.mock(ctrl))
+
+			err := u.SetUserNotFound(context.Background(), tt.user)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 16:20:45
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/userTwoLevel.go
+ * @Description: 本地缓存加redis的两级用户缓存，写了之后广播让其他实例删掉本地副本
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/coocood/freecache"
+	"github.com/gz4z2b/go-webook/internal/repository/dao"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// userInvalidateChannel 所有实例都订阅这个频道
+const userInvalidateChannel = "webook:user:invalidate"
+
+// PubSubCmdable redis.Cmdable 里没有 Subscribe，*redis.Client 满足这个接口
+type PubSubCmdable interface {
+	redis.Cmdable
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// userInvalidation 广播的失效消息，Profile 为 true 时只删档案
+type userInvalidation struct {
+	Node    string `json:"node"`
+	Id      uint64 `json:"id"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Profile bool   `json:"profile,omitempty"`
+}
+
+type UserTwoLevelCache struct {
+	local  *UserMemoryCache
+	remote UserCache
+	client PubSubCmdable
+	// 区分自己发的消息，自己刚写进本地的不用删
+	node string
+}
+
+/**
+ * @description: 两级用户缓存，要另外起协程调用 Subscribe 才能收到其他实例的失效消息
+ * @param {*freecache.Cache} local 只给用户缓存用，重新订阅时会整个清空
+ * @param {time.Duration} localExpiretion 本地副本的有效期，漏收失效消息时最多旧这么久，要比redis短
+ * @param {PubSubCmdable} client
+ * @return {*UserTwoLevelCache}
+ */
+func NewUserTwoLevelCache(local *freecache.Cache, localExpiretion time.Duration, client PubSubCmdable) *UserTwoLevelCache {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	// 不存在的标记在本地也不能比redis里留得久
+	notFound := userExpiry().notFound
+	if localExpiretion < notFound {
+		notFound = localExpiretion
+	}
+	return &UserTwoLevelCache{
+		local: &UserMemoryCache{
+			cache: local,
+			// 本地不留旧值，过期了就去redis拿
+			expiry: expiry{
+				expiretion: localExpiretion,
+				jitter:     0.1,
+				notFound:   notFound,
+				now:        time.Now,
+			},
+		},
+		remote: NewUserRedisCache(client),
+		client: client,
+		node:   hex.EncodeToString(buf),
+	}
+}
+
+/**
+ * @description: 先查本地，本地没有查redis，查到了放进本地，redis 里记着不存在的也放进本地；
+ * redis 里过了软过期的原样返回 ErrCacheStale，不放进本地
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @return {dao.User, error}
+ */
+func (u *UserTwoLevelCache) FindUserById(ctx context.Context, id uint64) (dao.User, error) {
+	user, err := u.local.FindUserById(ctx, id)
+	if err == nil || err == ErrCacheNotFound {
+		return user, err
+	}
+	user, err = u.remote.FindUserById(ctx, id)
+	switch err {
+	case nil:
+		u.fillUser(ctx, user)
+	case ErrCacheNotFound:
+		u.fillUserNotFound(ctx, dao.User{Id: id})
+	}
+	return user, err
+}
+
+func (u *UserTwoLevelCache) FindUserByEmail(ctx context.Context, email string) (dao.User, error) {
+	user, err := u.local.FindUserByEmail(ctx, email)
+	if err == nil || err == ErrCacheNotFound {
+		return user, err
+	}
+	user, err = u.remote.FindUserByEmail(ctx, email)
+	switch err {
+	case nil:
+		u.fillUser(ctx, user)
+	case ErrCacheNotFound:
+		u.fillUserNotFound(ctx, dao.User{Email: sql.NullString{String: email, Valid: true}})
+	}
+	return user, err
+}
+
+func (u *UserTwoLevelCache) FindUserByPhone(ctx context.Context, phone string) (dao.User, error) {
+	user, err := u.local.FindUserByPhone(ctx, phone)
+	if err == nil || err == ErrCacheNotFound {
+		return user, err
+	}
+	user, err = u.remote.FindUserByPhone(ctx, phone)
+	switch err {
+	case nil:
+		u.fillUser(ctx, user)
+	case ErrCacheNotFound:
+		u.fillUserNotFound(ctx, dao.User{Phone: sql.NullString{String: phone, Valid: true}})
+	}
+	return user, err
+}
+
+func (u *UserTwoLevelCache) FindProfileByUser(ctx context.Context, user dao.User) (dao.Profile, error) {
+	profile, err := u.local.FindProfileByUser(ctx, user)
+	if err == nil || err == ErrCacheNotFound {
+		return profile, err
+	}
+	profile, err = u.remote.FindProfileByUser(ctx, user)
+	var fillErr error
+	switch err {
+	case nil:
+		fillErr = u.local.SetProfile(ctx, profile)
+	case ErrCacheNotFound:
+		fillErr = u.local.SetProfileNotFound(ctx, user.Id)
+	}
+	if fillErr != nil {
+		log.Printf("用户档案放进本地缓存失败 uid=%d: %v", user.Id, fillErr)
+	}
+	return profile, err
+}
+
+/**
+ * @description: 写redis和本地，再通知其他实例删掉本地的旧副本
+ * @param {context.Context} ctx
+ * @param {dao.User} user
+ * @return {error}
+ */
+func (u *UserTwoLevelCache) SetUser(ctx context.Context, user dao.User) error {
+	if err := u.remote.SetUser(ctx, user); err != nil {
+		return err
+	}
+	if err := u.local.SetUser(ctx, user); err != nil {
+		return err
+	}
+	u.publish(ctx, u.userInvalidation(user))
+	return nil
+}
+
+func (u *UserTwoLevelCache) SetProfile(ctx context.Context, profile dao.Profile) error {
+	if err := u.remote.SetProfile(ctx, profile); err != nil {
+		return err
+	}
+	if err := u.local.SetProfile(ctx, profile); err != nil {
+		return err
+	}
+	u.publish(ctx, userInvalidation{Node: u.node, Id: profile.UserId, Profile: true})
+	return nil
+}
+
+/**
+ * @description: 不存在的标记写redis和本地，不用广播，其他实例的本地副本会在新建用户时收到失效消息
+ * @param {context.Context} ctx
+ * @param {dao.User} user 只填查询用的字段
+ * @return {error}
+ */
+func (u *UserTwoLevelCache) SetUserNotFound(ctx context.Context, user dao.User) error {
+	if err := u.remote.SetUserNotFound(ctx, user); err != nil {
+		return err
+	}
+	return u.local.SetUserNotFound(ctx, user)
+}
+
+func (u *UserTwoLevelCache) SetProfileNotFound(ctx context.Context, userId uint64) error {
+	if err := u.remote.SetProfileNotFound(ctx, userId); err != nil {
+		return err
+	}
+	return u.local.SetProfileNotFound(ctx, userId)
+}
+
+func (u *UserTwoLevelCache) DeleteUser(ctx context.Context, user dao.User) error {
+	if err := u.remote.DeleteUser(ctx, user); err != nil {
+		return err
+	}
+	_ = u.local.DeleteUser(ctx, user)
+	u.publish(ctx, u.userInvalidation(user))
+	return nil
+}
+
+func (u *UserTwoLevelCache) DeleteProfile(ctx context.Context, userId uint64) error {
+	if err := u.remote.DeleteProfile(ctx, userId); err != nil {
+		return err
+	}
+	_ = u.local.DeleteProfile(ctx, userId)
+	u.publish(ctx, userInvalidation{Node: u.node, Id: userId, Profile: true})
+	return nil
+}
+
+/**
+ * @description: 订阅其他实例的失效消息，ctx 取消之前一直阻塞，断线由 go-redis 自动重连
+ * @param {context.Context} ctx
+ * @return {*}
+ */
+func (u *UserTwoLevelCache) Subscribe(ctx context.Context) {
+	pubsub := u.client.Subscribe(ctx, userInvalidateChannel)
+	defer pubsub.Close()
+	ch := pubsub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			switch m := msg.(type) {
+			case *redis.Subscription:
+				// 断线期间的消息收不到，每次（重新）订阅成功都清空本地，宁可多查一次redis
+				if m.Kind == "subscribe" {
+					u.local.cache.Clear()
+				}
+			case *redis.Message:
+				u.handle(m.Payload)
+			}
+		}
+	}
+}
+
+/**
+ * @description: 处理失效消息，删掉本地的副本
+ * @param {string} payload
+ * @return {*}
+ */
+func (u *UserTwoLevelCache) handle(payload string) {
+	var msg userInvalidation
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Printf("用户缓存失效消息格式不对: %v", err)
+		return
+	}
+	if msg.Node == u.node {
+		return
+	}
+	ctx := context.Background()
+	if msg.Profile {
+		_ = u.local.DeleteProfile(ctx, msg.Id)
+		return
+	}
+	_ = u.local.DeleteUser(ctx, dao.User{
+		Id:    msg.Id,
+		Email: sql.NullString{String: msg.Email, Valid: msg.Email != ""},
+		Phone: sql.NullString{String: msg.Phone, Valid: msg.Phone != ""},
+	})
+}
+
+// fillUser redis 里查到的放进本地，放不进去只是下次再查redis
+func (u *UserTwoLevelCache) fillUser(ctx context.Context, user dao.User) {
+	if err := u.local.SetUser(ctx, user); err != nil {
+		log.Printf("用户放进本地缓存失败 uid=%d: %v", user.Id, err)
+	}
+}
+
+func (u *UserTwoLevelCache) fillUserNotFound(ctx context.Context, user dao.User) {
+	if err := u.local.SetUserNotFound(ctx, user); err != nil {
+		log.Printf("用户不存在的标记放进本地缓存失败: %v", err)
+	}
+}
+
+func (u *UserTwoLevelCache) userInvalidation(user dao.User) userInvalidation {
+	msg := userInvalidation{Node: u.node, Id: user.Id}
+	if user.Email.Valid {
+		msg.Email = user.Email.String
+	}
+	if user.Phone.Valid {
+		msg.Phone = user.Phone.String
+	}
+	return msg
+}
+
+// publish 发不出去只记日志，数据已经写好了，其他实例的本地副本最多旧 localExpiretion
+func (u *UserTwoLevelCache) publish(ctx context.Context, msg userInvalidation) {
+	payload, err := json.Marshal(msg)
+	if err == nil {
+		err = u.client.Publish(ctx, userInvalidateChannel, payload).Err()
+	}
+	if err != nil {
+		log.Printf("广播用户缓存失效失败 uid=%d: %v", msg.Id, err)
+	}
+}
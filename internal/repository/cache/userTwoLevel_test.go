@@ -0,0 +1,211 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 16:58:12
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cache/userTwoLevel_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/coocood/freecache"
+	redismocks "github.com/gz4z2b/go-webook/internal/repository/cache/mocks/redismocks"
+	"github.com/gz4z2b/go-webook/internal/repository/dao"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// testPubSubCmdable 测试不走订阅，只用 mock 的 Publish
+type testPubSubCmdable struct {
+	*redismocks.MockCmdable
+}
+
+func (testPubSubCmdable) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return nil
+}
+
+func newTestTwoLevelCache(ctrl *gomock.Controller) (*UserTwoLevelCache, *redismocks.MockCmdable) {
+	cmd := redismocks.NewMockCmdable(ctrl)
+	u := NewUserTwoLevelCache(freecache.NewCache(1024*1024), time.Minute, testPubSubCmdable{cmd})
+	u.node = "node-a"
+	return u, cmd
+}
+
+func TestUserTwoLevelCache_FindUserById(t *testing.T) {
+	tests := []struct {
+		name     string
+		local    *dao.User
+		mock     func(cmd *redismocks.MockCmdable)
+		wantUser dao.User
+		wantErr  error
+		// 查完之后本地有没有
+		wantLocal bool
+	}{
+		{
+			name:      "本地有",
+			local:     &user,
+			mock:      func(cmd *redismocks.MockCmdable) {},
+			wantUser:  user,
+			wantLocal: true,
+		},
+		{
+			name: "本地没有查redis",
+			mock: func(cmd *redismocks.MockCmdable) {
+				val, _ := testEntry(user)
+				res := redis.NewStringCmd(context.Background())
+				res.SetVal(string(val))
+				cmd.EXPECT().Get(gomock.Any(), "webook:user:getusercachekey:1").Return(res)
+			},
+			wantUser:  user,
+			wantLocal: true,
+		},
+		{
+			name: "都没有",
+			mock: func(cmd *redismocks.MockCmdable) {
+				res := redis.NewStringCmd(context.Background())
+				res.SetErr(redis.Nil)
+				cmd.EXPECT().Get(gomock.Any(), "webook:user:getusercachekey:1").Return(res)
+			},
+			wantErr: ErrCacheNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			u, cmd := newTestTwoLevelCache(ctrl)
+			tt.mock(cmd)
+			if tt.local != nil {
+				assert.NoError(t, u.local.SetUser(context.Background(), *tt.local))
+			}
+
+			got, err := u.FindUserById(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantUser.Id, got.Id)
+			assert.Equal(t, tt.wantUser.Email, got.Email)
+			_, err = u.local.FindUserById(context.Background(), 1)
+			assert.Equal(t, tt.wantLocal, err == nil)
+		})
+	}
+}
+
+func TestUserTwoLevelCache_FindUserByEmailNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	u, cmd := newTestTwoLevelCache(ctrl)
+	val, _, _ := userExpiry().encodeNotFound()
+	res := redis.NewStringCmd(context.Background())
+	res.SetVal(string(val))
+	// 只查一次redis，第二次本地就知道不存在
+	cmd.EXPECT().Get(gomock.Any(), "webook:user:getusercacheemailkey:nobody@163.com").Return(res).Times(1)
+
+	for i := 0; i < 2; i++ {
+		_, err := u.FindUserByEmail(context.Background(), "nobody@163.com")
+		assert.Equal(t, ErrCacheNotFound, err)
+	}
+}
+
+func TestUserTwoLevelCache_SetProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	u, cmd := newTestTwoLevelCache(ctrl)
+	ok := redis.NewStatusCmd(context.Background())
+	cmd.EXPECT().Set(gomock.Any(), "webook:user:getprofilecacheuserkey:1", gomock.Any(), gomock.Any()).Return(ok)
+	cmd.EXPECT().Publish(gomock.Any(), userInvalidateChannel, []byte(`{"node":"node-a","id":1,"profile":true}`)).
+		Return(redis.NewIntCmd(context.Background()))
+
+	assert.NoError(t, u.SetProfile(context.Background(), profile))
+	got, err := u.local.FindProfileByUser(context.Background(), dao.User{Id: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, profile.Nickname, got.Nickname)
+}
+
+func TestUserTwoLevelCache_DeleteUserPublishFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	u, cmd := newTestTwoLevelCache(ctrl)
+	assert.NoError(t, u.local.SetUser(context.Background(), user))
+	cmd.EXPECT().Del(gomock.Any(), "webook:user:getusercachekey:1", "webook:user:getusercacheemailkey:gz4z2b@163.com").
+		Return(redis.NewIntCmd(context.Background()))
+	published := redis.NewIntCmd(context.Background())
+	published.SetErr(context.DeadlineExceeded)
+	cmd.EXPECT().Publish(gomock.Any(), userInvalidateChannel, []byte(`{"node":"node-a","id":1,"email":"gz4z2b@163.com"}`)).
+		Return(published)
+
+	// 广播失败不影响删除
+	assert.NoError(t, u.DeleteUser(context.Background(), user))
+	_, err := u.local.FindUserByEmail(context.Background(), "gz4z2b@163.com")
+	assert.Equal(t, ErrCacheNotExist, err)
+}
+
+func TestUserTwoLevelCache_handle(t *testing.T) {
+	phoneUser := dao.User{Id: 2, Phone: sql.NullString{String: "13800138000", Valid: true}}
+	tests := []struct {
+		name        string
+		payload     string
+		wantUser    bool
+		wantPhone   bool
+		wantProfile bool
+	}{
+		{
+			name:        "其他实例改了用户",
+			payload:     `{"node":"node-b","id":1,"email":"gz4z2b@163.com"}`,
+			wantPhone:   true,
+			wantProfile: true,
+		},
+		{
+			name:      "其他实例改了档案",
+			payload:   `{"node":"node-b","id":1,"profile":true}`,
+			wantUser:  true,
+			wantPhone: true,
+		},
+		{
+			name:        "按手机号删",
+			payload:     `{"node":"node-b","id":2,"phone":"13800138000"}`,
+			wantUser:    true,
+			wantProfile: true,
+		},
+		{
+			name:        "自己发的",
+			payload:     `{"node":"node-a","id":1,"email":"gz4z2b@163.com"}`,
+			wantUser:    true,
+			wantPhone:   true,
+			wantProfile: true,
+		},
+		{
+			name:        "格式不对",
+			payload:     `not json`,
+			wantUser:    true,
+			wantPhone:   true,
+			wantProfile: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			u, _ := newTestTwoLevelCache(ctrl)
+			ctx := context.Background()
+			assert.NoError(t, u.local.SetUser(ctx, user))
+			assert.NoError(t, u.local.SetUser(ctx, phoneUser))
+			assert.NoError(t, u.local.SetProfile(ctx, profile))
+
+			u.handle(tt.payload)
+
+			_, err := u.local.FindUserByEmail(ctx, "gz4z2b@163.com")
+			assert.Equal(t, tt.wantUser, err == nil)
+			_, err = u.local.FindUserByPhone(ctx, "13800138000")
+			assert.Equal(t, tt.wantPhone, err == nil)
+			_, err = u.local.FindProfileByUser(ctx, dao.User{Id: 1})
+			assert.Equal(t, tt.wantProfile, err == nil)
+		})
+	}
+}
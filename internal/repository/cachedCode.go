@@ -0,0 +1,55 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 10:40:15
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cachedCode.go
+ * @Description: 验证码数据抽象层
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package repository
+
+import (
+	"context"
+
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+)
+
+var (
+	ErrCodeSendTooMany        = cache.ErrCodeSendTooMany
+	ErrCodeVerifyTooManyTimes = cache.ErrCodeVerifyTooManyTimes
+)
+
+type CachedCodeRepository struct {
+	cache cache.CodeCache
+}
+
+func NewCachedCodeRepository(cache cache.CodeCache) CodeRepository {
+	return &CachedCodeRepository{
+		cache: cache,
+	}
+}
+
+/**
+ * @description: 保存验证码
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} phone
+ * @param {string} code
+ * @return {error}
+ */
+func (r *CachedCodeRepository) Store(ctx context.Context, biz string, phone string, code string) error {
+	return r.cache.Set(ctx, biz, phone, code)
+}
+
+/**
+ * @description: 校验验证码
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} phone
+ * @param {string} inputCode
+ * @return {bool, error}
+ */
+func (r *CachedCodeRepository) Verify(ctx context.Context, biz string, phone string, inputCode string) (bool, error) {
+	return r.cache.Verify(ctx, biz, phone, inputCode)
+}
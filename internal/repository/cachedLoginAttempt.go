@@ -0,0 +1,76 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 22:30:16
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cachedLoginAttempt.go
+ * @Description: 登录失败记录
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+)
+
+type CachedLoginAttemptRepository struct {
+	cache cache.LoginAttemptCache
+	now   func() time.Time
+}
+
+func NewCachedLoginAttemptRepository(cache cache.LoginAttemptCache) LoginAttemptRepository {
+	return &CachedLoginAttemptRepository{
+		cache: cache,
+		now:   time.Now,
+	}
+}
+
+/**
+ * @description: 查失败记录
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @return {domain.LoginAttempt, error}
+ */
+func (r *CachedLoginAttemptRepository) Find(ctx context.Context, target string) (domain.LoginAttempt, error) {
+	cnt, last, err := r.cache.Get(ctx, target)
+	if err != nil {
+		return domain.LoginAttempt{}, err
+	}
+	return domain.LoginAttempt{
+		Failures:    cnt,
+		LastFailure: last,
+	}, nil
+}
+
+/**
+ * @description: 记一次失败
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @param {time.Duration} expiretion
+ * @return {domain.LoginAttempt, error}
+ */
+func (r *CachedLoginAttemptRepository) Fail(ctx context.Context, target string, expiretion time.Duration) (domain.LoginAttempt, error) {
+	now := r.now()
+	cnt, err := r.cache.Incr(ctx, target, now, expiretion)
+	if err != nil {
+		return domain.LoginAttempt{}, err
+	}
+	return domain.LoginAttempt{
+		Failures:    cnt,
+		LastFailure: now,
+	}, nil
+}
+
+/**
+ * @description: 清掉失败记录
+ * @param {context.Context} ctx
+ * @param {string} target
+ * @return {error}
+ */
+func (r *CachedLoginAttemptRepository) Reset(ctx context.Context, target string) error {
+	return r.cache.Delete(ctx, target)
+}
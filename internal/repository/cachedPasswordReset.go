@@ -0,0 +1,50 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:35:52
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/cachedPasswordReset.go
+ * @Description: 重置密码凭证
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+)
+
+type CachedPasswordResetRepository struct {
+	cache cache.PasswordResetCache
+}
+
+func NewCachedPasswordResetRepository(cache cache.PasswordResetCache) PasswordResetRepository {
+	return &CachedPasswordResetRepository{
+		cache: cache,
+	}
+}
+
+/**
+ * @description: 保存凭证
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @param {string} nonce
+ * @param {time.Duration} expiretion
+ * @return {error}
+ */
+func (r *CachedPasswordResetRepository) Store(ctx context.Context, uid uint64, nonce string, expiretion time.Duration) error {
+	return r.cache.Set(ctx, uid, nonce, expiretion)
+}
+
+/**
+ * @description: 使用凭证
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @param {string} nonce
+ * @return {bool, error}
+ */
+func (r *CachedPasswordResetRepository) Consume(ctx context.Context, uid uint64, nonce string) (bool, error) {
+	return r.cache.Consume(ctx, uid, nonce)
+}
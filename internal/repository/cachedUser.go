@@ -11,28 +11,54 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gz4z2b/go-webook/internal/domain"
 	"github.com/gz4z2b/go-webook/internal/repository/cache"
 	"github.com/gz4z2b/go-webook/internal/repository/dao"
+	"github.com/gz4z2b/go-webook/pkg/bloom"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
 	ErrEmailConflict   = dao.ErrEmailConflict
+	ErrPhoneConflict   = dao.ErrPhoneConflict
 	ErrUserNotFound    = dao.ErrUserNotFound
 	ErrProfileConflict = dao.ErrProfileConflict
+	ErrProfileNotFound = dao.ErrProfileNotFound
 	ErrCacheNotExist   = cache.ErrCacheNotExist
+	ErrCacheStale      = cache.ErrCacheStale
+	ErrCacheNotFound   = cache.ErrCacheNotFound
+)
+
+const (
+	// refreshTimeout 后台刷新不跟着请求走，单独给个超时
+	refreshTimeout = time.Second * 3
+	// emailFilterBatchSize 建邮箱布隆过滤器时一次从库里取多少个
+	emailFilterBatchSize = 1000
 )
 
 type CachedUserRepository struct {
 	dao   dao.UserDAO
 	cache cache.UserCache
+	// 同一个key同一时间只查一次库，key 带上查询方式，比如 email:xx、id:1
+	group singleflight.Group
+	// 所有注册过的邮箱，判定不存在的直接返回，不查缓存和库
+	emails bloom.Filter
+	// 邮箱没加进过滤器，清就绪标记也失败了，过滤器这时还可能误判，先不用它，等 EmailFilterReady 清掉标记去重建
+	emailsDirty atomic.Bool
 }
 
-func NewCachedUserRepository(dao dao.UserDAO, cache cache.UserCache) UserRepository {
+func NewCachedUserRepository(dao dao.UserDAO, cache cache.UserCache, emails bloom.Filter) UserRepository {
 	return &CachedUserRepository{
-		dao:   dao,
-		cache: cache,
+		dao:    dao,
+		cache:  cache,
+		emails: emails,
 	}
 
 }
@@ -45,10 +71,17 @@ func NewCachedUserRepository(dao dao.UserDAO, cache cache.UserCache) UserReposit
  */
 func (r *CachedUserRepository) Create(ctx context.Context, user *domain.User) error {
 	userDao, err := r.dao.Insert(ctx, dao.User{
-		Email:    user.Email,
+		Email: sql.NullString{
+			String: user.Email,
+			Valid:  user.Email != "",
+		},
 		Password: user.Password,
+		Status:   uint8(user.Status),
 	})
 	if err == nil {
+		user.Id = userDao.Id
+		r.addEmail(ctx, user.Email)
+		// 覆盖掉之前查询时记下的不存在
 		return r.cache.SetUser(ctx, userDao)
 	}
 	return err
@@ -61,26 +94,97 @@ func (r *CachedUserRepository) Create(ctx context.Context, user *domain.User) er
  * @return {*domain.User, error}
  */
 func (r *CachedUserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
-	user, err := r.cache.FindUserByEmail(ctx, email)
+	key := emailFilterKey(email)
+	if !r.emailsDirty.Load() {
+		ok, err := r.emails.MightContain(ctx, key)
+		if err != nil {
+			// 过滤器用不了就照常查缓存和库
+			log.Printf("查询邮箱布隆过滤器失败: %v", err)
+		} else if !ok {
+			return &domain.User{}, ErrUserNotFound
+		}
+	}
+	// 大小写不同的邮箱在库里是同一个用户，合并请求、查缓存、记不存在都用规整过的邮箱
+	user, err := cacheLoader[dao.User]{
+		key: "email:" + key,
+		fromCache: func(ctx context.Context) (dao.User, error) {
+			return r.cache.FindUserByEmail(ctx, key)
+		},
+		fromDb: func(ctx context.Context) (dao.User, error) {
+			return r.dao.FindByEmail(ctx, email)
+		},
+		set:      r.cache.SetUser,
+		notFound: ErrUserNotFound,
+		setNotFound: func(ctx context.Context) error {
+			return r.cache.SetUserNotFound(ctx, dao.User{Email: sql.NullString{String: key, Valid: true}})
+		},
+	}.load(ctx, &r.group)
 	if err != nil {
-		if err == ErrCacheNotExist {
-			user, err = r.dao.FindByEmail(ctx, email)
-			if err != nil {
-				return &domain.User{}, err
-			}
-			err = r.cache.SetUser(ctx, user)
-			if err != nil {
-				// TODO 监控
-			}
-		} else {
+		return &domain.User{}, err
+	}
+	return r.toDomain(user), nil
+}
+
+/**
+ * @description: 根据手机号获取用户
+ * @param {context.Context} ctx
+ * @param {string} phone
+ * @return {*domain.User, error}
+ */
+func (r *CachedUserRepository) FindByPhone(ctx context.Context, phone string) (*domain.User, error) {
+	user, err := cacheLoader[dao.User]{
+		key: "phone:" + phone,
+		fromCache: func(ctx context.Context) (dao.User, error) {
+			return r.cache.FindUserByPhone(ctx, phone)
+		},
+		fromDb: func(ctx context.Context) (dao.User, error) {
+			return r.dao.FindByPhone(ctx, phone)
+		},
+		set:      r.cache.SetUser,
+		notFound: ErrUserNotFound,
+		setNotFound: func(ctx context.Context) error {
+			return r.cache.SetUserNotFound(ctx, dao.User{Phone: sql.NullString{String: phone, Valid: true}})
+		},
+	}.load(ctx, &r.group)
+	if err != nil {
+		return &domain.User{}, err
+	}
+	return r.toDomain(user), nil
+}
+
+/**
+ * @description: 根据手机号获取用户，不存在则创建
+ * @param {context.Context} ctx
+ * @param {string} phone
+ * @return {*domain.User, error}
+ */
+func (r *CachedUserRepository) FindOrCreateByPhone(ctx context.Context, phone string) (*domain.User, error) {
+	user, err := r.FindByPhone(ctx, phone)
+	if err != ErrUserNotFound {
+		return user, err
+	}
+	userDao, err := r.dao.Insert(ctx, dao.User{
+		Phone: sql.NullString{
+			String: phone,
+			Valid:  true,
+		},
+	})
+	if err != nil {
+		// 只填了手机号，撞了哪个唯一索引都按并发首次登录处理，真不是手机号冲突的话下面查不到会返回错误
+		if err != ErrPhoneConflict && err != ErrEmailConflict {
+			return &domain.User{}, err
+		}
+		// 并发首次登录，别的请求已经建好了；直接查库，缓存里可能刚记下不存在
+		userDao, err = r.dao.FindByPhone(ctx, phone)
+		if err != nil {
 			return &domain.User{}, err
 		}
 	}
-	return &domain.User{
-		Id:       user.Id,
-		Email:    user.Email,
-		Password: user.Password,
-	}, err
+	err = r.cache.SetUser(ctx, userDao)
+	if err != nil {
+		// TODO 监控
+	}
+	return r.toDomain(userDao), nil
 }
 
 /**
@@ -90,26 +194,24 @@ func (r *CachedUserRepository) FindByEmail(ctx context.Context, email string) (*
  * @return {*domain.User, error}
  */
 func (r *CachedUserRepository) FindById(ctx context.Context, id uint64) (*domain.User, error) {
-	user, err := r.cache.FindUserById(ctx, id)
+	user, err := cacheLoader[dao.User]{
+		key: fmt.Sprintf("id:%d", id),
+		fromCache: func(ctx context.Context) (dao.User, error) {
+			return r.cache.FindUserById(ctx, id)
+		},
+		fromDb: func(ctx context.Context) (dao.User, error) {
+			return r.dao.FindById(ctx, id)
+		},
+		set:      r.cache.SetUser,
+		notFound: ErrUserNotFound,
+		setNotFound: func(ctx context.Context) error {
+			return r.cache.SetUserNotFound(ctx, dao.User{Id: id})
+		},
+	}.load(ctx, &r.group)
 	if err != nil {
-		if err == ErrCacheNotExist {
-			user, err = r.dao.FindById(ctx, id)
-			if err != nil {
-				return &domain.User{}, err
-			}
-			err = r.cache.SetUser(ctx, user)
-			if err != nil {
-				// TODO 监控
-			}
-		} else {
-			return &domain.User{}, err
-		}
+		return &domain.User{}, err
 	}
-	return &domain.User{
-		Id:       user.Id,
-		Email:    user.Email,
-		Password: user.Password,
-	}, err
+	return r.toDomain(user), nil
 }
 
 /**
@@ -119,26 +221,30 @@ func (r *CachedUserRepository) FindById(ctx context.Context, id uint64) (*domain
  * @return {*domain.Profile, error}
  */
 func (r *CachedUserRepository) FindProfileByUser(ctx context.Context, user dao.User) (*domain.Profile, error) {
-	profile, err := r.cache.FindProfileByUser(ctx, user)
+	profile, err := cacheLoader[dao.Profile]{
+		key: fmt.Sprintf("profile:%d", user.Id),
+		fromCache: func(ctx context.Context) (dao.Profile, error) {
+			return r.cache.FindProfileByUser(ctx, user)
+		},
+		fromDb: func(ctx context.Context) (dao.Profile, error) {
+			return r.dao.FindProfileByUser(ctx, user)
+		},
+		set:      r.cache.SetProfile,
+		notFound: ErrProfileNotFound,
+		setNotFound: func(ctx context.Context) error {
+			return r.cache.SetProfileNotFound(ctx, user.Id)
+		},
+	}.load(ctx, &r.group)
 	if err != nil {
-		if err == ErrCacheNotExist {
-			profile, err = r.dao.FindProfileByUser(ctx, user)
-			if err != nil {
-				return &domain.Profile{}, err
-			}
-			err = r.cache.SetProfile(ctx, profile)
-			if err != nil {
-				//TODO 监控
-			}
-		} else {
-			return &domain.Profile{}, err
-		}
+		return &domain.Profile{}, err
 	}
 	return &domain.Profile{
-		UserId:      profile.UserId,
-		NickName:    profile.Nickname,
-		BirthDay:    profile.Birthday,
-		Description: profile.Description,
+		UserId:             profile.UserId,
+		NickName:           profile.Nickname,
+		BirthDay:           profile.Birthday,
+		Description:        profile.Description,
+		BirthdayVisibility: domain.Visibility(profile.BirthdayVisibility),
+		EmailVisibility:    domain.Visibility(profile.EmailVisibility),
 	}, err
 }
 
@@ -151,23 +257,31 @@ func (r *CachedUserRepository) FindProfileByUser(ctx context.Context, user dao.U
  */
 func (r *CachedUserRepository) AddProfile(ctx context.Context, user *domain.User, profile *domain.Profile) (*domain.Profile, error) {
 	userDao := dao.User{
-		Id:    user.Id,
-		Email: user.Email,
+		Id: user.Id,
+		Email: sql.NullString{
+			String: user.Email,
+			Valid:  user.Email != "",
+		},
 	}
-	profileDao := dao.Profile{
-		UserId:      user.Id,
-		Nickname:    profile.NickName,
-		Birthday:    profile.BirthDay,
-		Description: profile.Description,
+	newProfileDao := dao.Profile{
+		UserId:             user.Id,
+		Nickname:           profile.NickName,
+		Birthday:           profile.BirthDay,
+		Description:        profile.Description,
+		BirthdayVisibility: uint8(profile.BirthdayVisibility),
+		EmailVisibility:    uint8(profile.EmailVisibility),
 	}
-	profileDao, err := r.dao.InsertProfile(ctx, userDao, profileDao)
+	profileDao, err := r.dao.InsertProfile(ctx, userDao, newProfileDao)
 	if err != nil {
 		if err == ErrProfileConflict {
-			profileDao, err = r.dao.FindProfileByUser(ctx, userDao)
+			existProfileDao, err := r.dao.FindProfileByUser(ctx, userDao)
 			if err != nil {
 				return &domain.Profile{}, err
 			}
-			profileDao, err = r.dao.UpdateProfile(ctx, profileDao)
+			// 已有档案时用新内容覆盖，主键和创建时间沿用原来的
+			newProfileDao.Id = existProfileDao.Id
+			newProfileDao.Createtime = existProfileDao.Createtime
+			profileDao, err = r.dao.UpdateProfile(ctx, newProfileDao)
 			if err != nil {
 				return &domain.Profile{}, err
 			}
@@ -177,9 +291,252 @@ func (r *CachedUserRepository) AddProfile(ctx context.Context, user *domain.User
 	}
 	profile.UserId = user.Id
 	user.Profile = *profile
+	// 覆盖掉之前查询时记下的没有档案
 	err = r.cache.SetProfile(ctx, profileDao)
 	if err != nil {
 		return &domain.Profile{}, err
 	}
 	return profile, err
 }
+
+/**
+ * @description: 数据库用户转领域用户
+ * @param {dao.User} user
+ * @return {*domain.User}
+ */
+func (r *CachedUserRepository) toDomain(user dao.User) *domain.User {
+	return &domain.User{
+		Id:       user.Id,
+		Email:    user.Email.String,
+		Phone:    user.Phone.String,
+		Password: user.Password,
+		Status:   domain.UserStatus(user.Status),
+	}
+}
+
+/**
+ * @description: 更新密码，缓存里的用户带着旧密码，一起删掉
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {string} password 加密后的密码
+ * @return {error}
+ */
+func (r *CachedUserRepository) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	err := r.dao.UpdatePassword(ctx, id, password)
+	if err != nil {
+		return err
+	}
+	user, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	return r.cache.DeleteUser(ctx, user)
+}
+
+/**
+ * @description: 更新邮箱，按id和新旧邮箱缓存的用户都要删掉
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {string} email
+ * @return {error}
+ */
+func (r *CachedUserRepository) UpdateEmail(ctx context.Context, id uint64, email string) error {
+	user, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = r.dao.UpdateEmail(ctx, id, email)
+	if err != nil {
+		return err
+	}
+	r.addEmail(ctx, email)
+	err = r.cache.DeleteUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	user.Email = sql.NullString{String: email, Valid: true}
+	return r.cache.DeleteUser(ctx, user)
+}
+
+/**
+ * @description: 更新账号状态，缓存里的用户带着旧状态，一起删掉
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {domain.UserStatus} status
+ * @return {error}
+ */
+func (r *CachedUserRepository) UpdateStatus(ctx context.Context, id uint64, status domain.UserStatus) error {
+	err := r.dao.UpdateStatus(ctx, id, uint8(status))
+	if err != nil {
+		return err
+	}
+	user, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	return r.cache.DeleteUser(ctx, user)
+}
+
+/**
+ * @description: 注销账号，用户和档案的缓存都要删掉
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @return {error}
+ */
+func (r *CachedUserRepository) Delete(ctx context.Context, id uint64) error {
+	user, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = r.dao.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = r.cache.DeleteUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	return r.cache.DeleteProfile(ctx, id)
+}
+
+/**
+ * @description: 彻底删除过了保留期的注销账号，缓存在注销时已经删过了
+ * @param {context.Context} ctx
+ * @param {time.Time} before
+ * @param {int} limit
+ * @return {int64, error}
+ */
+func (r *CachedUserRepository) Purge(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return r.dao.Purge(ctx, before.UnixMilli(), limit)
+}
+
+/**
+ * @description: 把所有注册过的邮箱加进布隆过滤器，加完之后过滤器才会判定不存在
+ * @param {context.Context} ctx
+ * @return {error}
+ */
+func (r *CachedUserRepository) LoadEmailFilter(ctx context.Context) error {
+	var afterId uint64
+	for {
+		users, err := r.dao.FindEmails(ctx, afterId, emailFilterBatchSize)
+		if err != nil {
+			return err
+		}
+		emails := make([]string, 0, len(users))
+		for _, user := range users {
+			emails = append(emails, emailFilterKey(user.Email.String))
+			afterId = user.Id
+		}
+		if err = r.emails.Add(ctx, emails...); err != nil {
+			return err
+		}
+		if len(users) < emailFilterBatchSize {
+			break
+		}
+	}
+	return r.emails.MarkReady(ctx)
+}
+
+/**
+ * @description: 过滤器是不是建好了，没建好要调 LoadEmailFilter 重建
+ * @param {context.Context} ctx
+ * @return {bool, error}
+ */
+func (r *CachedUserRepository) EmailFilterReady(ctx context.Context) (bool, error) {
+	if r.emailsDirty.Load() {
+		if err := r.emails.MarkNotReady(ctx); err != nil {
+			return false, err
+		}
+		r.emailsDirty.Store(false)
+		return false, nil
+	}
+	return r.emails.Ready(ctx)
+}
+
+// addEmail 库已经写好了，加不进去不能让请求失败；过滤器是所有实例共用的，清掉就绪标记让大家都不再判定不存在，等后台重建
+func (r *CachedUserRepository) addEmail(ctx context.Context, email string) {
+	if email == "" {
+		return
+	}
+	err := r.emails.Add(ctx, emailFilterKey(email))
+	if err == nil {
+		return
+	}
+	log.Printf("邮箱加进布隆过滤器失败 %s: %v", email, err)
+	if err = r.emails.MarkNotReady(ctx); err != nil {
+		log.Printf("清邮箱布隆过滤器就绪标记失败: %v", err)
+		r.emailsDirty.Store(true)
+	}
+}
+
+// emailFilterKey 邮箱列是不区分大小写的排序规则，库里 Foo@x.com 和 foo@x.com 是同一个，过滤器也要当成同一个
+func emailFilterKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// cacheLoader 一种查询方式怎么查缓存、查库、回填
+type cacheLoader[T any] struct {
+	// singleflight 的key
+	key       string
+	fromCache func(ctx context.Context) (T, error)
+	fromDb    func(ctx context.Context) (T, error)
+	// 回填缓存，失败只记日志
+	set func(ctx context.Context, val T) error
+	// 数据库返回 notFound 时调 setNotFound 在缓存里记下不存在，缓存里记着不存在也返回 notFound
+	notFound    error
+	setNotFound func(ctx context.Context) error
+}
+
+/**
+ * @description: 先查缓存，没有就查库回填，同一个key并发的请求只有一个去查库；
+ * 缓存过了软过期先返回旧值，后台查库刷新，刷新也按key合并
+ * @param {context.Context} ctx 合并的请求共用第一个请求的 ctx
+ * @param {*singleflight.Group} group
+ * @return {T, error}
+ */
+func (l cacheLoader[T]) load(ctx context.Context, group *singleflight.Group) (T, error) {
+	var zero T
+	val, err := l.fromCache(ctx)
+	switch err {
+	case nil:
+		return val, nil
+	case ErrCacheNotFound:
+		return zero, l.notFound
+	case ErrCacheStale:
+		// 不等结果，正在刷新的话不会再查一次库
+		group.DoChan(l.key, func() (any, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+			defer cancel()
+			return l.loadAndSet(ctx)
+		})
+		return val, nil
+	case ErrCacheNotExist:
+	default:
+		return zero, err
+	}
+
+	res, err, _ := group.Do(l.key, func() (any, error) {
+		return l.loadAndSet(ctx)
+	})
+	if err != nil {
+		return zero, err
+	}
+	return res.(T), nil
+}
+
+func (l cacheLoader[T]) loadAndSet(ctx context.Context) (T, error) {
+	val, err := l.fromDb(ctx)
+	if err == l.notFound {
+		if err := l.setNotFound(ctx); err != nil {
+			log.Printf("缓存记录 %s 不存在失败: %v", l.key, err)
+		}
+		return val, err
+	}
+	if err != nil {
+		return val, err
+	}
+	if err = l.set(ctx, val); err != nil {
+		log.Printf("回填用户缓存 %s 失败: %v", l.key, err)
+	}
+	return val, nil
+}
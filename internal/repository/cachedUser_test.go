@@ -11,8 +11,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-playground/assert/v2"
 	"github.com/gz4z2b/go-webook/internal/domain"
@@ -20,6 +24,8 @@ import (
 	cachemocks "github.com/gz4z2b/go-webook/internal/repository/cache/mocks"
 	"github.com/gz4z2b/go-webook/internal/repository/dao"
 	daomocks "github.com/gz4z2b/go-webook/internal/repository/dao/mocks"
+	"github.com/gz4z2b/go-webook/pkg/bloom"
+	bloommocks "github.com/gz4z2b/go-webook/pkg/bloom/mocks"
 	"go.uber.org/mock/gomock"
 )
 
@@ -43,14 +49,14 @@ func TestCachedUserRepository_Create(t *testing.T) {
 				cache := cachemocks.NewMockUserCache(ctrl)
 
 				daoMock.EXPECT().Insert(gomock.Any(), dao.User{
-					Email:    "gz4z2b@163.com",
+					Email:    sql.NullString{String: "gz4z2b@163.com", Valid: true},
 					Password: "19890821Xi_",
 				}).Return(dao.User{
-					Email:    "gz4z2b@163.com",
+					Email:    sql.NullString{String: "gz4z2b@163.com", Valid: true},
 					Password: "19890821Xi_",
 				}, nil)
 				cache.EXPECT().SetUser(gomock.Any(), dao.User{
-					Email:    "gz4z2b@163.com",
+					Email:    sql.NullString{String: "gz4z2b@163.com", Valid: true},
 					Password: "19890821Xi_",
 				}).Return(nil)
 
@@ -69,7 +75,7 @@ func TestCachedUserRepository_Create(t *testing.T) {
 				//cache := cachemocks.NewMockUserCache(ctrl)
 
 				daoMock.EXPECT().Insert(gomock.Any(), dao.User{
-					Email:    "gz4z2b@163.com",
+					Email:    sql.NullString{String: "gz4z2b@163.com", Valid: true},
 					Password: "19890821Xi_",
 				}).Return(dao.User{}, ErrEmailConflict)
 				// cache.EXPECT().SetUser(gomock.Any(), dao.User{
@@ -87,7 +93,7 @@ func TestCachedUserRepository_Create(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			dao, cache := tt.mock(ctrl)
-			repo := NewCachedUserRepository(dao, cache)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
 
 			err := repo.Create(context.Background(), tt.inputUser)
 
@@ -113,7 +119,7 @@ func TestCachedUserRepository_FindByEmail(t *testing.T) {
 				daoMock := daomocks.NewMockUserDAO(ctrl)
 				daoMock.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(dao.User{
 					Id:       1,
-					Email:    "gz4z2b@163.com",
+					Email:    sql.NullString{String: "gz4z2b@163.com", Valid: true},
 					Password: "19890821Xi_",
 				}, nil)
 
@@ -121,7 +127,7 @@ func TestCachedUserRepository_FindByEmail(t *testing.T) {
 				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2b@163.com").Return(dao.User{}, ErrCacheNotExist)
 				cacheMock.EXPECT().SetUser(gomock.Any(), dao.User{
 					Id:       1,
-					Email:    "gz4z2b@163.com",
+					Email:    sql.NullString{String: "gz4z2b@163.com", Valid: true},
 					Password: "19890821Xi_",
 				}).Return(nil)
 
@@ -143,6 +149,9 @@ func TestCachedUserRepository_FindByEmail(t *testing.T) {
 
 				cacheMock := cachemocks.NewMockUserCache(ctrl)
 				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2a@163.com").Return(dao.User{}, ErrCacheNotExist)
+				cacheMock.EXPECT().SetUserNotFound(gomock.Any(), dao.User{
+					Email: sql.NullString{String: "gz4z2a@163.com", Valid: true},
+				}).Return(nil)
 
 				return daoMock, cacheMock
 			},
@@ -158,7 +167,7 @@ func TestCachedUserRepository_FindByEmail(t *testing.T) {
 				cacheMock := cachemocks.NewMockUserCache(ctrl)
 				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2b@163.com").Return(dao.User{
 					Id:       1,
-					Email:    "gz4z2b@163.com",
+					Email:    sql.NullString{String: "gz4z2b@163.com", Valid: true},
 					Password: "19890821Xi_",
 				}, nil)
 
@@ -171,13 +180,45 @@ func TestCachedUserRepository_FindByEmail(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name:       "大小写不同按规整过的邮箱查缓存",
+			inputEmail: "GZ4Z2A@163.com",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().FindByEmail(gomock.Any(), "GZ4Z2A@163.com").Return(dao.User{}, ErrUserNotFound)
+
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2a@163.com").Return(dao.User{}, ErrCacheNotExist)
+				cacheMock.EXPECT().SetUserNotFound(gomock.Any(), dao.User{
+					Email: sql.NullString{String: "gz4z2a@163.com", Valid: true},
+				}).Return(nil)
+
+				return daoMock, cacheMock
+			},
+			wantUser: &domain.User{},
+			wantErr:  ErrUserNotFound,
+		},
+		{
+			name:       "缓存记着不存在",
+			inputEmail: "gz4z2a@163.com",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2a@163.com").Return(dao.User{}, ErrCacheNotFound)
+
+				return daoMock, cacheMock
+			},
+			wantUser: &domain.User{},
+			wantErr:  ErrUserNotFound,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			dao, cache := tt.mock(ctrl)
-			repo := NewCachedUserRepository(dao, cache)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
 
 			user, err := repo.FindByEmail(context.Background(), tt.inputEmail)
 
@@ -187,6 +228,312 @@ func TestCachedUserRepository_FindByEmail(t *testing.T) {
 	}
 }
 
+var emailUser = dao.User{
+	Id:    1,
+	Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
+}
+
+func TestCachedUserRepository_EmailFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		// 不填查 gz4z2b@163.com
+		email string
+		mock  func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, bloom.Filter)
+		// 先注册再查
+		create   bool
+		wantUser *domain.User
+		wantErr  error
+	}{
+		{
+			name: "判定不存在不查缓存和库",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, bloom.Filter) {
+				filter := bloommocks.NewMockFilter(ctrl)
+				filter.EXPECT().MightContain(gomock.Any(), "gz4z2b@163.com").Return(false, nil)
+				return daomocks.NewMockUserDAO(ctrl), cachemocks.NewMockUserCache(ctrl), filter
+			},
+			wantUser: &domain.User{},
+			wantErr:  ErrUserNotFound,
+		},
+		{
+			name: "过滤器炸了照常查",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, bloom.Filter) {
+				filter := bloommocks.NewMockFilter(ctrl)
+				filter.EXPECT().MightContain(gomock.Any(), "gz4z2b@163.com").Return(true, errors.New("缓存炸了"))
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2b@163.com").Return(emailUser, nil)
+				return daomocks.NewMockUserDAO(ctrl), cacheMock, filter
+			},
+			wantUser: &domain.User{Id: 1, Email: "gz4z2b@163.com"},
+		},
+		{
+			name:   "注册之后能查到",
+			create: true,
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, bloom.Filter) {
+				filter := bloom.NewLocalFilter(1000, 0.01)
+				_ = filter.MarkReady(context.Background())
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(emailUser, nil)
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().SetUser(gomock.Any(), emailUser).Return(nil)
+				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2b@163.com").Return(emailUser, nil)
+				return daoMock, cacheMock, filter
+			},
+			wantUser: &domain.User{Id: 1, Email: "gz4z2b@163.com"},
+		},
+		{
+			name:   "注册时没加进过滤器清掉就绪标记",
+			create: true,
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, bloom.Filter) {
+				filter := bloom.NewLocalFilter(1000, 0.01)
+				_ = filter.MarkReady(context.Background())
+				failing := bloommocks.NewMockFilter(ctrl)
+				// 加不进去，但清标记成功，之后判定都是可能存在
+				failing.EXPECT().Add(gomock.Any(), "gz4z2b@163.com").Return(errors.New("缓存炸了"))
+				failing.EXPECT().MarkNotReady(gomock.Any()).DoAndReturn(filter.MarkNotReady)
+				failing.EXPECT().MightContain(gomock.Any(), "gz4z2b@163.com").DoAndReturn(filter.MightContain)
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(emailUser, nil)
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().SetUser(gomock.Any(), emailUser).Return(nil)
+				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2b@163.com").Return(emailUser, nil)
+				return daoMock, cacheMock, failing
+			},
+			wantUser: &domain.User{Id: 1, Email: "gz4z2b@163.com"},
+		},
+		{
+			name:   "标记也清不掉先不用过滤器",
+			create: true,
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, bloom.Filter) {
+				filter := bloommocks.NewMockFilter(ctrl)
+				filter.EXPECT().Add(gomock.Any(), "gz4z2b@163.com").Return(errors.New("缓存炸了"))
+				filter.EXPECT().MarkNotReady(gomock.Any()).Return(errors.New("缓存炸了"))
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(emailUser, nil)
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().SetUser(gomock.Any(), emailUser).Return(nil)
+				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2b@163.com").Return(emailUser, nil)
+				return daoMock, cacheMock, filter
+			},
+			wantUser: &domain.User{Id: 1, Email: "gz4z2b@163.com"},
+		},
+		{
+			name:  "大小写不同也是同一个邮箱",
+			email: " GZ4Z2B@163.com",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache, bloom.Filter) {
+				filter := bloom.NewLocalFilter(1000, 0.01)
+				_ = filter.Add(context.Background(), "gz4z2b@163.com")
+				_ = filter.MarkReady(context.Background())
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByEmail(gomock.Any(), "gz4z2b@163.com").Return(emailUser, nil)
+				return daomocks.NewMockUserDAO(ctrl), cacheMock, filter
+			},
+			wantUser: &domain.User{Id: 1, Email: "gz4z2b@163.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := NewCachedUserRepository(tt.mock(ctrl))
+			if tt.email == "" {
+				tt.email = "gz4z2b@163.com"
+			}
+			if tt.create {
+				err := repo.Create(context.Background(), &domain.User{Email: "gz4z2b@163.com"})
+				assert.Equal(t, nil, err)
+			}
+
+			got, err := repo.FindByEmail(context.Background(), tt.email)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantUser, got)
+		})
+	}
+}
+
+func TestCachedUserRepository_LoadEmailFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	full := make([]dao.User, emailFilterBatchSize)
+	for i := range full {
+		full[i] = dao.User{Id: uint64(i + 1), Email: sql.NullString{String: fmt.Sprintf("user-%d@163.com", i+1), Valid: true}}
+	}
+	daoMock := daomocks.NewMockUserDAO(ctrl)
+	// 取满一批接着取，从上一批最后一个id往后
+	gomock.InOrder(
+		daoMock.EXPECT().FindEmails(gomock.Any(), uint64(0), emailFilterBatchSize).Return(full, nil),
+		daoMock.EXPECT().FindEmails(gomock.Any(), uint64(emailFilterBatchSize), emailFilterBatchSize).Return([]dao.User{emailUser}, nil),
+	)
+	filter := bloom.NewLocalFilter(10000, 0.01)
+	repo := NewCachedUserRepository(daoMock, cachemocks.NewMockUserCache(ctrl), filter)
+
+	assert.Equal(t, nil, repo.LoadEmailFilter(context.Background()))
+	for _, email := range []string{"user-1@163.com", "user-1000@163.com", "gz4z2b@163.com"} {
+		ok, _ := filter.MightContain(context.Background(), email)
+		assert.Equal(t, true, ok)
+	}
+	ok, _ := filter.MightContain(context.Background(), "nobody@163.com")
+	assert.Equal(t, false, ok)
+}
+
+func TestCachedUserRepository_EmailFilterReady(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	filter := bloommocks.NewMockFilter(ctrl)
+	gomock.InOrder(
+		filter.EXPECT().Add(gomock.Any(), "gz4z2b@163.com").Return(errors.New("缓存炸了")),
+		filter.EXPECT().MarkNotReady(gomock.Any()).Return(errors.New("缓存炸了")),
+		// 后台检查时 redis 还没好，下次接着清
+		filter.EXPECT().MarkNotReady(gomock.Any()).Return(errors.New("缓存炸了")),
+		filter.EXPECT().MarkNotReady(gomock.Any()).Return(nil),
+		filter.EXPECT().Ready(gomock.Any()).Return(true, nil),
+	)
+	daoMock := daomocks.NewMockUserDAO(ctrl)
+	daoMock.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(emailUser, nil)
+	cacheMock := cachemocks.NewMockUserCache(ctrl)
+	cacheMock.EXPECT().SetUser(gomock.Any(), emailUser).Return(nil)
+	repo := NewCachedUserRepository(daoMock, cacheMock, filter)
+	assert.Equal(t, nil, repo.Create(context.Background(), &domain.User{Email: "gz4z2b@163.com"}))
+
+	ready, err := repo.EmailFilterReady(context.Background())
+	assert.Equal(t, errors.New("缓存炸了"), err)
+	assert.Equal(t, false, ready)
+	ready, err = repo.EmailFilterReady(context.Background())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ready)
+	// 标记清掉了，重建完之后看过滤器自己的
+	ready, err = repo.EmailFilterReady(context.Background())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ready)
+}
+
+func TestCachedUserRepository_FindOrCreateByPhone(t *testing.T) {
+	phoneUser := dao.User{
+		Id:    1,
+		Phone: sql.NullString{String: "13800138000", Valid: true},
+	}
+	tests := []struct {
+		name       string
+		inputPhone string
+		mock       func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+		wantUser   *domain.User
+		wantErr    error
+	}{
+		{
+			name:       "已注册命中缓存",
+			inputPhone: "13800138000",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByPhone(gomock.Any(), "13800138000").Return(phoneUser, nil)
+				return daoMock, cacheMock
+			},
+			wantUser: &domain.User{
+				Id:    1,
+				Phone: "13800138000",
+			},
+			wantErr: nil,
+		},
+		{
+			name:       "首次登录自动注册",
+			inputPhone: "13800138000",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByPhone(gomock.Any(), "13800138000").Return(dao.User{}, ErrCacheNotExist)
+				daoMock.EXPECT().FindByPhone(gomock.Any(), "13800138000").Return(dao.User{}, ErrUserNotFound)
+				cacheMock.EXPECT().SetUserNotFound(gomock.Any(), dao.User{
+					Phone: sql.NullString{String: "13800138000", Valid: true},
+				}).Return(nil)
+				daoMock.EXPECT().Insert(gomock.Any(), dao.User{
+					Phone: sql.NullString{String: "13800138000", Valid: true},
+				}).Return(phoneUser, nil)
+				cacheMock.EXPECT().SetUser(gomock.Any(), phoneUser).Return(nil)
+				return daoMock, cacheMock
+			},
+			wantUser: &domain.User{
+				Id:    1,
+				Phone: "13800138000",
+			},
+			wantErr: nil,
+		},
+		{
+			name:       "并发注册冲突",
+			inputPhone: "13800138000",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByPhone(gomock.Any(), "13800138000").Return(dao.User{}, ErrCacheNotExist)
+				gomock.InOrder(
+					daoMock.EXPECT().FindByPhone(gomock.Any(), "13800138000").Return(dao.User{}, ErrUserNotFound),
+					cacheMock.EXPECT().SetUserNotFound(gomock.Any(), gomock.Any()).Return(nil),
+					daoMock.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(dao.User{}, ErrPhoneConflict),
+					// 重新查库，回填覆盖掉刚记下的不存在
+					daoMock.EXPECT().FindByPhone(gomock.Any(), "13800138000").Return(phoneUser, nil),
+					cacheMock.EXPECT().SetUser(gomock.Any(), phoneUser).Return(nil),
+				)
+				return daoMock, cacheMock
+			},
+			wantUser: &domain.User{
+				Id:    1,
+				Phone: "13800138000",
+			},
+			wantErr: nil,
+		},
+		{
+			name:       "冲突没认出是手机号也重新查",
+			inputPhone: "13800138000",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByPhone(gomock.Any(), "13800138000").Return(dao.User{}, ErrCacheNotExist)
+				gomock.InOrder(
+					daoMock.EXPECT().FindByPhone(gomock.Any(), "13800138000").Return(dao.User{}, ErrUserNotFound),
+					cacheMock.EXPECT().SetUserNotFound(gomock.Any(), gomock.Any()).Return(nil),
+					daoMock.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(dao.User{}, ErrEmailConflict),
+					daoMock.EXPECT().FindByPhone(gomock.Any(), "13800138000").Return(phoneUser, nil),
+					cacheMock.EXPECT().SetUser(gomock.Any(), phoneUser).Return(nil),
+				)
+				return daoMock, cacheMock
+			},
+			wantUser: &domain.User{
+				Id:    1,
+				Phone: "13800138000",
+			},
+			wantErr: nil,
+		},
+		{
+			name:       "数据库炸了",
+			inputPhone: "13800138000",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindUserByPhone(gomock.Any(), "13800138000").Return(dao.User{}, ErrCacheNotExist)
+				daoMock.EXPECT().FindByPhone(gomock.Any(), "13800138000").Return(dao.User{}, ErrUserNotFound)
+				cacheMock.EXPECT().SetUserNotFound(gomock.Any(), dao.User{
+					Phone: sql.NullString{String: "13800138000", Valid: true},
+				}).Return(nil)
+				daoMock.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(dao.User{}, errors.New("数据库炸了"))
+				return daoMock, cacheMock
+			},
+			wantUser: &domain.User{},
+			wantErr:  errors.New("数据库炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			dao, cache := tt.mock(ctrl)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
+
+			user, err := repo.FindOrCreateByPhone(context.Background(), tt.inputPhone)
+
+			assert.Equal(t, tt.wantUser, user)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
 func TestCachedUserRepository_FindById(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -203,14 +550,14 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 				daoMock := daomocks.NewMockUserDAO(ctrl)
 				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}, nil)
 
 				cacheMock := cachemocks.NewMockUserCache(ctrl)
 				cacheMock.EXPECT().FindUserById(gomock.Any(), uint64(1)).Return(dao.User{}, ErrCacheNotExist)
 				cacheMock.EXPECT().SetUser(gomock.Any(), dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}).Return(nil)
 
 				return daoMock, cacheMock
@@ -230,6 +577,7 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 
 				cacheMock := cachemocks.NewMockUserCache(ctrl)
 				cacheMock.EXPECT().FindUserById(gomock.Any(), uint64(1)).Return(dao.User{}, ErrCacheNotExist)
+				cacheMock.EXPECT().SetUserNotFound(gomock.Any(), dao.User{Id: 1}).Return(nil)
 
 				return daoMock, cacheMock
 			},
@@ -251,20 +599,20 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 			wantErr:  errors.New("缓d炸了"),
 		},
 		{
-			name:    "设置缓存失败",
+			name:    "设置缓存失败不影响返回",
 			inputId: uint64(1),
 			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
 				daoMock := daomocks.NewMockUserDAO(ctrl)
 				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}, nil)
 
 				cacheMock := cachemocks.NewMockUserCache(ctrl)
 				cacheMock.EXPECT().FindUserById(gomock.Any(), uint64(1)).Return(dao.User{}, ErrCacheNotExist)
 				cacheMock.EXPECT().SetUser(gomock.Any(), dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}).Return(errors.New("设置失败"))
 
 				return daoMock, cacheMock
@@ -273,7 +621,7 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 				Id:    uint64(1),
 				Email: "gz4z2b@163.com",
 			},
-			wantErr: errors.New("设置失败"),
+			wantErr: nil,
 		},
 	}
 	for _, tt := range tests {
@@ -281,7 +629,7 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			dao, cache := tt.mock(ctrl)
-			repo := NewCachedUserRepository(dao, cache)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
 
 			user, err := repo.FindById(context.Background(), tt.inputId)
 
@@ -291,6 +639,70 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 	}
 }
 
+func TestCachedUserRepository_FindByIdStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stale := dao.User{Id: 1, Email: sql.NullString{String: "old@163.com", Valid: true}}
+	fresh := dao.User{Id: 1, Email: sql.NullString{String: "gz4z2b@163.com", Valid: true}}
+
+	daoMock := daomocks.NewMockUserDAO(ctrl)
+	daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(fresh, nil)
+	cacheMock := cachemocks.NewMockUserCache(ctrl)
+	cacheMock.EXPECT().FindUserById(gomock.Any(), uint64(1)).Return(stale, ErrCacheStale)
+	refreshed := make(chan struct{})
+	cacheMock.EXPECT().SetUser(gomock.Any(), fresh).DoAndReturn(func(ctx context.Context, user dao.User) error {
+		close(refreshed)
+		return nil
+	})
+	repo := NewCachedUserRepository(daoMock, cacheMock, bloom.NewNopFilter())
+
+	// 先拿到旧值，后台再查库回填
+	user, err := repo.FindById(context.Background(), 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "old@163.com", user.Email)
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("后台没有刷新缓存")
+	}
+}
+
+func TestCachedUserRepository_FindByIdConcurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	const n = 10
+	found := dao.User{Id: 1, Email: sql.NullString{String: "gz4z2b@163.com", Valid: true}}
+
+	release := make(chan struct{})
+	daoMock := daomocks.NewMockUserDAO(ctrl)
+	// 并发未命中只查一次库
+	daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).DoAndReturn(func(ctx context.Context, id uint64) (dao.User, error) {
+		<-release
+		return found, nil
+	}).Times(1)
+	cacheMock := cachemocks.NewMockUserCache(ctrl)
+	cacheMock.EXPECT().FindUserById(gomock.Any(), uint64(1)).Return(dao.User{}, ErrCacheNotExist).Times(n)
+	cacheMock.EXPECT().SetUser(gomock.Any(), found).Return(nil).Times(1)
+	repo := NewCachedUserRepository(daoMock, cacheMock, bloom.NewNopFilter())
+
+	var wg sync.WaitGroup
+	users := make([]*domain.User, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			users[i], _ = repo.FindById(context.Background(), 1)
+		}(i)
+	}
+	// 等其他请求都排到第一个后面
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+	for _, user := range users {
+		assert.Equal(t, "gz4z2b@163.com", user.Email)
+	}
+}
+
 func TestCachedUserRepository_FindProfileByUser(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -304,13 +716,13 @@ func TestCachedUserRepository_FindProfileByUser(t *testing.T) {
 			name: "正常",
 			inputUser: dao.User{
 				Id:    uint64(1),
-				Email: "gz4z2b@163.com",
+				Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 			},
 			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
 				cacheMock := cachemocks.NewMockUserCache(ctrl)
 				cacheMock.EXPECT().FindProfileByUser(gomock.Any(), dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}).Return(dao.Profile{}, ErrCacheNotExist)
 				cacheMock.EXPECT().SetProfile(gomock.Any(), dao.Profile{
 					UserId:   1,
@@ -320,7 +732,7 @@ func TestCachedUserRepository_FindProfileByUser(t *testing.T) {
 				daoMock := daomocks.NewMockUserDAO(ctrl)
 				daoMock.EXPECT().FindProfileByUser(gomock.Any(), dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}).Return(dao.Profile{
 					UserId:   1,
 					Nickname: "test",
@@ -338,19 +750,19 @@ func TestCachedUserRepository_FindProfileByUser(t *testing.T) {
 			name: "不存在",
 			inputUser: dao.User{
 				Id:    uint64(1),
-				Email: "gz4z2b@163.com",
+				Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 			},
 			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
 				cacheMock := cachemocks.NewMockUserCache(ctrl)
 				cacheMock.EXPECT().FindProfileByUser(gomock.Any(), dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}).Return(dao.Profile{}, ErrCacheNotExist)
 
 				daoMock := daomocks.NewMockUserDAO(ctrl)
 				daoMock.EXPECT().FindProfileByUser(gomock.Any(), dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}).Return(dao.Profile{}, errors.New("档案不存在"))
 
 				return daoMock, cacheMock
@@ -358,17 +770,49 @@ func TestCachedUserRepository_FindProfileByUser(t *testing.T) {
 			wantProfile: &domain.Profile{},
 			wantErr:     errors.New("档案不存在"),
 		},
+		{
+			name: "没有档案记进缓存",
+			inputUser: dao.User{
+				Id: uint64(1),
+			},
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindProfileByUser(gomock.Any(), dao.User{Id: uint64(1)}).Return(dao.Profile{}, ErrCacheNotExist)
+				cacheMock.EXPECT().SetProfileNotFound(gomock.Any(), uint64(1)).Return(nil)
+
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().FindProfileByUser(gomock.Any(), dao.User{Id: uint64(1)}).Return(dao.Profile{}, ErrProfileNotFound)
+
+				return daoMock, cacheMock
+			},
+			wantProfile: &domain.Profile{},
+			wantErr:     ErrProfileNotFound,
+		},
+		{
+			name: "缓存记着没有档案",
+			inputUser: dao.User{
+				Id: uint64(1),
+			},
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().FindProfileByUser(gomock.Any(), dao.User{Id: uint64(1)}).Return(dao.Profile{}, ErrCacheNotFound)
+
+				return daomocks.NewMockUserDAO(ctrl), cacheMock
+			},
+			wantProfile: &domain.Profile{},
+			wantErr:     ErrProfileNotFound,
+		},
 		{
 			name: "缓存炸了",
 			inputUser: dao.User{
 				Id:    uint64(1),
-				Email: "gz4z2b@163.com",
+				Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 			},
 			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
 				cacheMock := cachemocks.NewMockUserCache(ctrl)
 				cacheMock.EXPECT().FindProfileByUser(gomock.Any(), dao.User{
 					Id:    uint64(1),
-					Email: "gz4z2b@163.com",
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
 				}).Return(dao.Profile{}, errors.New("缓存炸了"))
 
 				daoMock := daomocks.NewMockUserDAO(ctrl)
@@ -383,7 +827,7 @@ func TestCachedUserRepository_FindProfileByUser(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			dao, cache := tt.mock(ctrl)
-			repo := NewCachedUserRepository(dao, cache)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
 
 			profile, err := repo.FindProfileByUser(context.Background(), tt.inputUser)
 
@@ -430,6 +874,46 @@ func TestCachedUserRepository_AddProfile(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "已有档案用新内容覆盖",
+			inputUser: &domain.User{
+				Id:    uint64(1),
+				Email: "gz4z2b@163.com",
+			},
+			inputProfile: &domain.Profile{
+				NickName:           "new",
+				BirthdayVisibility: domain.VisibilityPublic,
+			},
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().InsertProfile(context.Background(), gomock.Any(), gomock.Any()).Return(dao.Profile{}, ErrProfileConflict)
+				daoMock.EXPECT().FindProfileByUser(gomock.Any(), gomock.Any()).Return(dao.Profile{
+					Id:         uint64(10),
+					UserId:     uint64(1),
+					Nickname:   "old",
+					Createtime: 1000,
+				}, nil)
+				updated := dao.Profile{
+					Id:                 uint64(10),
+					UserId:             uint64(1),
+					Nickname:           "new",
+					BirthdayVisibility: uint8(domain.VisibilityPublic),
+					Createtime:         1000,
+				}
+				daoMock.EXPECT().UpdateProfile(gomock.Any(), updated).Return(updated, nil)
+
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().SetProfile(gomock.Any(), updated).Return(nil)
+
+				return daoMock, cacheMock
+			},
+			wantProfile: &domain.Profile{
+				UserId:             uint64(1),
+				NickName:           "new",
+				BirthdayVisibility: domain.VisibilityPublic,
+			},
+			wantErr: nil,
+		},
 		{
 			name: "更新获取失败",
 			inputUser: &domain.User{
@@ -532,7 +1016,7 @@ func TestCachedUserRepository_AddProfile(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			dao, cache := tt.mock(ctrl)
-			repo := NewCachedUserRepository(dao, cache)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
 
 			profile, err := repo.AddProfile(context.Background(), tt.inputUser, tt.inputProfile)
 
@@ -541,3 +1025,216 @@ func TestCachedUserRepository_AddProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestCachedUserRepository_UpdatePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().UpdatePassword(gomock.Any(), uint64(1), "hash").Return(nil)
+				user := dao.User{
+					Id:       uint64(1),
+					Email:    sql.NullString{String: "gz4z2b@163.com", Valid: true},
+					Password: "hash",
+				}
+				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(user, nil)
+
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				// 缓存里还是旧密码，删掉
+				cacheMock.EXPECT().DeleteUser(gomock.Any(), user).Return(nil)
+				return daoMock, cacheMock
+			},
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().UpdatePassword(gomock.Any(), uint64(1), "hash").Return(ErrUserNotFound)
+				return daoMock, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "删缓存失败",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().UpdatePassword(gomock.Any(), uint64(1), "hash").Return(nil)
+				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(dao.User{Id: uint64(1)}, nil)
+
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().DeleteUser(gomock.Any(), gomock.Any()).Return(errors.New("缓存炸了"))
+				return daoMock, cacheMock
+			},
+			wantErr: errors.New("缓存炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dao, cache := tt.mock(ctrl)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
+			err := repo.UpdatePassword(context.Background(), uint64(1), "hash")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_UpdateEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				old := dao.User{
+					Id:    uint64(1),
+					Email: sql.NullString{String: "old@163.com", Valid: true},
+				}
+				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(old, nil)
+				daoMock.EXPECT().UpdateEmail(gomock.Any(), uint64(1), "new@163.com").Return(nil)
+
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				// 新旧邮箱的缓存都要删
+				cacheMock.EXPECT().DeleteUser(gomock.Any(), old).Return(nil)
+				cacheMock.EXPECT().DeleteUser(gomock.Any(), dao.User{
+					Id:    uint64(1),
+					Email: sql.NullString{String: "new@163.com", Valid: true},
+				}).Return(nil)
+				return daoMock, cacheMock
+			},
+		},
+		{
+			name: "邮箱冲突",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(dao.User{Id: uint64(1)}, nil)
+				daoMock.EXPECT().UpdateEmail(gomock.Any(), uint64(1), "new@163.com").Return(ErrEmailConflict)
+				return daoMock, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: ErrEmailConflict,
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(dao.User{}, ErrUserNotFound)
+				return daoMock, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dao, cache := tt.mock(ctrl)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
+			err := repo.UpdateEmail(context.Background(), uint64(1), "new@163.com")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_UpdateStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().UpdateStatus(gomock.Any(), uint64(1), uint8(domain.UserStatusActive)).Return(nil)
+				user := dao.User{
+					Id:    uint64(1),
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
+				}
+				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(user, nil)
+
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				cacheMock.EXPECT().DeleteUser(gomock.Any(), user).Return(nil)
+				return daoMock, cacheMock
+			},
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().UpdateStatus(gomock.Any(), uint64(1), uint8(domain.UserStatusActive)).Return(ErrUserNotFound)
+				return daoMock, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dao, cache := tt.mock(ctrl)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
+			err := repo.UpdateStatus(context.Background(), uint64(1), domain.UserStatusActive)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestCachedUserRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				user := dao.User{
+					Id:    uint64(1),
+					Email: sql.NullString{String: "gz4z2b@163.com", Valid: true},
+				}
+				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(user, nil)
+				daoMock.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+
+				cacheMock := cachemocks.NewMockUserCache(ctrl)
+				// 不删的话注销之后还能从缓存里查到
+				cacheMock.EXPECT().DeleteUser(gomock.Any(), user).Return(nil)
+				cacheMock.EXPECT().DeleteProfile(gomock.Any(), uint64(1)).Return(nil)
+				return daoMock, cacheMock
+			},
+		},
+		{
+			name: "删除失败不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				daoMock := daomocks.NewMockUserDAO(ctrl)
+				daoMock.EXPECT().FindById(gomock.Any(), uint64(1)).Return(dao.User{Id: uint64(1)}, nil)
+				daoMock.EXPECT().Delete(gomock.Any(), uint64(1)).Return(errors.New("数据库炸了"))
+				return daoMock, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: errors.New("数据库炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dao, cache := tt.mock(ctrl)
+			repo := NewCachedUserRepository(dao, cache, bloom.NewNopFilter())
+			err := repo.Delete(context.Background(), uint64(1))
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 18:05:47
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/dao/asyncSmsMysql.go
+ * @Description: 异步短信队列表
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAsyncSmsNotFound error = errors.New("没有待发送的短信")
+	// ErrAsyncSmsLost 上报结果时版本号对不上，发送超时后被别的实例重新抢占了
+	ErrAsyncSmsLost error = errors.New("异步短信已被重新抢占")
+)
+
+const (
+	AsyncSmsStatusWaiting uint8 = iota
+	AsyncSmsStatusSending
+	AsyncSmsStatusSuccess
+	AsyncSmsStatusFailed
+)
+
+// 抢占时别的实例改了同一行，换一行再试的次数
+const preemptRetryTimes = 3
+
+// redactedConfig 发完或者放弃之后参数就没用了，验证码不能一直明文留在库里；号码和模板留着排查问题
+var redactedConfig = gorm.Expr("JSON_REMOVE(config, '$.args')")
+
+type AsyncSmsMysqlDAO struct {
+	db *gorm.DB
+}
+
+func NewAsyncSmsMysqlDAO(db *gorm.DB) AsyncSmsDAO {
+	return &AsyncSmsMysqlDAO{
+		db: db,
+	}
+}
+
+/**
+ * @description: 插入一条待发送的短信
+ * @param {context.Context} ctx
+ * @param {AsyncSms} sms
+ * @return {error}
+ */
+func (d *AsyncSmsMysqlDAO) Insert(ctx context.Context, sms AsyncSms) error {
+	return d.db.WithContext(ctx).Create(&sms).Error
+}
+
+/**
+ * @description: 抢占一条到了重试时间的短信，发送中但超时没有上报结果的也会被重新抢占
+ * @param {context.Context} ctx
+ * @param {int64} now 当前时间（毫秒）
+ * @param {int64} sendingTimeout 发送中状态的超时时间（毫秒）
+ * @return {AsyncSms, error}
+ */
+func (d *AsyncSmsMysqlDAO) Preempt(ctx context.Context, now int64, sendingTimeout int64) (AsyncSms, error) {
+	for i := 0; i < preemptRetryTimes; i++ {
+		var sms AsyncSms
+		err := d.db.WithContext(ctx).
+			Where("(status = ? AND next_retry_time <= ?) OR (status = ? AND updatetime < ?)",
+				AsyncSmsStatusWaiting, now, AsyncSmsStatusSending, now-sendingTimeout).
+			Order("id").
+			First(&sms).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return AsyncSms{}, ErrAsyncSmsNotFound
+		}
+		if err != nil {
+			return AsyncSms{}, err
+		}
+		// 用版本号做乐观锁，多个实例同时抢只有一个能成功
+		res := d.db.WithContext(ctx).Model(&AsyncSms{}).
+			Where("id = ? AND version = ?", sms.Id, sms.Version).
+			Updates(map[string]any{
+				"status":     AsyncSmsStatusSending,
+				"version":    sms.Version + 1,
+				"updatetime": now,
+			})
+		if res.Error != nil {
+			return AsyncSms{}, res.Error
+		}
+		if res.RowsAffected == 1 {
+			sms.Status = AsyncSmsStatusSending
+			sms.Version++
+			sms.Updatetime = now
+			return sms, nil
+		}
+	}
+	return AsyncSms{}, ErrAsyncSmsNotFound
+}
+
+/**
+ * @description: 发送成功
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version 抢占时拿到的版本号
+ * @return {error}
+ */
+func (d *AsyncSmsMysqlDAO) MarkSuccess(ctx context.Context, id uint64, version int64) error {
+	return d.mark(ctx, id, version, map[string]any{
+		"status": AsyncSmsStatusSuccess,
+		"config": redactedConfig,
+	})
+}
+
+/**
+ * @description: 发送失败，等下次重试
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version
+ * @param {int64} nextRetryTime 下次重试时间（毫秒）
+ * @return {error}
+ */
+func (d *AsyncSmsMysqlDAO) MarkRetry(ctx context.Context, id uint64, version int64, nextRetryTime int64) error {
+	return d.mark(ctx, id, version, map[string]any{
+		"status":          AsyncSmsStatusWaiting,
+		"retry_cnt":       gorm.Expr("retry_cnt + 1"),
+		"next_retry_time": nextRetryTime,
+	})
+}
+
+/**
+ * @description: 重试次数用完或者不可重试，不再发送
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version
+ * @return {error}
+ */
+func (d *AsyncSmsMysqlDAO) MarkFailed(ctx context.Context, id uint64, version int64) error {
+	return d.mark(ctx, id, version, map[string]any{
+		"status":    AsyncSmsStatusFailed,
+		"retry_cnt": gorm.Expr("retry_cnt + 1"),
+		"config":    redactedConfig,
+	})
+}
+
+/**
+ * @description: 抢到了但是还在限流，放回队列，不算重试次数
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version
+ * @return {error}
+ */
+func (d *AsyncSmsMysqlDAO) MarkWaiting(ctx context.Context, id uint64, version int64) error {
+	return d.mark(ctx, id, version, map[string]any{
+		"status": AsyncSmsStatusWaiting,
+	})
+}
+
+/**
+ * @description: 版本号还是抢占时的才更新，被别的实例重新抢占了返回 ErrAsyncSmsLost，不能覆盖新主人的状态
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {int64} version
+ * @param {map[string]any} updates
+ * @return {error}
+ */
+func (d *AsyncSmsMysqlDAO) mark(ctx context.Context, id uint64, version int64, updates map[string]any) error {
+	updates["version"] = version + 1
+	res := d.db.WithContext(ctx).Model(&AsyncSms{}).
+		Where("id = ? AND version = ?", id, version).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAsyncSmsLost
+	}
+	return nil
+}
+
+type AsyncSms struct {
+	Id uint64 `gorm:"primaryKey,not null,autoIncrement"`
+	// 号码、模板、参数序列化后的json
+	Config        string
+	RetryCnt      int
+	RetryMax      int
+	Status        uint8
+	NextRetryTime int64
+	Version       int64
+
+	Createtime int64 `gorm:"autoCreateTime:milli"`
+	Updatetime int64 `gorm:"autoUpdateTime:milli"`
+}
+
+func (s AsyncSms) TableName() string {
+	return "t_async_sms"
+}
@@ -0,0 +1,80 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 23:02:14
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/dao/asyncSmsMysql_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package dao
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB 只生成SQL不连库，返回的函数取最后一条 UPDATE
+func newDryRunDB(t *testing.T) (*gorm.DB, func() string) {
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "root:root@tcp(127.0.0.1:3306)/webook", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.NoError(t, err)
+	var sql string
+	err = db.Callback().Update().After("gorm:update").Register("test:capture", func(db *gorm.DB) {
+		sql = db.Statement.SQL.String()
+	})
+	assert.NoError(t, err)
+	return db, func() string {
+		return sql
+	}
+}
+
+func TestAsyncSmsMysqlDAO_RedactArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		mark func(d AsyncSmsDAO) error
+		// 发完、放弃了才去掉参数，还要重试的得留着
+		wantRedact bool
+	}{
+		{
+			name: "发送成功",
+			mark: func(d AsyncSmsDAO) error {
+				return d.MarkSuccess(context.Background(), 1, 1)
+			},
+			wantRedact: true,
+		},
+		{
+			name: "不再重试",
+			mark: func(d AsyncSmsDAO) error {
+				return d.MarkFailed(context.Background(), 1, 1)
+			},
+			wantRedact: true,
+		},
+		{
+			name: "等下次重试",
+			mark: func(d AsyncSmsDAO) error {
+				return d.MarkRetry(context.Background(), 1, 1, 0)
+			},
+		},
+		{
+			name: "放回队列",
+			mark: func(d AsyncSmsDAO) error {
+				return d.MarkWaiting(context.Background(), 1, 1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, lastSQL := newDryRunDB(t)
+			// 不连库影响行数是0，会报被重新抢占，这里只看SQL
+			_ = tt.mark(NewAsyncSmsMysqlDAO(db))
+			assert.Contains(t, lastSQL(), "UPDATE `t_async_sms`")
+			assert.Equal(t, tt.wantRedact, strings.Contains(lastSQL(), "JSON_REMOVE(config, '$.args')"))
+		})
+	}
+}
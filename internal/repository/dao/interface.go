@@ -3,7 +3,7 @@
  * @Date: 2023-09-15 17:17:35
  * @LastEditors: p_hanxichen
  * @FilePath: /go/src/webook/internal/repository/dao/interface.go
- * @Description: 用户数据库接口
+ * @Description: 数据库接口
  *
  * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
  */
@@ -14,8 +14,26 @@ import "context"
 type UserDAO interface {
 	Insert(ctx context.Context, user User) (User, error)
 	FindByEmail(ctx context.Context, email string) (User, error)
+	FindByPhone(ctx context.Context, phone string) (User, error)
 	FindById(ctx context.Context, id uint64) (User, error)
 	FindProfileByUser(ctx context.Context, user User) (Profile, error)
 	InsertProfile(ctx context.Context, user User, profile Profile) (Profile, error)
 	UpdateProfile(ctx context.Context, profile Profile) (Profile, error)
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	UpdateEmail(ctx context.Context, id uint64, email string) error
+	UpdateStatus(ctx context.Context, id uint64, status uint8) error
+	Delete(ctx context.Context, id uint64) error
+	Purge(ctx context.Context, before int64, limit int) (int64, error)
+	// FindEmails 按id从小到大分批取有邮箱的用户，用来建邮箱的布隆过滤器
+	FindEmails(ctx context.Context, afterId uint64, limit int) ([]User, error)
+}
+
+type AsyncSmsDAO interface {
+	Insert(ctx context.Context, sms AsyncSms) error
+	Preempt(ctx context.Context, now int64, sendingTimeout int64) (AsyncSms, error)
+	// MarkSuccess 等上报结果的方法 version 传抢占时拿到的，被重新抢占了返回 ErrAsyncSmsLost
+	MarkSuccess(ctx context.Context, id uint64, version int64) error
+	MarkRetry(ctx context.Context, id uint64, version int64, nextRetryTime int64) error
+	MarkFailed(ctx context.Context, id uint64, version int64) error
+	MarkWaiting(ctx context.Context, id uint64, version int64) error
 }
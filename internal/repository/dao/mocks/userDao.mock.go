@@ -0,0 +1,339 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/dao/interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/repository/dao/interface.go -package=daomocks -destination=./internal/repository/dao/mocks/userDao.mock.go
+//
+// Package daomocks is a generated GoMock package.
+package daomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	dao "github.com/gz4z2b/go-webook/internal/repository/dao"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserDAO is a mock of UserDAO interface.
+type MockUserDAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserDAOMockRecorder
+}
+
+// MockUserDAOMockRecorder is the mock recorder for MockUserDAO.
+type MockUserDAOMockRecorder struct {
+	mock *MockUserDAO
+}
+
+// NewMockUserDAO creates a new mock instance.
+func NewMockUserDAO(ctrl *gomock.Controller) *MockUserDAO {
+	mock := &MockUserDAO{ctrl: ctrl}
+	mock.recorder = &MockUserDAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserDAO) EXPECT() *MockUserDAOMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockUserDAO) Delete(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserDAOMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserDAO)(nil).Delete), ctx, id)
+}
+
+// FindByEmail mocks base method.
+func (m *MockUserDAO) FindByEmail(ctx context.Context, email string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByEmail", ctx, email)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByEmail indicates an expected call of FindByEmail.
+func (mr *MockUserDAOMockRecorder) FindByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockUserDAO)(nil).FindByEmail), ctx, email)
+}
+
+// FindById mocks base method.
+func (m *MockUserDAO) FindById(ctx context.Context, id uint64) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindById", ctx, id)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindById indicates an expected call of FindById.
+func (mr *MockUserDAOMockRecorder) FindById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockUserDAO)(nil).FindById), ctx, id)
+}
+
+// FindByPhone mocks base method.
+func (m *MockUserDAO) FindByPhone(ctx context.Context, phone string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByPhone", ctx, phone)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByPhone indicates an expected call of FindByPhone.
+func (mr *MockUserDAOMockRecorder) FindByPhone(ctx, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockUserDAO)(nil).FindByPhone), ctx, phone)
+}
+
+// FindEmails mocks base method.
+func (m *MockUserDAO) FindEmails(ctx context.Context, afterId uint64, limit int) ([]dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEmails", ctx, afterId, limit)
+	ret0, _ := ret[0].([]dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEmails indicates an expected call of FindEmails.
+func (mr *MockUserDAOMockRecorder) FindEmails(ctx, afterId, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEmails", reflect.TypeOf((*MockUserDAO)(nil).FindEmails), ctx, afterId, limit)
+}
+
+// FindProfileByUser mocks base method.
+func (m *MockUserDAO) FindProfileByUser(ctx context.Context, user dao.User) (dao.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProfileByUser", ctx, user)
+	ret0, _ := ret[0].(dao.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindProfileByUser indicates an expected call of FindProfileByUser.
+func (mr *MockUserDAOMockRecorder) FindProfileByUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProfileByUser", reflect.TypeOf((*MockUserDAO)(nil).FindProfileByUser), ctx, user)
+}
+
+// Insert mocks base method.
+func (m *MockUserDAO) Insert(ctx context.Context, user dao.User) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, user)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockUserDAOMockRecorder) Insert(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockUserDAO)(nil).Insert), ctx, user)
+}
+
+// InsertProfile mocks base method.
+func (m *MockUserDAO) InsertProfile(ctx context.Context, user dao.User, profile dao.Profile) (dao.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertProfile", ctx, user, profile)
+	ret0, _ := ret[0].(dao.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertProfile indicates an expected call of InsertProfile.
+func (mr *MockUserDAOMockRecorder) InsertProfile(ctx, user, profile any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertProfile", reflect.TypeOf((*MockUserDAO)(nil).InsertProfile), ctx, user, profile)
+}
+
+// Purge mocks base method.
+func (m *MockUserDAO) Purge(ctx context.Context, before int64, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockUserDAOMockRecorder) Purge(ctx, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockUserDAO)(nil).Purge), ctx, before, limit)
+}
+
+// UpdateEmail mocks base method.
+func (m *MockUserDAO) UpdateEmail(ctx context.Context, id uint64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", ctx, id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockUserDAOMockRecorder) UpdateEmail(ctx, id, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockUserDAO)(nil).UpdateEmail), ctx, id, email)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserDAO) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserDAOMockRecorder) UpdatePassword(ctx, id, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserDAO)(nil).UpdatePassword), ctx, id, password)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUserDAO) UpdateProfile(ctx context.Context, profile dao.Profile) (dao.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, profile)
+	ret0, _ := ret[0].(dao.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserDAOMockRecorder) UpdateProfile(ctx, profile any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserDAO)(nil).UpdateProfile), ctx, profile)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockUserDAO) UpdateStatus(ctx context.Context, id uint64, status uint8) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockUserDAOMockRecorder) UpdateStatus(ctx, id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockUserDAO)(nil).UpdateStatus), ctx, id, status)
+}
+
+// MockAsyncSmsDAO is a mock of AsyncSmsDAO interface.
+type MockAsyncSmsDAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockAsyncSmsDAOMockRecorder
+}
+
+// MockAsyncSmsDAOMockRecorder is the mock recorder for MockAsyncSmsDAO.
+type MockAsyncSmsDAOMockRecorder struct {
+	mock *MockAsyncSmsDAO
+}
+
+// NewMockAsyncSmsDAO creates a new mock instance.
+func NewMockAsyncSmsDAO(ctrl *gomock.Controller) *MockAsyncSmsDAO {
+	mock := &MockAsyncSmsDAO{ctrl: ctrl}
+	mock.recorder = &MockAsyncSmsDAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAsyncSmsDAO) EXPECT() *MockAsyncSmsDAOMockRecorder {
+	return m.recorder
+}
+
+// Insert mocks base method.
+func (m *MockAsyncSmsDAO) Insert(ctx context.Context, sms dao.AsyncSms) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, sms)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockAsyncSmsDAOMockRecorder) Insert(ctx, sms any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockAsyncSmsDAO)(nil).Insert), ctx, sms)
+}
+
+// MarkFailed mocks base method.
+func (m *MockAsyncSmsDAO) MarkFailed(ctx context.Context, id uint64, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockAsyncSmsDAOMockRecorder) MarkFailed(ctx, id, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockAsyncSmsDAO)(nil).MarkFailed), ctx, id, version)
+}
+
+// MarkRetry mocks base method.
+func (m *MockAsyncSmsDAO) MarkRetry(ctx context.Context, id uint64, version, nextRetryTime int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRetry", ctx, id, version, nextRetryTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRetry indicates an expected call of MarkRetry.
+func (mr *MockAsyncSmsDAOMockRecorder) MarkRetry(ctx, id, version, nextRetryTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRetry", reflect.TypeOf((*MockAsyncSmsDAO)(nil).MarkRetry), ctx, id, version, nextRetryTime)
+}
+
+// MarkSuccess mocks base method.
+func (m *MockAsyncSmsDAO) MarkSuccess(ctx context.Context, id uint64, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSuccess", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSuccess indicates an expected call of MarkSuccess.
+func (mr *MockAsyncSmsDAOMockRecorder) MarkSuccess(ctx, id, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSuccess", reflect.TypeOf((*MockAsyncSmsDAO)(nil).MarkSuccess), ctx, id, version)
+}
+
+// MarkWaiting mocks base method.
+func (m *MockAsyncSmsDAO) MarkWaiting(ctx context.Context, id uint64, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWaiting", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWaiting indicates an expected call of MarkWaiting.
+func (mr *MockAsyncSmsDAOMockRecorder) MarkWaiting(ctx, id, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWaiting", reflect.TypeOf((*MockAsyncSmsDAO)(nil).MarkWaiting), ctx, id, version)
+}
+
+// Preempt mocks base method.
+func (m *MockAsyncSmsDAO) Preempt(ctx context.Context, now, sendingTimeout int64) (dao.AsyncSms, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Preempt", ctx, now, sendingTimeout)
+	ret0, _ := ret[0].(dao.AsyncSms)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Preempt indicates an expected call of Preempt.
+func (mr *MockAsyncSmsDAOMockRecorder) Preempt(ctx, now, sendingTimeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Preempt", reflect.TypeOf((*MockAsyncSmsDAO)(nil).Preempt), ctx, now, sendingTimeout)
+}
@@ -11,14 +11,21 @@ package dao
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
+// userStatusDeleted 对应 domain.UserStatusDeleted
+const userStatusDeleted uint8 = 3
+
 var (
 	ErrEmailConflict   error = errors.New("邮箱冲突")
+	ErrPhoneConflict   error = errors.New("手机号冲突")
 	ErrUserNotFound    error = gorm.ErrRecordNotFound
 	ErrProfileNotFound error = gorm.ErrRecordNotFound
 	ErrProfileConflict error = errors.New("个人档案冲突")
@@ -49,12 +56,29 @@ func (u *UserMysqlDAO) Insert(ctx context.Context, user User) (User, error) {
 	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
 		const uniqueConflictsErrorNo uint16 = 1062
 		if mysqlErr.Number == uniqueConflictsErrorNo {
+			if conflictKey(mysqlErr.Message) == "uniq_phone" {
+				return User{}, ErrPhoneConflict
+			}
 			return User{}, ErrEmailConflict
 		}
 	}
 	return user, err
 }
 
+// conflictKey 从 1062 的报错里取出冲突的唯一索引名，MySQL 8 带表名前缀 for key 't_user.uniq_phone'，5.7 只有 for key 'uniq_phone'
+func conflictKey(msg string) string {
+	const prefix = "for key '"
+	i := strings.LastIndex(msg, prefix)
+	if i < 0 {
+		return ""
+	}
+	key := strings.TrimSuffix(msg[i+len(prefix):], "'")
+	if j := strings.LastIndex(key, "."); j >= 0 {
+		key = key[j+1:]
+	}
+	return key
+}
+
 /**
  * @description: 通过email查询用户
  * @param {context.Context} ctx
@@ -63,18 +87,34 @@ func (u *UserMysqlDAO) Insert(ctx context.Context, user User) (User, error) {
  */
 func (u *UserMysqlDAO) FindByEmail(ctx context.Context, email string) (User, error) {
 	var user User
-	err := u.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := u.db.WithContext(ctx).Where("email = ? AND deletetime = 0", email).First(&user).Error
+	if err != nil {
+		// 只有查不到才是 ErrUserNotFound，库挂了不能当成不存在缓存起来
+		return User{}, err
+	}
+	return user, err
+}
+
+/**
+ * @description: 通过手机号查询用户
+ * @param {context.Context} ctx
+ * @param {string} phone
+ * @return {User, error}
+ */
+func (u *UserMysqlDAO) FindByPhone(ctx context.Context, phone string) (User, error) {
+	var user User
+	err := u.db.WithContext(ctx).Where("phone = ? AND deletetime = 0", phone).First(&user).Error
 	if err != nil {
-		return User{}, ErrUserNotFound
+		return User{}, err
 	}
 	return user, err
 }
 
 func (u *UserMysqlDAO) FindById(ctx context.Context, id uint64) (User, error) {
 	var user User
-	err := u.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	err := u.db.WithContext(ctx).Where("id = ? AND deletetime = 0", id).First(&user).Error
 	if err != nil {
-		return User{}, ErrUserNotFound
+		return User{}, err
 	}
 	return user, err
 }
@@ -87,7 +127,7 @@ func (u *UserMysqlDAO) FindById(ctx context.Context, id uint64) (User, error) {
  */
 func (u *UserMysqlDAO) FindProfileByUser(ctx context.Context, user User) (Profile, error) {
 	var profile Profile
-	err := u.db.WithContext(ctx).Where("user_id = ?", user.Id).First(&profile).Error
+	err := u.db.WithContext(ctx).Where("user_id = ? AND deletetime = 0", user.Id).First(&profile).Error
 	if err != nil {
 		return Profile{}, err
 	}
@@ -125,14 +165,159 @@ func (u *UserMysqlDAO) UpdateProfile(ctx context.Context, profile Profile) (Prof
 	return profile, err
 }
 
+/**
+ * @description: 更新密码
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {string} password 加密后的密码
+ * @return {error}
+ */
+func (u *UserMysqlDAO) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	res := u.db.WithContext(ctx).Model(&User{}).Where("id = ? AND deletetime = 0", id).Updates(map[string]any{
+		"password":   password,
+		"updatetime": time.Now().UnixMilli(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+/**
+ * @description: 更新邮箱
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {string} email
+ * @return {error}
+ */
+func (u *UserMysqlDAO) UpdateEmail(ctx context.Context, id uint64, email string) error {
+	res := u.db.WithContext(ctx).Model(&User{}).Where("id = ? AND deletetime = 0", id).Updates(map[string]any{
+		"email":      sql.NullString{String: email, Valid: true},
+		"updatetime": time.Now().UnixMilli(),
+	})
+	if mysqlErr, ok := res.Error.(*mysql.MySQLError); ok {
+		const uniqueConflictsErrorNo uint16 = 1062
+		if mysqlErr.Number == uniqueConflictsErrorNo {
+			return ErrEmailConflict
+		}
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+/**
+ * @description: 更新账号状态
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {uint8} status
+ * @return {error}
+ */
+func (u *UserMysqlDAO) UpdateStatus(ctx context.Context, id uint64, status uint8) error {
+	res := u.db.WithContext(ctx).Model(&User{}).Where("id = ? AND deletetime = 0", id).Updates(map[string]any{
+		"status":     status,
+		"updatetime": time.Now().UnixMilli(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+/**
+ * @description: 注销账号，只打删除标记，过了保留期由 Purge 彻底删除
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @return {error}
+ */
+func (u *UserMysqlDAO) Delete(ctx context.Context, id uint64) error {
+	now := time.Now().UnixMilli()
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&User{}).Where("id = ? AND deletetime = 0", id).Updates(map[string]any{
+			"status":     userStatusDeleted,
+			"deletetime": now,
+			"updatetime": now,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+		return tx.Model(&Profile{}).Where("user_id = ? AND deletetime = 0", id).Updates(map[string]any{
+			"deletetime": now,
+			"updatetime": now,
+		}).Error
+	})
+}
+
+/**
+ * @description: 彻底删除注销时间早于 before 的用户和档案
+ * @param {context.Context} ctx
+ * @param {int64} before 毫秒时间戳
+ * @param {int} limit 一次最多删多少个用户，免得长时间锁表
+ * @return {int64, error} 删掉的用户数
+ */
+func (u *UserMysqlDAO) Purge(ctx context.Context, before int64, limit int) (int64, error) {
+	var purged int64
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint64
+		err := tx.Model(&User{}).Where("deletetime > 0 AND deletetime < ?", before).
+			Limit(limit).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		err = tx.Where("user_id IN ?", ids).Delete(&Profile{}).Error
+		if err != nil {
+			return err
+		}
+		res := tx.Where("id IN ?", ids).Delete(&User{})
+		purged = res.RowsAffected
+		return res.Error
+	})
+	return purged, err
+}
+
+/**
+ * @description: 按id分批取有邮箱的用户，只查id和邮箱
+ * @param {context.Context} ctx
+ * @param {uint64} afterId 从这个id之后开始，第一批传0
+ * @param {int} limit
+ * @return {[]User, error}
+ */
+func (u *UserMysqlDAO) FindEmails(ctx context.Context, afterId uint64, limit int) ([]User, error) {
+	var users []User
+	// 注销的也带上，保留期内找回账号不用等重建
+	err := u.db.WithContext(ctx).Select("id", "email").
+		Where("id > ? AND email IS NOT NULL", afterId).
+		Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
 type User struct {
-	Id       uint64 `gorm:"primaryKey,not null,autoIncrement"`
-	Email    string `gorm:"unique"`
+	Id uint64 `gorm:"primaryKey,not null,autoIncrement"`
+	// 邮箱注册与手机号登录的用户各自只有其中一项，用 NULL 避开唯一索引冲突
+	// 唯一索引带上 Deletetime，注销之后同一个邮箱、手机号可以重新注册
+	Email    sql.NullString `gorm:"uniqueIndex:uniq_email"`
+	Phone    sql.NullString `gorm:"uniqueIndex:uniq_phone"`
 	Password string
+	// 0 正常，1 待验证邮箱，2 禁用，3 已注销，对应 domain.UserStatus
+	Status uint8
 
 	Createtime int64 `gorm:"autoCreateTime:milli"`
 	Updatetime int64 `gorm:"autoUpdateTime:milli"`
-	Deletetime int64
+	// 0 表示没删除，注销时记下毫秒时间戳
+	Deletetime int64 `gorm:"uniqueIndex:uniq_email;uniqueIndex:uniq_phone"`
 
 	Profile Profile `gorm:"foreignKey:UserId"`
 }
@@ -148,9 +333,12 @@ type Profile struct {
 	Nickname    string
 	Birthday    int64
 	Description string
-	Createtime  int64 `gorm:"autoCreateTime:milli"`
-	Updatetime  int64 `gorm:"autoUpdateTime:milli"`
-	Deletetime  int64
+	// 0 仅自己可见，1 公开
+	BirthdayVisibility uint8
+	EmailVisibility    uint8
+	Createtime         int64 `gorm:"autoCreateTime:milli"`
+	Updatetime         int64 `gorm:"autoUpdateTime:milli"`
+	Deletetime         int64
 }
 
 func (p Profile) TableName() string {
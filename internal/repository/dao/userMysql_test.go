@@ -0,0 +1,50 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 22:41:25
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/repository/dao/userMysql_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package dao
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConflictKey(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			name: "mysql8带表名",
+			msg:  "Duplicate entry '13800138000-0' for key 't_user.uniq_phone'",
+			want: "uniq_phone",
+		},
+		{
+			name: "mysql5.7",
+			msg:  "Duplicate entry 'a@163.com-0' for key 'uniq_email'",
+			want: "uniq_email",
+		},
+		{
+			name: "号码里带索引名也不会认错",
+			msg:  "Duplicate entry 'uniq_phone@163.com-0' for key 't_user.uniq_email'",
+			want: "uniq_email",
+		},
+		{
+			name: "认不出来",
+			msg:  "Duplicate entry",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, conflictKey(tt.msg))
+		})
+	}
+}
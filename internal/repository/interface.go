@@ -11,6 +11,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/gz4z2b/go-webook/internal/domain"
 	"github.com/gz4z2b/go-webook/internal/repository/dao"
@@ -19,7 +20,50 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
+	FindByPhone(ctx context.Context, phone string) (*domain.User, error)
+	FindOrCreateByPhone(ctx context.Context, phone string) (*domain.User, error)
 	FindById(ctx context.Context, id uint64) (*domain.User, error)
 	FindProfileByUser(ctx context.Context, user dao.User) (*domain.Profile, error)
 	AddProfile(ctx context.Context, user *domain.User, profile *domain.Profile) (*domain.Profile, error)
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	UpdateEmail(ctx context.Context, id uint64, email string) error
+	UpdateStatus(ctx context.Context, id uint64, status domain.UserStatus) error
+	// Delete 注销账号，软删除
+	Delete(ctx context.Context, id uint64) error
+	// Purge 彻底删除注销时间早于 before 的账号，返回删掉的个数
+	Purge(ctx context.Context, before time.Time, limit int) (int64, error)
+	// LoadEmailFilter 从库里把所有邮箱加进布隆过滤器，启动时和 EmailFilterReady 返回 false 时调用
+	LoadEmailFilter(ctx context.Context) error
+	// EmailFilterReady 有邮箱没加进过滤器、或者过滤器被淘汰了返回 false
+	EmailFilterReady(ctx context.Context) (bool, error)
+}
+
+type CodeRepository interface {
+	Store(ctx context.Context, biz string, phone string, code string) error
+	Verify(ctx context.Context, biz string, phone string, inputCode string) (bool, error)
+}
+
+type PasswordResetRepository interface {
+	Store(ctx context.Context, uid uint64, nonce string, expiretion time.Duration) error
+	// Consume 凭证一次性有效，对得上返回 true 并作废
+	Consume(ctx context.Context, uid uint64, nonce string) (bool, error)
+}
+
+type LoginAttemptRepository interface {
+	// Find target 是账号或者IP，没有失败记录返回零值
+	Find(ctx context.Context, target string) (domain.LoginAttempt, error)
+	// Fail 记一次失败，返回记完之后的记录，expiretion 从这次失败开始算
+	Fail(ctx context.Context, target string, expiretion time.Duration) (domain.LoginAttempt, error)
+	Reset(ctx context.Context, target string) error
+}
+
+type AsyncSmsRepository interface {
+	Add(ctx context.Context, sms domain.AsyncSms) error
+	PreemptWaitingSms(ctx context.Context) (domain.AsyncSms, error)
+	// ReportSuccess 等上报结果的方法 version 传抢占时拿到的，被重新抢占了返回 ErrAsyncSmsLost
+	ReportSuccess(ctx context.Context, id uint64, version int64) error
+	ReportRetry(ctx context.Context, id uint64, version int64, nextRetryTime time.Time) error
+	ReportFailed(ctx context.Context, id uint64, version int64) error
+	// Release 抢到了但是发不了，放回队列，不算重试次数
+	Release(ctx context.Context, id uint64, version int64) error
 }
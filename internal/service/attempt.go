@@ -0,0 +1,181 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 22:38:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/attempt.go
+ * @Description: 登录防爆破，按账号和IP统计失败次数
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository"
+)
+
+var (
+	ErrLoginTooFrequent = errors.New("登录失败太频繁，请稍后再试")
+	ErrLoginLocked      = errors.New("登录失败次数过多，暂时锁定")
+)
+
+// LoginPolicy 登录失败的处理策略
+type LoginPolicy struct {
+	// 账号连续失败几次之后开始要求等待，等待时间每次翻倍
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// 账号失败几次锁定
+	AccountMaxFailures int
+	// 同一个IP失败几次锁定，一个IP可能是很多人共用的出口，阈值要比账号高
+	IpMaxFailures int
+	// 锁定多久，从最后一次失败开始算
+	Lockout time.Duration
+	// 多久没有新的失败就清掉记录
+	Window time.Duration
+}
+
+type LoginAttemptService interface {
+	// Check 登录前检查，不让登录时返回还要等多久
+	Check(ctx context.Context, email string, ip string) (time.Duration, error)
+	// Fail 邮箱不存在或者密码错了才算失败
+	Fail(ctx context.Context, email string, ip string) error
+	// Succeed 登录成功清掉账号的失败记录，IP的不清，免得用一个自己的账号给IP解锁
+	Succeed(ctx context.Context, email string) error
+	// UpdatePolicy 配置热更新时换策略，已有的失败记录按新策略算
+	UpdatePolicy(policy LoginPolicy)
+}
+
+type LoginAttemptServiceInstance struct {
+	repo   repository.LoginAttemptRepository
+	policy atomic.Pointer[LoginPolicy]
+	now    func() time.Time
+}
+
+/**
+ * @description: 登录防爆破服务
+ * @param {repository.LoginAttemptRepository} repo
+ * @param {LoginPolicy} policy
+ * @return {LoginAttemptService}
+ */
+func NewLoginAttemptService(repo repository.LoginAttemptRepository, policy LoginPolicy) LoginAttemptService {
+	svc := &LoginAttemptServiceInstance{
+		repo: repo,
+		now:  time.Now,
+	}
+	svc.policy.Store(&policy)
+	return svc
+}
+
+/**
+ * @description: 先看IP有没有被锁，再看账号，缓存出问题不拦着登录
+ * @param {context.Context} ctx
+ * @param {string} email
+ * @param {string} ip
+ * @return {time.Duration, error} 还要等多久
+ */
+func (svc *LoginAttemptServiceInstance) Check(ctx context.Context, email string, ip string) (time.Duration, error) {
+	now := svc.now()
+	policy := svc.policy.Load()
+
+	attempt, err := svc.repo.Find(ctx, ipTarget(ip))
+	if err != nil {
+		log.Printf("查询IP登录失败记录出错: %v", err)
+	} else if attempt.Failures >= policy.IpMaxFailures {
+		if wait := attempt.LastFailure.Add(policy.Lockout).Sub(now); wait > 0 {
+			return wait, ErrLoginLocked
+		}
+	}
+
+	attempt, err = svc.repo.Find(ctx, emailTarget(email))
+	if err != nil {
+		log.Printf("查询账号登录失败记录出错: %v", err)
+		return 0, nil
+	}
+	if attempt.Failures >= policy.AccountMaxFailures {
+		if wait := attempt.LastFailure.Add(policy.Lockout).Sub(now); wait > 0 {
+			return wait, ErrLoginLocked
+		}
+		return 0, nil
+	}
+	if wait := attempt.LastFailure.Add(policy.delay(attempt)).Sub(now); wait > 0 {
+		return wait, ErrLoginTooFrequent
+	}
+	return 0, nil
+}
+
+/**
+ * @description: 账号和IP各记一次失败
+ * @param {context.Context} ctx
+ * @param {string} email
+ * @param {string} ip
+ * @return {error}
+ */
+func (svc *LoginAttemptServiceInstance) Fail(ctx context.Context, email string, ip string) error {
+	policy := svc.policy.Load()
+	// 记录至少要留到锁定结束
+	expiretion := policy.Window
+	if expiretion < policy.Lockout {
+		expiretion = policy.Lockout
+	}
+	_, err := svc.repo.Fail(ctx, emailTarget(email), expiretion)
+	if err != nil {
+		return err
+	}
+	_, err = svc.repo.Fail(ctx, ipTarget(ip), expiretion)
+	return err
+}
+
+/**
+ * @description: 清掉账号的失败记录
+ * @param {context.Context} ctx
+ * @param {string} email
+ * @return {error}
+ */
+func (svc *LoginAttemptServiceInstance) Succeed(ctx context.Context, email string) error {
+	return svc.repo.Reset(ctx, emailTarget(email))
+}
+
+/**
+ * @description: 换登录失败的处理策略
+ * @param {LoginPolicy} policy
+ * @return {*}
+ */
+func (svc *LoginAttemptServiceInstance) UpdatePolicy(policy LoginPolicy) {
+	svc.policy.Store(&policy)
+}
+
+/**
+ * @description: 失败次数超过 DelayAfter 之后，每多失败一次等待时间翻倍，最多 MaxDelay
+ * @param {domain.LoginAttempt} attempt
+ * @return {time.Duration}
+ */
+func (policy *LoginPolicy) delay(attempt domain.LoginAttempt) time.Duration {
+	if attempt.Failures < policy.DelayAfter {
+		return 0
+	}
+	delay := policy.BaseDelay
+	for i := policy.DelayAfter; i < attempt.Failures && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return delay
+}
+
+// emailTarget 邮箱不区分大小写，不然换个大小写就能绕开账号的计数
+func emailTarget(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipTarget(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,208 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 23:11:46
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/attempt_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	repomocks "github.com/gz4z2b/go-webook/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testLoginPolicy = LoginPolicy{
+	DelayAfter:         3,
+	BaseDelay:          time.Second,
+	MaxDelay:           time.Second * 10,
+	AccountMaxFailures: 10,
+	IpMaxFailures:      50,
+	Lockout:            time.Minute * 15,
+	Window:             time.Hour,
+}
+
+var testLoginNow = time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+func newTestLoginAttemptService(repo repository.LoginAttemptRepository) *LoginAttemptServiceInstance {
+	svc := NewLoginAttemptService(repo, testLoginPolicy).(*LoginAttemptServiceInstance)
+	svc.now = func() time.Time {
+		return testLoginNow
+	}
+	return svc
+}
+
+func TestLoginAttemptServiceInstance_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		ip       domain.LoginAttempt
+		ipErr    error
+		account  domain.LoginAttempt
+		wantWait time.Duration
+		wantErr  error
+	}{
+		{
+			name:  "没有失败记录",
+			email: "gz4z2b@163.com",
+		},
+		{
+			name:    "失败次数不多不用等",
+			email:   "gz4z2b@163.com",
+			account: domain.LoginAttempt{Failures: 2, LastFailure: testLoginNow},
+		},
+		{
+			name:     "开始要等",
+			email:    "gz4z2b@163.com",
+			account:  domain.LoginAttempt{Failures: 3, LastFailure: testLoginNow.Add(-time.Millisecond * 400)},
+			wantWait: time.Millisecond * 600,
+			wantErr:  ErrLoginTooFrequent,
+		},
+		{
+			name:     "等待时间翻倍",
+			email:    "gz4z2b@163.com",
+			account:  domain.LoginAttempt{Failures: 5, LastFailure: testLoginNow},
+			wantWait: time.Second * 4,
+			wantErr:  ErrLoginTooFrequent,
+		},
+		{
+			name:     "等待时间有上限",
+			email:    "gz4z2b@163.com",
+			account:  domain.LoginAttempt{Failures: 9, LastFailure: testLoginNow},
+			wantWait: time.Second * 10,
+			wantErr:  ErrLoginTooFrequent,
+		},
+		{
+			name:    "等够了",
+			email:   "gz4z2b@163.com",
+			account: domain.LoginAttempt{Failures: 5, LastFailure: testLoginNow.Add(-time.Second * 4)},
+		},
+		{
+			name:     "账号锁定",
+			email:    "gz4z2b@163.com",
+			account:  domain.LoginAttempt{Failures: 10, LastFailure: testLoginNow.Add(-time.Minute * 5)},
+			wantWait: time.Minute * 10,
+			wantErr:  ErrLoginLocked,
+		},
+		{
+			name:    "锁定过期",
+			email:   "gz4z2b@163.com",
+			account: domain.LoginAttempt{Failures: 10, LastFailure: testLoginNow.Add(-time.Minute * 15)},
+		},
+		{
+			name:     "IP锁定",
+			email:    "gz4z2b@163.com",
+			ip:       domain.LoginAttempt{Failures: 50, LastFailure: testLoginNow},
+			wantWait: time.Minute * 15,
+			wantErr:  ErrLoginLocked,
+		},
+		{
+			name:     "查IP出错照样查账号",
+			email:    "gz4z2b@163.com",
+			ipErr:    errors.New("缓存炸了"),
+			account:  domain.LoginAttempt{Failures: 10, LastFailure: testLoginNow},
+			wantWait: time.Minute * 15,
+			wantErr:  ErrLoginLocked,
+		},
+		{
+			name:     "邮箱大小写算同一个账号",
+			email:    " GZ4Z2B@163.com",
+			account:  domain.LoginAttempt{Failures: 10, LastFailure: testLoginNow},
+			wantWait: time.Minute * 15,
+			wantErr:  ErrLoginLocked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+			repo.EXPECT().Find(gomock.Any(), "ip:192.0.2.1").Return(tt.ip, tt.ipErr)
+			// IP被锁了就不用再查账号
+			if tt.ip.Failures < testLoginPolicy.IpMaxFailures {
+				repo.EXPECT().Find(gomock.Any(), "email:gz4z2b@163.com").Return(tt.account, nil)
+			}
+
+			svc := newTestLoginAttemptService(repo)
+			wait, err := svc.Check(context.Background(), tt.email, "192.0.2.1")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantWait, wait)
+		})
+	}
+}
+
+func TestLoginAttemptServiceInstance_CheckCacheError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 缓存出问题不拦着登录
+	repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+	repo.EXPECT().Find(gomock.Any(), gomock.Any()).Return(domain.LoginAttempt{}, errors.New("缓存炸了")).Times(2)
+
+	svc := newTestLoginAttemptService(repo)
+	wait, err := svc.Check(context.Background(), "gz4z2b@163.com", "192.0.2.1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), wait)
+}
+
+func TestLoginAttemptServiceInstance_Fail(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) repository.LoginAttemptRepository
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				// 记录保留时间取 Window 和 Lockout 里长的那个
+				repo.EXPECT().Fail(gomock.Any(), "email:gz4z2b@163.com", time.Hour).Return(domain.LoginAttempt{Failures: 1}, nil)
+				repo.EXPECT().Fail(gomock.Any(), "ip:192.0.2.1", time.Hour).Return(domain.LoginAttempt{Failures: 1}, nil)
+				return repo
+			},
+		},
+		{
+			name: "缓存炸了",
+			mock: func(ctrl *gomock.Controller) repository.LoginAttemptRepository {
+				repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				repo.EXPECT().Fail(gomock.Any(), "email:gz4z2b@163.com", time.Hour).Return(domain.LoginAttempt{}, errors.New("缓存炸了"))
+				return repo
+			},
+			wantErr: errors.New("缓存炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := newTestLoginAttemptService(tt.mock(ctrl))
+			err := svc.Fail(context.Background(), "gz4z2b@163.com", "192.0.2.1")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestLoginAttemptServiceInstance_Succeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 只清账号的，IP的留着
+	repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+	repo.EXPECT().Reset(gomock.Any(), "email:gz4z2b@163.com").Return(nil)
+
+	svc := newTestLoginAttemptService(repo)
+	err := svc.Succeed(context.Background(), "Gz4z2b@163.com")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,125 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 10:52:44
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/code.go
+ * @Description: 验证码服务
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/gz4z2b/go-webook/internal/repository"
+	"github.com/gz4z2b/go-webook/internal/service/email"
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+)
+
+const (
+	// CodeBizLogin 短信登录
+	CodeBizLogin = "login"
+	// CodeBizResetPassword 重置密码
+	CodeBizResetPassword = "reset_password"
+	// CodeBizChangeEmail 修改邮箱
+	CodeBizChangeEmail = "change_email"
+	// CodeBizDeleteAccount 没设密码的账号注销
+	CodeBizDeleteAccount = "delete_account"
+
+	codeTplId = "1877556"
+
+	codeEmailSubject = "小微书验证码"
+)
+
+var (
+	ErrCodeSendTooMany        = repository.ErrCodeSendTooMany
+	ErrCodeVerifyTooManyTimes = repository.ErrCodeVerifyTooManyTimes
+)
+
+type CodeService interface {
+	Send(ctx context.Context, biz string, phone string) error
+	// SendEmail 验证码发到邮箱，校验同样用 Verify，phone 传邮箱
+	SendEmail(ctx context.Context, biz string, email string) error
+	Verify(ctx context.Context, biz string, phone string, inputCode string) (bool, error)
+}
+
+type CodeServiceInstance struct {
+	repo     repository.CodeRepository
+	smsSvc   sms.Service
+	emailSvc email.Service
+}
+
+func NewCodeService(repo repository.CodeRepository, smsSvc sms.Service, emailSvc email.Service) CodeService {
+	return &CodeServiceInstance{
+		repo:     repo,
+		smsSvc:   smsSvc,
+		emailSvc: emailSvc,
+	}
+}
+
+/**
+ * @description: 生成并发送验证码，一分钟内只能发一次
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} phone
+ * @return {error}
+ */
+func (svc *CodeServiceInstance) Send(ctx context.Context, biz string, phone string) error {
+	code, err := svc.generateCode()
+	if err != nil {
+		return err
+	}
+	err = svc.repo.Store(ctx, biz, phone, code)
+	if err != nil {
+		return err
+	}
+	return svc.smsSvc.Send(ctx, []string{phone}, codeTplId, []string{code})
+}
+
+/**
+ * @description: 生成验证码发到邮箱，跟短信验证码一样一分钟内只能发一次
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} email
+ * @return {error}
+ */
+func (svc *CodeServiceInstance) SendEmail(ctx context.Context, biz string, email string) error {
+	code, err := svc.generateCode()
+	if err != nil {
+		return err
+	}
+	err = svc.repo.Store(ctx, biz, email, code)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("<p>你的验证码是 <b>%s</b>，十分钟内有效。</p><p>如果不是你本人操作，请忽略这封邮件。</p>", code)
+	return svc.emailSvc.Send(ctx, email, codeEmailSubject, body)
+}
+
+/**
+ * @description: 校验验证码，十分钟有效，最多验证三次
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} phone
+ * @param {string} inputCode
+ * @return {bool, error}
+ */
+func (svc *CodeServiceInstance) Verify(ctx context.Context, biz string, phone string, inputCode string) (bool, error) {
+	return svc.repo.Verify(ctx, biz, phone, inputCode)
+}
+
+/**
+ * @description: 生成6位数字验证码，用 crypto/rand，math/rand 的序列能被推出来
+ * @return {string, error}
+ */
+func (svc *CodeServiceInstance) generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
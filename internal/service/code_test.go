@@ -0,0 +1,142 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 11:46:09
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/code_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	repomocks "github.com/gz4z2b/go-webook/internal/repository/mocks"
+	"github.com/gz4z2b/go-webook/internal/service/email"
+	emailmocks "github.com/gz4z2b/go-webook/internal/service/email/mocks"
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	smsmocks "github.com/gz4z2b/go-webook/internal/service/sms/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCodeServiceInstance_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				smsSvc := smsmocks.NewMockService(ctrl)
+				var code string
+				repo.EXPECT().Store(gomock.Any(), CodeBizLogin, "13800138000", gomock.Any()).
+					DoAndReturn(func(ctx context.Context, biz string, phone string, inputCode string) error {
+						code = inputCode
+						return nil
+					})
+				smsSvc.EXPECT().Send(gomock.Any(), []string{"13800138000"}, codeTplId, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, numbers []string, tpl string, args []string) error {
+						// 发出去的验证码必须和存下来的一致
+						if len(args) != 1 || args[0] != code || len(code) != 6 {
+							return errors.New("验证码不一致")
+						}
+						return nil
+					})
+				return repo, smsSvc
+			},
+			wantErr: nil,
+		},
+		{
+			name: "存储失败不发短信",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				smsSvc := smsmocks.NewMockService(ctrl)
+				repo.EXPECT().Store(gomock.Any(), CodeBizLogin, "13800138000", gomock.Any()).Return(errors.New("缓存炸了"))
+				return repo, smsSvc
+			},
+			wantErr: errors.New("缓存炸了"),
+		},
+		{
+			name: "短信发送失败",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, sms.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				smsSvc := smsmocks.NewMockService(ctrl)
+				repo.EXPECT().Store(gomock.Any(), CodeBizLogin, "13800138000", gomock.Any()).Return(nil)
+				smsSvc.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("短信炸了"))
+				return repo, smsSvc
+			},
+			wantErr: errors.New("短信炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, smsSvc := tt.mock(ctrl)
+			svc := NewCodeService(repo, smsSvc, nil)
+			err := svc.Send(context.Background(), CodeBizLogin, "13800138000")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestCodeServiceInstance_SendEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (repository.CodeRepository, email.Service)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, email.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				emailSvc := emailmocks.NewMockService(ctrl)
+				var code string
+				repo.EXPECT().Store(gomock.Any(), CodeBizChangeEmail, "gz4z2b@163.com", gomock.Any()).
+					DoAndReturn(func(ctx context.Context, biz string, phone string, inputCode string) error {
+						code = inputCode
+						return nil
+					})
+				emailSvc.EXPECT().Send(gomock.Any(), "gz4z2b@163.com", codeEmailSubject, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, to string, subject string, body string) error {
+						// 邮件里的验证码必须和存下来的一致
+						if len(code) != 6 || !strings.Contains(body, code) {
+							return errors.New("验证码不一致")
+						}
+						return nil
+					})
+				return repo, emailSvc
+			},
+			wantErr: nil,
+		},
+		{
+			name: "存储失败不发邮件",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, email.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				repo.EXPECT().Store(gomock.Any(), CodeBizChangeEmail, "gz4z2b@163.com", gomock.Any()).Return(repository.ErrCodeSendTooMany)
+				return repo, emailmocks.NewMockService(ctrl)
+			},
+			wantErr: repository.ErrCodeSendTooMany,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, emailSvc := tt.mock(ctrl)
+			svc := NewCodeService(repo, nil, emailSvc)
+			err := svc.SendEmail(context.Background(), CodeBizChangeEmail, "gz4z2b@163.com")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
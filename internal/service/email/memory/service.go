@@ -0,0 +1,67 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:44:31
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/email/memory/service.go
+ * @Description: 本地邮件服务，只打印不发送，开发测试用
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package memory
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/gz4z2b/go-webook/internal/service/email"
+)
+
+// Mail 发出去的邮件
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Service 打印邮件内容，同时记在内存里，测试里可以取出来看
+type Service struct {
+	lock  sync.Mutex
+	mails []Mail
+}
+
+func NewService() *Service {
+	return &Service{}
+}
+
+var _ email.Service = &Service{}
+
+/**
+ * @description: 打印邮件内容
+ * @param {context.Context} ctx
+ * @param {string} to
+ * @param {string} subject
+ * @param {string} body
+ * @return {error}
+ */
+func (s *Service) Send(ctx context.Context, to string, subject string, body string) error {
+	log.Printf("邮件 to: %s, subject: %s, body: %s", to, subject, body)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.mails = append(s.mails, Mail{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	})
+	return nil
+}
+
+/**
+ * @description: 已经发出去的邮件
+ * @return {[]Mail}
+ */
+func (s *Service) Mails() []Mail {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Mail(nil), s.mails...)
+}
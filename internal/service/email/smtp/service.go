@@ -0,0 +1,87 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:51:26
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/email/smtp/service.go
+ * @Description: SMTP 发邮件，587 端口走 STARTTLS
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/service/email"
+)
+
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+type Service struct {
+	addr string
+	auth smtp.Auth
+	// from 写在邮件头里，可以带名字；sender 是 MAIL FROM 用的纯地址
+	from     string
+	sender   string
+	sendMail sendMailFunc
+}
+
+func NewService(host string, port int, username string, password string, from string) email.Service {
+	sender := from
+	if addr, err := mail.ParseAddress(from); err == nil {
+		// 名字有中文的话 String() 会按 RFC 2047 编码
+		from = addr.String()
+		sender = addr.Address
+	}
+	return &Service{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		auth:     smtp.PlainAuth("", username, password, host),
+		from:     from,
+		sender:   sender,
+		sendMail: smtp.SendMail,
+	}
+}
+
+/**
+ * @description: 发送 HTML 邮件
+ * @param {context.Context} ctx net/smtp 不支持 ctx，超时靠服务器断开
+ * @param {string} to
+ * @param {string} subject
+ * @param {string} body
+ * @return {error}
+ */
+func (s *Service) Send(ctx context.Context, to string, subject string, body string) error {
+	err := s.sendMail(s.addr, s.auth, s.sender, []string{to}, s.buildMessage(to, subject, body))
+	if err != nil {
+		return fmt.Errorf("smtp 发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * @description: 拼邮件内容，标题有中文要按 RFC 2047 编码
+ * @param {string} to
+ * @param {string} subject
+ * @param {string} body
+ * @return {[]byte}
+ */
+func (s *Service) buildMessage(to string, subject string, body string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(body)
+	return buf.Bytes()
+}
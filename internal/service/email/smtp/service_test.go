@@ -0,0 +1,64 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 18:02:47
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/email/smtp/service_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package smtp
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		sendErr error
+		wantErr bool
+	}{
+		{
+			name: "正常",
+		},
+		{
+			name:    "服务器拒绝",
+			sendErr: errors.New("535 Authentication failed"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService("smtp.163.com", 587, "webook@163.com", "secret", "小微书 <webook@163.com>").(*Service)
+			var gotAddr, gotFrom string
+			var gotTo []string
+			var gotMsg []byte
+			svc.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+				return tt.sendErr
+			}
+
+			err := svc.Send(context.Background(), "gz4z2b@163.com", "重置小微书密码", "<p>hello</p>")
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, "smtp.163.com:587", gotAddr)
+			assert.Equal(t, "webook@163.com", gotFrom)
+			assert.Equal(t, []string{"gz4z2b@163.com"}, gotTo)
+
+			msg := string(gotMsg)
+			assert.Contains(t, msg, "From: =?utf-8?q?=E5=B0=8F=E5=BE=AE=E4=B9=A6?= <webook@163.com>\r\n")
+			assert.Contains(t, msg, "To: gz4z2b@163.com\r\n")
+			// 中文标题要编码，不能原样写进头部
+			assert.Contains(t, msg, "Subject: =?UTF-8?b?")
+			assert.NotContains(t, msg, "重置小微书密码")
+			assert.Contains(t, msg, "Content-Type: text/html; charset=UTF-8\r\n")
+			assert.True(t, strings.HasSuffix(msg, "\r\n\r\n<p>hello</p>"))
+		})
+	}
+}
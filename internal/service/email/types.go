@@ -0,0 +1,17 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:42:09
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/email/types.go
+ * @Description: 邮件服务
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package email
+
+import "context"
+
+type Service interface {
+	// Send 发送 HTML 邮件
+	Send(ctx context.Context, to string, subject string, body string) error
+}
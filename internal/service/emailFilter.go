@@ -0,0 +1,66 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 21:05:14
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/emailFilter.go
+ * @Description: 启动时建注册邮箱的布隆过滤器
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/repository"
+)
+
+// EmailFilterJob 建好之前过滤器不会判定不存在，查询照常走缓存和库，所以放后台慢慢建
+// 建好之后也一直检查，有邮箱没加进去、redis 的key被淘汰了，就绪标记会被清掉，要重新建
+type EmailFilterJob struct {
+	repo repository.UserRepository
+	// 多久检查一次，失败了也是隔这么久重试
+	interval time.Duration
+}
+
+func NewEmailFilterJob(repo repository.UserRepository, interval time.Duration) *EmailFilterJob {
+	return &EmailFilterJob{
+		repo:     repo,
+		interval: interval,
+	}
+}
+
+/**
+ * @description: 一直跑到 ctx 结束，多个实例同时建也没关系
+ * @param {context.Context} ctx
+ * @return {*}
+ */
+func (j *EmailFilterJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		j.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *EmailFilterJob) check(ctx context.Context) {
+	ready, err := j.repo.EmailFilterReady(ctx)
+	if err != nil {
+		// 连就绪标记都查不了，建也建不成，下次再看
+		log.Printf("检查邮箱布隆过滤器失败: %v", err)
+		return
+	}
+	if ready {
+		return
+	}
+	if err = j.repo.LoadEmailFilter(ctx); err != nil {
+		log.Printf("建邮箱布隆过滤器失败: %v", err)
+	}
+}
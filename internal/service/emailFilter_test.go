@@ -0,0 +1,55 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 21:12:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/emailFilter_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	repomocks "github.com/gz4z2b/go-webook/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEmailFilterJob_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomocks.NewMockUserRepository(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// 失败了重试，建好之后接着检查，就绪标记被清掉了再建
+	gomock.InOrder(
+		repo.EXPECT().EmailFilterReady(gomock.Any()).Return(false, nil),
+		repo.EXPECT().LoadEmailFilter(gomock.Any()).Return(errors.New("数据库炸了")),
+		repo.EXPECT().EmailFilterReady(gomock.Any()).Return(false, nil),
+		repo.EXPECT().LoadEmailFilter(gomock.Any()).Return(nil),
+		repo.EXPECT().EmailFilterReady(gomock.Any()).Return(true, nil),
+		repo.EXPECT().EmailFilterReady(gomock.Any()).Return(false, errors.New("缓存炸了")),
+		repo.EXPECT().EmailFilterReady(gomock.Any()).Return(false, nil),
+		repo.EXPECT().LoadEmailFilter(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+			cancel()
+			return nil
+		}),
+		// cancel 之后 ticker 可能先到，多检查一次
+		repo.EXPECT().EmailFilterReady(gomock.Any()).Return(true, nil).AnyTimes(),
+	)
+
+	done := make(chan struct{})
+	go func() {
+		NewEmailFilterJob(repo, time.Millisecond).Start(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ctx 结束之后没有退出")
+	}
+}
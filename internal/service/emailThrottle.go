@@ -0,0 +1,66 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 21:12:36
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/emailThrottle.go
+ * @Description: 邮件重发间隔
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+)
+
+var ErrEmailSendTooMany = errors.New("邮件发送太频繁")
+
+const (
+	// EmailBizResetPassword 找回密码邮件
+	EmailBizResetPassword = "reset_password"
+	// EmailBizVerifyEmail 注册验证邮件
+	EmailBizVerifyEmail = "verify_email"
+)
+
+// EmailThrottle 同一个邮箱同一种邮件在间隔内只发一封，跟验证码的重发间隔一个意思
+type EmailThrottle struct {
+	limiter ratelimit.Limiter
+}
+
+/**
+ * @description: 邮件重发间隔
+ * @param {ratelimit.Limiter} limiter 窗口就是重发间隔，阈值是1
+ * @return {*EmailThrottle}
+ */
+func NewEmailThrottle(limiter ratelimit.Limiter) *EmailThrottle {
+	return &EmailThrottle{
+		limiter: limiter,
+	}
+}
+
+/**
+ * @description: 占一次发送名额，间隔内已经发过返回 ErrEmailSendTooMany；邮箱注没注册都要占，不然能用来探测账号
+ * @param {context.Context} ctx
+ * @param {string} biz
+ * @param {string} email
+ * @return {error}
+ */
+func (t *EmailThrottle) Allow(ctx context.Context, biz string, email string) error {
+	key := fmt.Sprintf("webook:email_send:%s:%s", biz, strings.ToLower(strings.TrimSpace(email)))
+	limited, err := t.limiter.Limit(ctx, key)
+	if err != nil {
+		// 限流器本身出问题不能让人收不到邮件
+		log.Printf("邮件重发间隔检查失败: %v", err)
+		return nil
+	}
+	if limited {
+		return ErrEmailSendTooMany
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 20:26:41
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/link.go
+ * @Description: 邮件链接里token的签名
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// linkSigner token 格式是 字段1.字段2...签名，字段里不能有 .
+type linkSigner struct {
+	key []byte
+}
+
+func newLinkSigner(key string) linkSigner {
+	return linkSigner{
+		key: []byte(key),
+	}
+}
+
+/**
+ * @description: 拼接字段并签名
+ * @param {...string} fields
+ * @return {string}
+ */
+func (s linkSigner) sign(fields ...string) string {
+	payload := strings.Join(fields, ".")
+	return payload + "." + s.mac(payload)
+}
+
+/**
+ * @description: 校验签名，返回签名前的字段，过期之类的业务校验调用方自己做
+ * @param {string} token
+ * @param {int} n 字段个数
+ * @return {[]string, bool}
+ */
+func (s linkSigner) verify(token string, n int) ([]string, bool) {
+	segs := strings.Split(token, ".")
+	if len(segs) != n+1 {
+		return nil, false
+	}
+	payload := strings.Join(segs[:n], ".")
+	if !hmac.Equal([]byte(segs[n]), []byte(s.mac(payload))) {
+		return nil, false
+	}
+	return segs[:n], true
+}
+
+func (s linkSigner) mac(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
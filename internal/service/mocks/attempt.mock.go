@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/attempt.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/service/attempt.go -package=svcmocks -destination=./internal/service/mocks/attempt.mock.go
+//
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	service "github.com/gz4z2b/go-webook/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginAttemptService is a mock of LoginAttemptService interface.
+type MockLoginAttemptService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptServiceMockRecorder
+}
+
+// MockLoginAttemptServiceMockRecorder is the mock recorder for MockLoginAttemptService.
+type MockLoginAttemptServiceMockRecorder struct {
+	mock *MockLoginAttemptService
+}
+
+// NewMockLoginAttemptService creates a new mock instance.
+func NewMockLoginAttemptService(ctrl *gomock.Controller) *MockLoginAttemptService {
+	mock := &MockLoginAttemptService{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttemptService) EXPECT() *MockLoginAttemptServiceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockLoginAttemptService) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, email, ip)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLoginAttemptServiceMockRecorder) Check(ctx, email, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLoginAttemptService)(nil).Check), ctx, email, ip)
+}
+
+// Fail mocks base method.
+func (m *MockLoginAttemptService) Fail(ctx context.Context, email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", ctx, email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockLoginAttemptServiceMockRecorder) Fail(ctx, email, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockLoginAttemptService)(nil).Fail), ctx, email, ip)
+}
+
+// Succeed mocks base method.
+func (m *MockLoginAttemptService) Succeed(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Succeed", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Succeed indicates an expected call of Succeed.
+func (mr *MockLoginAttemptServiceMockRecorder) Succeed(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Succeed", reflect.TypeOf((*MockLoginAttemptService)(nil).Succeed), ctx, email)
+}
+
+// UpdatePolicy mocks base method.
+func (m *MockLoginAttemptService) UpdatePolicy(policy service.LoginPolicy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdatePolicy", policy)
+}
+
+// UpdatePolicy indicates an expected call of UpdatePolicy.
+func (mr *MockLoginAttemptServiceMockRecorder) UpdatePolicy(policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePolicy", reflect.TypeOf((*MockLoginAttemptService)(nil).UpdatePolicy), policy)
+}
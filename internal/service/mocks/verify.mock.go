@@ -0,0 +1,67 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/verify.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/service/verify.go -package=svcmocks -destination=./internal/service/mocks/verify.mock.go
+//
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailVerifyService is a mock of EmailVerifyService interface.
+type MockEmailVerifyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailVerifyServiceMockRecorder
+}
+
+// MockEmailVerifyServiceMockRecorder is the mock recorder for MockEmailVerifyService.
+type MockEmailVerifyServiceMockRecorder struct {
+	mock *MockEmailVerifyService
+}
+
+// NewMockEmailVerifyService creates a new mock instance.
+func NewMockEmailVerifyService(ctrl *gomock.Controller) *MockEmailVerifyService {
+	mock := &MockEmailVerifyService{ctrl: ctrl}
+	mock.recorder = &MockEmailVerifyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailVerifyService) EXPECT() *MockEmailVerifyServiceMockRecorder {
+	return m.recorder
+}
+
+// SendVerifyEmail mocks base method.
+func (m *MockEmailVerifyService) SendVerifyEmail(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerifyEmail", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerifyEmail indicates an expected call of SendVerifyEmail.
+func (mr *MockEmailVerifyServiceMockRecorder) SendVerifyEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerifyEmail", reflect.TypeOf((*MockEmailVerifyService)(nil).SendVerifyEmail), ctx, email)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockEmailVerifyService) VerifyEmail(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockEmailVerifyServiceMockRecorder) VerifyEmail(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockEmailVerifyService)(nil).VerifyEmail), ctx, token)
+}
@@ -0,0 +1,201 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 18:15:33
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/password.go
+ * @Description: 找回密码
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/repository"
+	"github.com/gz4z2b/go-webook/internal/service/email"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrResetTokenInvalid = errors.New("重置密码链接无效或已过期")
+
+const resetPasswordSubject = "重置小微书密码"
+
+type PasswordService interface {
+	// SendResetEmail 邮箱没注册也返回 nil，不让人用这个接口探测哪些邮箱注册过
+	SendResetEmail(ctx context.Context, email string) error
+	// ResetPassword 返回被重置密码的用户id，调用方负责作废这个用户已有的登录态
+	ResetPassword(ctx context.Context, token string, password string) (uint64, error)
+}
+
+type PasswordServiceInstance struct {
+	userRepo    repository.UserRepository
+	resetRepo   repository.PasswordResetRepository
+	attemptRepo repository.LoginAttemptRepository
+	emailSvc    email.Service
+	throttle    *EmailThrottle
+	signer      linkSigner
+	linkUrl     string
+	expiretion  time.Duration
+	now         func() time.Time
+}
+
+/**
+ * @description: 找回密码服务
+ * @param {repository.UserRepository} userRepo
+ * @param {repository.PasswordResetRepository} resetRepo
+ * @param {repository.LoginAttemptRepository} attemptRepo
+ * @param {email.Service} emailSvc
+ * @param {*EmailThrottle} throttle
+ * @param {string} key 重置链接的签名key
+ * @param {string} linkUrl 前端重置密码页面，%s 替换成 token
+ * @param {time.Duration} expiretion 链接有效期
+ * @return {PasswordService}
+ */
+func NewPasswordService(userRepo repository.UserRepository, resetRepo repository.PasswordResetRepository,
+	attemptRepo repository.LoginAttemptRepository, emailSvc email.Service, throttle *EmailThrottle,
+	key string, linkUrl string, expiretion time.Duration) PasswordService {
+	return &PasswordServiceInstance{
+		userRepo:    userRepo,
+		resetRepo:   resetRepo,
+		attemptRepo: attemptRepo,
+		emailSvc:    emailSvc,
+		throttle:    throttle,
+		signer:      newLinkSigner(key),
+		linkUrl:     linkUrl,
+		expiretion:  expiretion,
+		now:         time.Now,
+	}
+}
+
+/**
+ * @description: 发重置密码邮件，同一个用户只有最新的链接有效，同一个邮箱有重发间隔
+ * @param {context.Context} ctx
+ * @param {string} email
+ * @return {error}
+ */
+func (svc *PasswordServiceInstance) SendResetEmail(ctx context.Context, email string) error {
+	err := svc.throttle.Allow(ctx, EmailBizResetPassword, email)
+	if err != nil {
+		return err
+	}
+	user, err := svc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if err == repository.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	nonce, err := svc.generateNonce()
+	if err != nil {
+		return err
+	}
+	err = svc.resetRepo.Store(ctx, user.Id, nonce, svc.expiretion)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf(svc.linkUrl, url.QueryEscape(svc.signToken(user.Id, svc.now().Add(svc.expiretion), nonce)))
+	body := fmt.Sprintf(`<p>你好：</p>
+<p>你正在重置小微书的登录密码，请在 %d 分钟内打开下面的链接完成重置，链接只能用一次：</p>
+<p><a href="%s">%s</a></p>
+<p>如果不是你本人操作，请忽略这封邮件，你的密码不会被修改。</p>`, int(svc.expiretion.Minutes()), link, link)
+	return svc.emailSvc.Send(ctx, user.Email, resetPasswordSubject, body)
+}
+
+/**
+ * @description: 用重置链接里的token改密码，token 用过一次就作废，账号的登录锁定一起解除
+ * @param {context.Context} ctx
+ * @param {string} token
+ * @param {string} password 明文新密码
+ * @return {uint64, error}
+ */
+func (svc *PasswordServiceInstance) ResetPassword(ctx context.Context, token string, password string) (uint64, error) {
+	uid, nonce, err := svc.verifyToken(token)
+	if err != nil {
+		return 0, err
+	}
+	ok, err := svc.resetRepo.Consume(ctx, uid, nonce)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrResetTokenInvalid
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+	err = svc.userRepo.UpdatePassword(ctx, uid, string(hash))
+	if err != nil {
+		return 0, err
+	}
+	svc.unlock(ctx, uid)
+	return uid, nil
+}
+
+/**
+ * @description: token 格式是 uid.过期时间.nonce.签名
+ * @param {uint64} uid
+ * @param {time.Time} expireAt
+ * @param {string} nonce
+ * @return {string}
+ */
+func (svc *PasswordServiceInstance) signToken(uid uint64, expireAt time.Time, nonce string) string {
+	return svc.signer.sign(strconv.FormatUint(uid, 10), strconv.FormatInt(expireAt.Unix(), 10), nonce)
+}
+
+/**
+ * @description: 校验签名和过期时间，单次有效要再去缓存里核对 nonce
+ * @param {string} token
+ * @return {uint64, string, error} uid 和 nonce
+ */
+func (svc *PasswordServiceInstance) verifyToken(token string) (uint64, string, error) {
+	segs, ok := svc.signer.verify(token, 3)
+	if !ok {
+		return 0, "", ErrResetTokenInvalid
+	}
+	uid, err := strconv.ParseUint(segs[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrResetTokenInvalid
+	}
+	expireAt, err := strconv.ParseInt(segs[1], 10, 64)
+	if err != nil || !svc.now().Before(time.Unix(expireAt, 0)) {
+		return 0, "", ErrResetTokenInvalid
+	}
+	return uid, segs[2], nil
+}
+
+/**
+ * @description: 能收到重置邮件说明是本人，清掉账号的登录失败记录，清不掉只记日志，密码已经改好了
+ * @param {context.Context} ctx
+ * @param {uint64} uid
+ * @return {*}
+ */
+func (svc *PasswordServiceInstance) unlock(ctx context.Context, uid uint64) {
+	user, err := svc.userRepo.FindById(ctx, uid)
+	if err == nil {
+		err = svc.attemptRepo.Reset(ctx, emailTarget(user.Email))
+	}
+	if err != nil {
+		log.Printf("重置密码后解除登录锁定失败 uid=%d: %v", uid, err)
+	}
+}
+
+func (svc *PasswordServiceInstance) generateNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,280 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 19:05:22
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/password_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	repomocks "github.com/gz4z2b/go-webook/internal/repository/mocks"
+	"github.com/gz4z2b/go-webook/internal/service/email"
+	emailmocks "github.com/gz4z2b/go-webook/internal/service/email/mocks"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testResetKey = "reset-key-for-test"
+	testResetUrl = "http://localhost:3000/users/password/reset?token=%s"
+)
+
+var testResetNow = time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+func newTestPasswordService(userRepo repository.UserRepository, resetRepo repository.PasswordResetRepository,
+	emailSvc email.Service) *PasswordServiceInstance {
+	throttle := NewEmailThrottle(ratelimit.NewLocalSlidingWindowLimiter(time.Minute, 1))
+	svc := NewPasswordService(userRepo, resetRepo, nil, emailSvc, throttle, testResetKey, testResetUrl, time.Minute*30).(*PasswordServiceInstance)
+	svc.now = func() time.Time {
+		return testResetNow
+	}
+	return svc
+}
+
+func TestPasswordServiceInstance_SendResetEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, email.Service)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, email.Service) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				resetRepo := repomocks.NewMockPasswordResetRepository(ctrl)
+				emailSvc := emailmocks.NewMockService(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{
+					Id:    1,
+					Email: "gz4z2b@163.com",
+				}, nil)
+				var nonce string
+				resetRepo.EXPECT().Store(gomock.Any(), uint64(1), gomock.Any(), time.Minute*30).
+					DoAndReturn(func(ctx context.Context, uid uint64, inputNonce string, expiretion time.Duration) error {
+						nonce = inputNonce
+						return nil
+					})
+				emailSvc.EXPECT().Send(gomock.Any(), "gz4z2b@163.com", resetPasswordSubject, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, to string, subject string, body string) error {
+						// 邮件里的链接能解出刚存进去的 nonce
+						token := extractResetToken(t, body)
+						svc := newTestPasswordService(nil, nil, nil)
+						uid, gotNonce, err := svc.verifyToken(token)
+						assert.NoError(t, err)
+						assert.Equal(t, uint64(1), uid)
+						assert.Equal(t, nonce, gotNonce)
+						return nil
+					})
+				return userRepo, resetRepo, emailSvc
+			},
+		},
+		{
+			name: "邮箱没注册不发邮件也不报错",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, email.Service) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{}, repository.ErrUserNotFound)
+				return userRepo, repomocks.NewMockPasswordResetRepository(ctrl), emailmocks.NewMockService(ctrl)
+			},
+		},
+		{
+			name: "缓存炸了",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, email.Service) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				resetRepo := repomocks.NewMockPasswordResetRepository(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{
+					Id:    1,
+					Email: "gz4z2b@163.com",
+				}, nil)
+				resetRepo.EXPECT().Store(gomock.Any(), uint64(1), gomock.Any(), gomock.Any()).Return(errors.New("缓存炸了"))
+				return userRepo, resetRepo, emailmocks.NewMockService(ctrl)
+			},
+			wantErr: errors.New("缓存炸了"),
+		},
+		{
+			name: "邮件发送失败",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, email.Service) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				resetRepo := repomocks.NewMockPasswordResetRepository(ctrl)
+				emailSvc := emailmocks.NewMockService(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{
+					Id:    1,
+					Email: "gz4z2b@163.com",
+				}, nil)
+				resetRepo.EXPECT().Store(gomock.Any(), uint64(1), gomock.Any(), gomock.Any()).Return(nil)
+				emailSvc.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("smtp 发送邮件失败"))
+				return userRepo, resetRepo, emailSvc
+			},
+			wantErr: errors.New("smtp 发送邮件失败"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := newTestPasswordService(tt.mock(ctrl))
+			err := svc.SendResetEmail(context.Background(), "gz4z2b@163.com")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestPasswordServiceInstance_SendResetEmailTooMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	// 邮箱没注册也要占名额，第二次连用户都不查
+	userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{}, repository.ErrUserNotFound)
+
+	svc := newTestPasswordService(userRepo, repomocks.NewMockPasswordResetRepository(ctrl), emailmocks.NewMockService(ctrl))
+	assert.NoError(t, svc.SendResetEmail(context.Background(), "gz4z2b@163.com"))
+	assert.Equal(t, ErrEmailSendTooMany, svc.SendResetEmail(context.Background(), "GZ4Z2B@163.com"))
+}
+
+func TestPasswordServiceInstance_ResetPassword(t *testing.T) {
+	signer := newTestPasswordService(nil, nil, nil)
+	validToken := signer.signToken(1, testResetNow.Add(time.Minute*30), "nonce-1")
+
+	tests := []struct {
+		name    string
+		token   string
+		mock    func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository)
+		wantUid uint64
+		wantErr error
+	}{
+		{
+			name:  "正常",
+			token: validToken,
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				resetRepo := repomocks.NewMockPasswordResetRepository(ctrl)
+				resetRepo.EXPECT().Consume(gomock.Any(), uint64(1), "nonce-1").Return(true, nil)
+				userRepo.EXPECT().UpdatePassword(gomock.Any(), uint64(1), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, id uint64, password string) error {
+						// 存的是加密后的密码
+						assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(password), []byte("Hello@123")))
+						return nil
+					})
+				userRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{
+					Id:    1,
+					Email: "GZ4Z2B@163.com",
+				}, nil)
+				attemptRepo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				attemptRepo.EXPECT().Reset(gomock.Any(), "email:gz4z2b@163.com").Return(nil)
+				return userRepo, resetRepo, attemptRepo
+			},
+			wantUid: 1,
+		},
+		{
+			name:  "解除锁定失败不影响重置",
+			token: validToken,
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				resetRepo := repomocks.NewMockPasswordResetRepository(ctrl)
+				attemptRepo := repomocks.NewMockLoginAttemptRepository(ctrl)
+				resetRepo.EXPECT().Consume(gomock.Any(), uint64(1), "nonce-1").Return(true, nil)
+				userRepo.EXPECT().UpdatePassword(gomock.Any(), uint64(1), gomock.Any()).Return(nil)
+				userRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{
+					Id:    1,
+					Email: "gz4z2b@163.com",
+				}, nil)
+				attemptRepo.EXPECT().Reset(gomock.Any(), "email:gz4z2b@163.com").Return(errors.New("缓存炸了"))
+				return userRepo, resetRepo, attemptRepo
+			},
+			wantUid: 1,
+		},
+		{
+			name:  "已经用过",
+			token: validToken,
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository) {
+				resetRepo := repomocks.NewMockPasswordResetRepository(ctrl)
+				resetRepo.EXPECT().Consume(gomock.Any(), uint64(1), "nonce-1").Return(false, nil)
+				return repomocks.NewMockUserRepository(ctrl), resetRepo, repomocks.NewMockLoginAttemptRepository(ctrl)
+			},
+			wantErr: ErrResetTokenInvalid,
+		},
+		{
+			name:  "过期",
+			token: signer.signToken(1, testResetNow, "nonce-1"),
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository) {
+				return repomocks.NewMockUserRepository(ctrl), repomocks.NewMockPasswordResetRepository(ctrl), repomocks.NewMockLoginAttemptRepository(ctrl)
+			},
+			wantErr: ErrResetTokenInvalid,
+		},
+		{
+			name:  "改了uid",
+			token: "2" + validToken[1:],
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository) {
+				return repomocks.NewMockUserRepository(ctrl), repomocks.NewMockPasswordResetRepository(ctrl), repomocks.NewMockLoginAttemptRepository(ctrl)
+			},
+			wantErr: ErrResetTokenInvalid,
+		},
+		{
+			name:  "别的key签的",
+			token: NewPasswordService(nil, nil, nil, nil, nil, "other-key", testResetUrl, time.Minute*30).(*PasswordServiceInstance).signToken(1, testResetNow.Add(time.Minute), "nonce-1"),
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository) {
+				return repomocks.NewMockUserRepository(ctrl), repomocks.NewMockPasswordResetRepository(ctrl), repomocks.NewMockLoginAttemptRepository(ctrl)
+			},
+			wantErr: ErrResetTokenInvalid,
+		},
+		{
+			name:  "格式不对",
+			token: "abc",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository) {
+				return repomocks.NewMockUserRepository(ctrl), repomocks.NewMockPasswordResetRepository(ctrl), repomocks.NewMockLoginAttemptRepository(ctrl)
+			},
+			wantErr: ErrResetTokenInvalid,
+		},
+		{
+			name:  "更新密码失败",
+			token: validToken,
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.PasswordResetRepository, repository.LoginAttemptRepository) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				resetRepo := repomocks.NewMockPasswordResetRepository(ctrl)
+				resetRepo.EXPECT().Consume(gomock.Any(), uint64(1), "nonce-1").Return(true, nil)
+				userRepo.EXPECT().UpdatePassword(gomock.Any(), uint64(1), gomock.Any()).Return(errors.New("数据库炸了"))
+				return userRepo, resetRepo, repomocks.NewMockLoginAttemptRepository(ctrl)
+			},
+			wantErr: errors.New("数据库炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo, resetRepo, attemptRepo := tt.mock(ctrl)
+			svc := newTestPasswordService(userRepo, resetRepo, nil)
+			svc.attemptRepo = attemptRepo
+			uid, err := svc.ResetPassword(context.Background(), tt.token, "Hello@123")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantUid, uid)
+		})
+	}
+}
+
+func extractResetToken(t *testing.T, body string) string {
+	matches := regexp.MustCompile(`href="([^"]+)"`).FindStringSubmatch(body)
+	if len(matches) != 2 {
+		t.Fatalf("邮件里没有重置链接: %s", body)
+	}
+	link, err := url.Parse(matches[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
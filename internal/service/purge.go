@@ -0,0 +1,89 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 21:36:52
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/purge.go
+ * @Description: 定时彻底删除注销的账号
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/repository"
+)
+
+// purgeBatchSize 单次最多删多少个账号，删满一批说明可能还有，马上接着删
+const purgeBatchSize = 100
+
+// UserPurgeJob 注销的账号保留一段时间方便申诉找回，过了保留期彻底删除
+type UserPurgeJob struct {
+	repo repository.UserRepository
+	// 注销之后保留多久
+	retention time.Duration
+	// 两次检查的间隔
+	interval time.Duration
+	now      func() time.Time
+}
+
+/**
+ * @description: 创建清理任务，调用 Start 才开始跑
+ * @param {repository.UserRepository} repo
+ * @param {time.Duration} retention
+ * @param {time.Duration} interval
+ * @return {*UserPurgeJob}
+ */
+func NewUserPurgeJob(repo repository.UserRepository, retention time.Duration, interval time.Duration) *UserPurgeJob {
+	return &UserPurgeJob{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+/**
+ * @description: 循环清理直到 ctx 结束，多个实例同时跑也没关系
+ * @param {context.Context} ctx
+ * @return {*}
+ */
+func (j *UserPurgeJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		_, err := j.PurgeOnce(ctx)
+		if err != nil {
+			log.Printf("清理注销账号失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+ * @description: 把过了保留期的账号分批删完
+ * @param {context.Context} ctx
+ * @return {int64, error} 删掉的账号数
+ */
+func (j *UserPurgeJob) PurgeOnce(ctx context.Context) (int64, error) {
+	before := j.now().Add(-j.retention)
+	var total int64
+	for ctx.Err() == nil {
+		purged, err := j.repo.Purge(ctx, before, purgeBatchSize)
+		total += purged
+		if err != nil {
+			return total, err
+		}
+		if purged < purgeBatchSize {
+			break
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,79 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 21:58:33
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/purge_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/repository"
+	repomocks "github.com/gz4z2b/go-webook/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUserPurgeJob_PurgeOnce(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	before := now.Add(-time.Hour * 24 * 30)
+
+	tests := []struct {
+		name      string
+		mock      func(ctrl *gomock.Controller) repository.UserRepository
+		wantTotal int64
+		wantErr   error
+	}{
+		{
+			name: "一批删完",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().Purge(gomock.Any(), before, purgeBatchSize).Return(int64(3), nil)
+				return repo
+			},
+			wantTotal: 3,
+		},
+		{
+			name: "删满一批接着删",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				gomock.InOrder(
+					repo.EXPECT().Purge(gomock.Any(), before, purgeBatchSize).Return(int64(purgeBatchSize), nil),
+					repo.EXPECT().Purge(gomock.Any(), before, purgeBatchSize).Return(int64(0), nil),
+				)
+				return repo
+			},
+			wantTotal: purgeBatchSize,
+		},
+		{
+			name: "数据库炸了",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().Purge(gomock.Any(), before, purgeBatchSize).Return(int64(0), errors.New("数据库炸了"))
+				return repo
+			},
+			wantErr: errors.New("数据库炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			job := NewUserPurgeJob(tt.mock(ctrl), time.Hour*24*30, time.Hour)
+			job.now = func() time.Time {
+				return now
+			}
+			total, err := job.PurgeOnce(context.Background())
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantTotal, total)
+		})
+	}
+}
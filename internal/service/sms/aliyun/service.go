@@ -0,0 +1,196 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 15:10:26
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/aliyun/service.go
+ * @Description: 阿里云短信，直接调 SendSms 的 RPC 接口，不引 SDK
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package aliyun
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+)
+
+const defaultEndpoint = "https://dysmsapi.aliyuncs.com/"
+
+// Template 阿里云的模板是具名参数，模板id也跟腾讯云不一样，
+// 按业务里用的模板id映射到阿里云的模板
+type Template struct {
+	Code   string
+	Params []string
+}
+
+type Service struct {
+	client          *http.Client
+	endpoint        string
+	accessKeyId     string
+	accessKeySecret string
+	signName        string
+	templates       map[string]Template
+}
+
+func NewService(client *http.Client, accessKeyId string, accessKeySecret string, signName string,
+	templates map[string]Template) sms.Service {
+	return NewServiceWithEndpoint(client, defaultEndpoint, accessKeyId, accessKeySecret, signName, templates)
+}
+
+func NewServiceWithEndpoint(client *http.Client, endpoint string, accessKeyId string, accessKeySecret string,
+	signName string, templates map[string]Template) sms.Service {
+	return &Service{
+		client:          client,
+		endpoint:        endpoint,
+		accessKeyId:     accessKeyId,
+		accessKeySecret: accessKeySecret,
+		signName:        signName,
+		templates:       templates,
+	}
+}
+
+type sendSmsResp struct {
+	Code      string `json:"Code"`
+	Message   string `json:"Message"`
+	BizId     string `json:"BizId"`
+	RequestId string `json:"RequestId"`
+}
+
+/**
+ * @description: 发送短信
+ * @param {context.Context} ctx
+ * @param {[]string} numbers
+ * @param {string} tpl 业务模板id
+ * @param {[]string} args 模板参数，按 Template.Params 的顺序
+ * @return {error}
+ */
+func (s *Service) Send(ctx context.Context, numbers []string, tpl string, args []string) error {
+	template, ok := s.templates[tpl]
+	if !ok {
+		return fmt.Errorf("%w: 阿里云没有配置模板 %s", sms.ErrTemplateInvalid, tpl)
+	}
+	if len(template.Params) != len(args) {
+		return fmt.Errorf("%w: 模板 %s 需要 %d 个参数，实际 %d 个", sms.ErrTemplateInvalid,
+			tpl, len(template.Params), len(args))
+	}
+	params := make(map[string]string, len(args))
+	for i, name := range template.Params {
+		params[name] = args[i]
+	}
+	tplParam, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	query := map[string]string{
+		"AccessKeyId":      s.accessKeyId,
+		"Action":           "SendSms",
+		"Format":           "JSON",
+		"PhoneNumbers":     strings.Join(numbers, ","),
+		"RegionId":         "cn-hangzhou",
+		"SignName":         s.signName,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   strconv.FormatInt(time.Now().UnixNano(), 36),
+		"SignatureVersion": "1.0",
+		"TemplateCode":     template.Code,
+		"TemplateParam":    string(tplParam),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		"Version":          "2017-05-25",
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"?"+s.sign(query), nil)
+	if err != nil {
+		return err
+	}
+	httpResp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	var resp sendSmsResp
+	err = json.NewDecoder(httpResp.Body).Decode(&resp)
+	if err != nil {
+		return fmt.Errorf("%w: 阿里云返回 http %d", sms.ErrSendFailed, httpResp.StatusCode)
+	}
+	if resp.Code != "OK" {
+		return fmt.Errorf("%w: %s %s", mapErrCode(resp.Code), resp.Code, resp.Message)
+	}
+	return nil
+}
+
+/**
+ * @description: 按阿里云 RPC 签名规则生成带签名的 query
+ * @param {map[string]string} query
+ * @return {string}
+ */
+func (s *Service) sign(query map[string]string) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, percentEncode(key)+"="+percentEncode(query[key]))
+	}
+	canonicalized := strings.Join(pairs, "&")
+
+	stringToSign := http.MethodGet + "&" + percentEncode("/") + "&" + percentEncode(canonicalized)
+	mac := hmac.New(sha1.New, []byte(s.accessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return "Signature=" + percentEncode(signature) + "&" + canonicalized
+}
+
+func percentEncode(str string) string {
+	str = url.QueryEscape(str)
+	str = strings.ReplaceAll(str, "+", "%20")
+	str = strings.ReplaceAll(str, "*", "%2A")
+	str = strings.ReplaceAll(str, "%7E", "~")
+	return str
+}
+
+/**
+ * @description: 阿里云错误码映射到 sms 包的错误
+ * @param {string} code
+ * @return {error}
+ */
+func mapErrCode(code string) error {
+	switch {
+	case code == "isv.MOBILE_NUMBER_ILLEGAL",
+		code == "isv.MOBILE_COUNT_OVER_LIMIT",
+		code == "isv.BLACK_KEY_CONTROL_LIMIT":
+		return sms.ErrInvalidNumber
+	case code == "isv.BUSINESS_LIMIT_CONTROL",
+		code == "isv.DAY_LIMIT_CONTROL",
+		code == "Throttling.User":
+		return sms.ErrLimitExceeded
+	case code == "isv.AMOUNT_NOT_ENOUGH",
+		code == "isv.OUT_OF_SERVICE":
+		return sms.ErrInsufficientBalance
+	case code == "isv.SMS_SIGNATURE_ILLEGAL",
+		code == "isv.SMS_TEMPLATE_ILLEGAL",
+		code == "isv.TEMPLATE_MISSING_PARAMETERS",
+		code == "isv.INVALID_PARAMETERS":
+		return sms.ErrTemplateInvalid
+	case strings.HasPrefix(code, "InvalidAccessKeyId"),
+		code == "SignatureDoesNotMatch",
+		code == "isp.RAM_PERMISSION_DENY":
+		return sms.ErrAuthFailed
+	default:
+		return sms.ErrSendFailed
+	}
+}
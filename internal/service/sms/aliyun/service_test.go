@@ -0,0 +1,139 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:12:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/aliyun/service_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package aliyun
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Send(t *testing.T) {
+	templates := map[string]Template{
+		"1877556": {
+			Code:   "SMS_462030212",
+			Params: []string{"code"},
+		},
+	}
+	tests := []struct {
+		name    string
+		tpl     string
+		args    []string
+		handler func(t *testing.T, query url.Values) (int, string)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			tpl:  "1877556",
+			args: []string{"123456"},
+			handler: func(t *testing.T, query url.Values) (int, string) {
+				assert.Equal(t, "SendSms", query.Get("Action"))
+				assert.Equal(t, "13800138000", query.Get("PhoneNumbers"))
+				assert.Equal(t, "SMS_462030212", query.Get("TemplateCode"))
+				assert.Equal(t, `{"code":"123456"}`, query.Get("TemplateParam"))
+				assert.Equal(t, "小微书", query.Get("SignName"))
+				return http.StatusOK, `{"Code": "OK", "Message": "OK", "BizId": "1", "RequestId": "1"}`
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "没配置模板",
+			tpl:     "1877557",
+			args:    []string{"123456"},
+			wantErr: sms.ErrTemplateInvalid,
+		},
+		{
+			name:    "模板参数个数不对",
+			tpl:     "1877556",
+			args:    []string{"123456", "10"},
+			wantErr: sms.ErrTemplateInvalid,
+		},
+		{
+			name: "号码不对",
+			tpl:  "1877556",
+			args: []string{"123456"},
+			handler: func(t *testing.T, query url.Values) (int, string) {
+				return http.StatusOK, `{"Code": "isv.MOBILE_NUMBER_ILLEGAL", "Message": "illegal", "RequestId": "2"}`
+			},
+			wantErr: sms.ErrInvalidNumber,
+		},
+		{
+			name: "流控",
+			tpl:  "1877556",
+			args: []string{"123456"},
+			handler: func(t *testing.T, query url.Values) (int, string) {
+				return http.StatusOK, `{"Code": "isv.BUSINESS_LIMIT_CONTROL", "Message": "limit", "RequestId": "3"}`
+			},
+			wantErr: sms.ErrLimitExceeded,
+		},
+		{
+			name: "签名不对",
+			tpl:  "1877556",
+			args: []string{"123456"},
+			handler: func(t *testing.T, query url.Values) (int, string) {
+				return http.StatusBadRequest, `{"Code": "SignatureDoesNotMatch", "Message": "signature", "RequestId": "4"}`
+			},
+			wantErr: sms.ErrAuthFailed,
+		},
+		{
+			name: "阿里云挂了",
+			tpl:  "1877556",
+			args: []string{"123456"},
+			handler: func(t *testing.T, query url.Values) (int, string) {
+				return http.StatusBadGateway, `bad gateway`
+			},
+			wantErr: sms.ErrSendFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.handler == nil {
+					t.Fatal("不应该请求阿里云")
+				}
+				query := r.URL.Query()
+				assertSignature(t, query)
+				code, body := tt.handler(t, query)
+				w.WriteHeader(code)
+				_, _ = w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			svc := NewServiceWithEndpoint(server.Client(), server.URL+"/", "test-id", "test-secret", "小微书", templates)
+			err := svc.Send(context.Background(), []string{"13800138000"}, tt.tpl, tt.args)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.wantErr), "期望 %v，实际 %v", tt.wantErr, err)
+		})
+	}
+}
+
+// assertSignature 用收到的参数重新算一遍签名，跟请求带过来的比对
+func assertSignature(t *testing.T, query url.Values) {
+	signature := query.Get("Signature")
+	params := make(map[string]string, len(query))
+	for key := range query {
+		if key != "Signature" {
+			params[key] = query.Get(key)
+		}
+	}
+	svc := &Service{accessKeySecret: "test-secret"}
+	signed, err := url.ParseQuery(svc.sign(params))
+	assert.NoError(t, err)
+	assert.Equal(t, signed.Get("Signature"), signature)
+}
@@ -0,0 +1,191 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 18:35:04
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/async/service.go
+ * @Description: 限流或者服务商出错时转存数据库，后台异步重试
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package async
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+)
+
+const limitKey = "webook:sms:limit"
+
+type Service struct {
+	svc     sms.Service
+	repo    repository.AsyncSmsRepository
+	limiter ratelimit.Limiter
+	// 最多重试次数
+	retryMax int
+	// 第一次重试的间隔，之后每次翻倍
+	backoff    time.Duration
+	maxBackoff time.Duration
+	// 队列里没有短信时后台任务的等待时间
+	idleInterval time.Duration
+	// 后台单次发送的超时时间
+	timeout time.Duration
+	now     func() time.Time
+}
+
+/**
+ * @description: 创建异步短信服务，调用 Start 才开始后台重试
+ * @param {sms.Service} svc 实际发送短信的服务
+ * @param {repository.AsyncSmsRepository} repo
+ * @param {ratelimit.Limiter} limiter
+ * @param {int} retryMax
+ * @return {*Service}
+ */
+func NewService(svc sms.Service, repo repository.AsyncSmsRepository, limiter ratelimit.Limiter, retryMax int) *Service {
+	return &Service{
+		svc:          svc,
+		repo:         repo,
+		limiter:      limiter,
+		retryMax:     retryMax,
+		backoff:      time.Second * 5,
+		maxBackoff:   time.Minute,
+		idleInterval: time.Second,
+		timeout:      time.Second * 5,
+		now:          time.Now,
+	}
+}
+
+/**
+ * @description: 没触发限流就同步发送，限流或者可重试的错误转为异步发送
+ * @param {context.Context} ctx
+ * @param {[]string} numbers
+ * @param {string} tpl
+ * @param {[]string} args
+ * @return {error}
+ */
+func (s *Service) Send(ctx context.Context, numbers []string, tpl string, args []string) error {
+	limited, err := s.limiter.Limit(ctx, limitKey)
+	if err != nil {
+		// 限流器挂了不影响正常发送，服务商出错照样会转异步
+		log.Printf("短信限流器出错: %v", err)
+	}
+	if limited {
+		return s.addAsync(ctx, numbers, tpl, args)
+	}
+	err = s.svc.Send(ctx, numbers, tpl, args)
+	if err == nil || !retryable(err) {
+		return err
+	}
+	if addErr := s.addAsync(ctx, numbers, tpl, args); addErr != nil {
+		log.Printf("短信转异步失败: %v", addErr)
+		return err
+	}
+	return nil
+}
+
+func (s *Service) addAsync(ctx context.Context, numbers []string, tpl string, args []string) error {
+	return s.repo.Add(ctx, domain.AsyncSms{
+		Numbers:  numbers,
+		Tpl:      tpl,
+		Args:     args,
+		RetryMax: s.retryMax,
+	})
+}
+
+/**
+ * @description: 后台循环发送队列里的短信，ctx 取消后退出
+ * @param {context.Context} ctx
+ * @return {*}
+ */
+func (s *Service) Start(ctx context.Context) {
+	for ctx.Err() == nil {
+		if !s.AsyncSendOnce(ctx) {
+			s.sleep(ctx, s.idleInterval)
+		}
+	}
+}
+
+/**
+ * @description: 抢占并发送一条短信
+ * @param {context.Context} ctx
+ * @return {bool} 是否处理了一条短信，没有的话调用方应该等一会
+ */
+func (s *Service) AsyncSendOnce(ctx context.Context) bool {
+	// 先抢再占限流名额，队列空的时候轮询不消耗名额
+	asyncSms, err := s.repo.PreemptWaitingSms(ctx)
+	if err != nil {
+		if err != repository.ErrAsyncSmsNotFound {
+			log.Printf("抢占异步短信失败: %v", err)
+		}
+		return false
+	}
+	limited, err := s.limiter.Limit(ctx, limitKey)
+	if err != nil {
+		log.Printf("短信限流器出错: %v", err)
+	}
+	if limited {
+		if err = s.repo.Release(ctx, asyncSms.Id, asyncSms.Version); err != nil {
+			// 放不回去就等发送超时后被重新抢占
+			log.Printf("异步短信 %d 放回队列失败: %v", asyncSms.Id, err)
+		}
+		return false
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	err = s.svc.Send(sendCtx, asyncSms.Numbers, asyncSms.Tpl, asyncSms.Args)
+	cancel()
+	switch {
+	case err == nil:
+		err = s.repo.ReportSuccess(ctx, asyncSms.Id, asyncSms.Version)
+	case !retryable(err) || asyncSms.RetryCnt+1 >= asyncSms.RetryMax:
+		log.Printf("异步短信 %d 发送失败，不再重试: %v", asyncSms.Id, err)
+		err = s.repo.ReportFailed(ctx, asyncSms.Id, asyncSms.Version)
+	default:
+		err = s.repo.ReportRetry(ctx, asyncSms.Id, asyncSms.Version, s.now().Add(s.nextBackoff(asyncSms.RetryCnt)))
+	}
+	switch {
+	case err == repository.ErrAsyncSmsLost:
+		// 发送超时被别的实例重新抢占了，结果以新主人的为准
+		log.Printf("异步短信 %d 已被重新抢占，放弃上报结果", asyncSms.Id)
+	case err != nil:
+		// 上报失败的话这条会停在发送中，超时后被重新抢占
+		log.Printf("异步短信 %d 上报结果失败: %v", asyncSms.Id, err)
+	}
+	return true
+}
+
+/**
+ * @description: 计算第 retryCnt 次失败后的重试间隔
+ * @param {int} retryCnt
+ * @return {time.Duration}
+ */
+func (s *Service) nextBackoff(retryCnt int) time.Duration {
+	backoff := s.backoff
+	for i := 0; i < retryCnt && backoff < s.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > s.maxBackoff {
+		return s.maxBackoff
+	}
+	return backoff
+}
+
+func (s *Service) sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// retryable 号码、模板这类错误重试多少次都一样，不进队列
+func retryable(err error) bool {
+	return !errors.Is(err, sms.ErrInvalidNumber) && !errors.Is(err, sms.ErrTemplateInvalid)
+}
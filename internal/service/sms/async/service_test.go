@@ -0,0 +1,273 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 19:02:45
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/async/service_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	repomocks "github.com/gz4z2b/go-webook/internal/repository/mocks"
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	smsmocks "github.com/gz4z2b/go-webook/internal/service/sms/mocks"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+	limitmocks "github.com/gz4z2b/go-webook/pkg/ratelimit/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestService_Send(t *testing.T) {
+	numbers := []string{"13800138000"}
+	args := []string{"123456"}
+	asyncSms := domain.AsyncSms{
+		Numbers:  numbers,
+		Tpl:      "1877556",
+		Args:     args,
+		RetryMax: 3,
+	}
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter)
+		wantErr error
+	}{
+		{
+			name: "同步发送成功",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				svc.EXPECT().Send(gomock.Any(), numbers, "1877556", args).Return(nil)
+				return svc, repo, limiter
+			},
+			wantErr: nil,
+		},
+		{
+			name: "限流转异步",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(true, nil)
+				repo.EXPECT().Add(gomock.Any(), asyncSms).Return(nil)
+				return svc, repo, limiter
+			},
+			wantErr: nil,
+		},
+		{
+			name: "限流器出错照常发送",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, errors.New("缓存炸了"))
+				svc.EXPECT().Send(gomock.Any(), numbers, "1877556", args).Return(nil)
+				return svc, repo, limiter
+			},
+			wantErr: nil,
+		},
+		{
+			name: "服务商出错转异步",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				svc.EXPECT().Send(gomock.Any(), numbers, "1877556", args).Return(sms.ErrSendFailed)
+				repo.EXPECT().Add(gomock.Any(), asyncSms).Return(nil)
+				return svc, repo, limiter
+			},
+			wantErr: nil,
+		},
+		{
+			name: "号码不对不转异步",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				svc.EXPECT().Send(gomock.Any(), numbers, "1877556", args).Return(sms.ErrInvalidNumber)
+				return svc, repo, limiter
+			},
+			wantErr: sms.ErrInvalidNumber,
+		},
+		{
+			name: "转异步失败返回原错误",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				svc.EXPECT().Send(gomock.Any(), numbers, "1877556", args).Return(sms.ErrSendFailed)
+				repo.EXPECT().Add(gomock.Any(), asyncSms).Return(errors.New("数据库炸了"))
+				return svc, repo, limiter
+			},
+			wantErr: sms.ErrSendFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			smsSvc, repo, limiter := tt.mock(ctrl)
+			svc := NewService(smsSvc, repo, limiter, 3)
+			err := svc.Send(context.Background(), numbers, "1877556", args)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestService_AsyncSendOnce(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	asyncSms := domain.AsyncSms{
+		Id:       1,
+		Numbers:  []string{"13800138000"},
+		Tpl:      "1877556",
+		Args:     []string{"123456"},
+		RetryCnt: 1,
+		RetryMax: 3,
+		Version:  5,
+	}
+	tests := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter)
+		want bool
+	}{
+		{
+			name: "发送成功",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				repo.EXPECT().PreemptWaitingSms(gomock.Any()).Return(asyncSms, nil)
+				svc.EXPECT().Send(gomock.Any(), asyncSms.Numbers, asyncSms.Tpl, asyncSms.Args).Return(nil)
+				repo.EXPECT().ReportSuccess(gomock.Any(), uint64(1), int64(5)).Return(nil)
+				return svc, repo, limiter
+			},
+			want: true,
+		},
+		{
+			name: "发送失败退避重试",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				repo.EXPECT().PreemptWaitingSms(gomock.Any()).Return(asyncSms, nil)
+				svc.EXPECT().Send(gomock.Any(), asyncSms.Numbers, asyncSms.Tpl, asyncSms.Args).Return(sms.ErrSendFailed)
+				// 已经重试过一次，间隔翻倍
+				repo.EXPECT().ReportRetry(gomock.Any(), uint64(1), int64(5), now.Add(time.Second*10)).Return(nil)
+				return svc, repo, limiter
+			},
+			want: true,
+		},
+		{
+			name: "重试次数用完",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				last := asyncSms
+				last.RetryCnt = 2
+				repo.EXPECT().PreemptWaitingSms(gomock.Any()).Return(last, nil)
+				svc.EXPECT().Send(gomock.Any(), asyncSms.Numbers, asyncSms.Tpl, asyncSms.Args).Return(sms.ErrSendFailed)
+				repo.EXPECT().ReportFailed(gomock.Any(), uint64(1), int64(5)).Return(nil)
+				return svc, repo, limiter
+			},
+			want: true,
+		},
+		{
+			name: "不可重试的错误",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				repo.EXPECT().PreemptWaitingSms(gomock.Any()).Return(asyncSms, nil)
+				svc.EXPECT().Send(gomock.Any(), asyncSms.Numbers, asyncSms.Tpl, asyncSms.Args).Return(sms.ErrTemplateInvalid)
+				repo.EXPECT().ReportFailed(gomock.Any(), uint64(1), int64(5)).Return(nil)
+				return svc, repo, limiter
+			},
+			want: true,
+		},
+		{
+			name: "超时被重新抢占",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(false, nil)
+				repo.EXPECT().PreemptWaitingSms(gomock.Any()).Return(asyncSms, nil)
+				svc.EXPECT().Send(gomock.Any(), asyncSms.Numbers, asyncSms.Tpl, asyncSms.Args).Return(nil)
+				repo.EXPECT().ReportSuccess(gomock.Any(), uint64(1), int64(5)).Return(repository.ErrAsyncSmsLost)
+				return svc, repo, limiter
+			},
+			want: true,
+		},
+		{
+			name: "队列是空的",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				// 队列空的时候不占限流名额
+				repo.EXPECT().PreemptWaitingSms(gomock.Any()).Return(domain.AsyncSms{}, repository.ErrAsyncSmsNotFound)
+				return svc, repo, limiter
+			},
+			want: false,
+		},
+		{
+			name: "还在限流",
+			mock: func(ctrl *gomock.Controller) (sms.Service, repository.AsyncSmsRepository, ratelimit.Limiter) {
+				svc := smsmocks.NewMockService(ctrl)
+				repo := repomocks.NewMockAsyncSmsRepository(ctrl)
+				limiter := limitmocks.NewMockLimiter(ctrl)
+				gomock.InOrder(
+					repo.EXPECT().PreemptWaitingSms(gomock.Any()).Return(asyncSms, nil),
+					limiter.EXPECT().Limit(gomock.Any(), limitKey).Return(true, nil),
+					repo.EXPECT().Release(gomock.Any(), uint64(1), int64(5)).Return(nil),
+				)
+				return svc, repo, limiter
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			smsSvc, repo, limiter := tt.mock(ctrl)
+			svc := NewService(smsSvc, repo, limiter, 3)
+			svc.now = func() time.Time {
+				return now
+			}
+			assert.Equal(t, tt.want, svc.AsyncSendOnce(context.Background()))
+		})
+	}
+}
+
+func TestService_nextBackoff(t *testing.T) {
+	svc := &Service{
+		backoff:    time.Second * 5,
+		maxBackoff: time.Minute,
+	}
+	assert.Equal(t, time.Second*5, svc.nextBackoff(0))
+	assert.Equal(t, time.Second*10, svc.nextBackoff(1))
+	assert.Equal(t, time.Second*40, svc.nextBackoff(3))
+	assert.Equal(t, time.Minute, svc.nextBackoff(4))
+	assert.Equal(t, time.Minute, svc.nextBackoff(100))
+}
@@ -0,0 +1,73 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 15:42:03
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/failover/failover.go
+ * @Description: 轮询多个短信服务商，失败或超时换下一个
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+)
+
+var ErrAllServiceFailed = errors.New("所有短信服务商都发送失败")
+
+type FailoverService struct {
+	svcs []sms.Service
+	idx  uint64
+	// 单个服务商的超时时间
+	timeout time.Duration
+}
+
+func NewFailoverService(svcs []sms.Service, timeout time.Duration) sms.Service {
+	return &FailoverService{
+		svcs:    svcs,
+		timeout: timeout,
+	}
+}
+
+/**
+ * @description: 从下一个服务商开始轮流尝试，直到有一个发送成功
+ * @param {context.Context} ctx
+ * @param {[]string} numbers
+ * @param {string} tpl
+ * @param {[]string} args
+ * @return {error}
+ */
+func (f *FailoverService) Send(ctx context.Context, numbers []string, tpl string, args []string) error {
+	start := atomic.AddUint64(&f.idx, 1)
+	length := uint64(len(f.svcs))
+	errs := make([]error, 0, length)
+	for i := uint64(0); i < length; i++ {
+		svc := f.svcs[(start+i)%length]
+		err := f.send(ctx, svc, numbers, tpl, args)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			// 调用方已经不等了
+			return ctx.Err()
+		}
+		if errors.Is(err, sms.ErrInvalidNumber) {
+			// 号码本身有问题，换哪家都一样
+			return err
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("%w: %w", ErrAllServiceFailed, errors.Join(errs...))
+}
+
+func (f *FailoverService) send(ctx context.Context, svc sms.Service, numbers []string, tpl string, args []string) error {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	return svc.Send(ctx, numbers, tpl, args)
+}
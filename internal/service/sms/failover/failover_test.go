@@ -0,0 +1,119 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 16:31:55
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/failover/failover_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package failover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	smsmocks "github.com/gz4z2b/go-webook/internal/service/sms/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFailoverService_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) []sms.Service
+		wantErr error
+	}{
+		{
+			name: "第一家成功",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				// 轮询从下标1开始
+				svc1.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return []sms.Service{svc0, svc1}
+			},
+			wantErr: nil,
+		},
+		{
+			name: "第一家失败换第二家",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc1.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(sms.ErrSendFailed)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return []sms.Service{svc0, svc1}
+			},
+			wantErr: nil,
+		},
+		{
+			name: "第一家超时换第二家",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc1.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, numbers []string, tpl string, args []string) error {
+						<-ctx.Done()
+						return ctx.Err()
+					})
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return []sms.Service{svc0, svc1}
+			},
+			wantErr: nil,
+		},
+		{
+			name: "号码不对不再重试",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc1.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(sms.ErrInvalidNumber)
+				return []sms.Service{svc0, svc1}
+			},
+			wantErr: sms.ErrInvalidNumber,
+		},
+		{
+			name: "全部失败",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc1.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(sms.ErrLimitExceeded)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(sms.ErrAuthFailed)
+				return []sms.Service{svc0, svc1}
+			},
+			wantErr: ErrAllServiceFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewFailoverService(tt.mock(ctrl), time.Millisecond*50)
+			err := svc.Send(context.Background(), []string{"13800138000"}, "1877556", []string{"123456"})
+			assert.True(t, errors.Is(err, tt.wantErr), "期望 %v，实际 %v", tt.wantErr, err)
+		})
+	}
+}
+
+func TestFailoverService_RoundRobin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc0 := smsmocks.NewMockService(ctrl)
+	svc1 := smsmocks.NewMockService(ctrl)
+	svc2 := smsmocks.NewMockService(ctrl)
+	gomock.InOrder(
+		svc1.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+		svc2.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+		svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	svc := NewFailoverService([]sms.Service{svc0, svc1, svc2}, time.Second)
+	for i := 0; i < 3; i++ {
+		err := svc.Send(context.Background(), []string{"13800138000"}, "1877556", []string{"123456"})
+		assert.NoError(t, err)
+	}
+}
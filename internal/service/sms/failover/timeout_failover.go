@@ -0,0 +1,70 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 16:05:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/failover/timeout_failover.go
+ * @Description: 固定用一个服务商，连续超时到阈值就认为它不健康，切到下一个
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package failover
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+)
+
+type TimeoutFailoverService struct {
+	svcs []sms.Service
+	// 当前使用的服务商
+	idx int32
+	// 当前服务商连续超时的次数
+	cnt int32
+	// 连续超时多少次切换
+	threshold int32
+	timeout   time.Duration
+}
+
+func NewTimeoutFailoverService(svcs []sms.Service, threshold int32, timeout time.Duration) sms.Service {
+	return &TimeoutFailoverService{
+		svcs:      svcs,
+		threshold: threshold,
+		timeout:   timeout,
+	}
+}
+
+/**
+ * @description: 用当前服务商发送，连续超时达到阈值后切换
+ * @param {context.Context} ctx
+ * @param {[]string} numbers
+ * @param {string} tpl
+ * @param {[]string} args
+ * @return {error}
+ */
+func (t *TimeoutFailoverService) Send(ctx context.Context, numbers []string, tpl string, args []string) error {
+	idx := atomic.LoadInt32(&t.idx)
+	cnt := atomic.LoadInt32(&t.cnt)
+	if cnt >= t.threshold {
+		newIdx := (idx + 1) % int32(len(t.svcs))
+		// 并发的请求只有一个能切换成功
+		if atomic.CompareAndSwapInt32(&t.idx, idx, newIdx) {
+			atomic.StoreInt32(&t.cnt, 0)
+		}
+		idx = atomic.LoadInt32(&t.idx)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	err := t.svcs[idx].Send(sendCtx, numbers, tpl, args)
+	switch {
+	case err == nil:
+		atomic.StoreInt32(&t.cnt, 0)
+	case sendCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		// 是服务商慢，不是调用方自己超时。各家 SDK 包装超时错误的方式不一样，只看 ctx
+		atomic.AddInt32(&t.cnt, 1)
+	}
+	return err
+}
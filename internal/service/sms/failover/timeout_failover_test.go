@@ -0,0 +1,126 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 16:50:12
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/failover/timeout_failover_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package failover
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	smsmocks "github.com/gz4z2b/go-webook/internal/service/sms/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTimeoutFailoverService_Send(t *testing.T) {
+	timeout := func(ctx context.Context, numbers []string, tpl string, args []string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) []sms.Service
+		idx     int32
+		cnt     int32
+		wantErr error
+		wantIdx int32
+		wantCnt int32
+	}{
+		{
+			name: "没到阈值，成功后清零",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return []sms.Service{svc0, svc1}
+			},
+			idx:     0,
+			cnt:     2,
+			wantErr: nil,
+			wantIdx: 0,
+			wantCnt: 0,
+		},
+		{
+			name: "没到阈值，超时累加",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(timeout)
+				return []sms.Service{svc0, svc1}
+			},
+			idx:     0,
+			cnt:     1,
+			wantErr: context.DeadlineExceeded,
+			wantIdx: 0,
+			wantCnt: 2,
+		},
+		{
+			name: "普通错误不计数",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(sms.ErrSendFailed)
+				return []sms.Service{svc0, svc1}
+			},
+			idx:     0,
+			cnt:     1,
+			wantErr: sms.ErrSendFailed,
+			wantIdx: 0,
+			wantCnt: 1,
+		},
+		{
+			name: "到阈值切换",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc1.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				return []sms.Service{svc0, svc1}
+			},
+			idx:     0,
+			cnt:     3,
+			wantErr: nil,
+			wantIdx: 1,
+			wantCnt: 0,
+		},
+		{
+			name: "最后一家到阈值绕回第一家",
+			mock: func(ctrl *gomock.Controller) []sms.Service {
+				svc0 := smsmocks.NewMockService(ctrl)
+				svc1 := smsmocks.NewMockService(ctrl)
+				svc0.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(timeout)
+				return []sms.Service{svc0, svc1}
+			},
+			idx:     1,
+			cnt:     3,
+			wantErr: context.DeadlineExceeded,
+			wantIdx: 0,
+			wantCnt: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := &TimeoutFailoverService{
+				svcs:      tt.mock(ctrl),
+				idx:       tt.idx,
+				cnt:       tt.cnt,
+				threshold: 3,
+				timeout:   time.Millisecond * 50,
+			}
+			err := svc.Send(context.Background(), []string{"13800138000"}, "1877556", []string{"123456"})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantIdx, svc.idx)
+			assert.Equal(t, tt.wantCnt, svc.cnt)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 11:03:26
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/sms/memory/service.go
+ * @Description: 本地短信服务，只打印不发送，开发测试用
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package memory
+
+import (
+	"context"
+	"log"
+
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+)
+
+type Service struct {
+}
+
+func NewService() sms.Service {
+	return &Service{}
+}
+
+/**
+ * @description: 打印短信内容
+ * @param {context.Context} ctx
+ * @param {[]string} numbers
+ * @param {string} tpl
+ * @param {[]string} args
+ * @return {error}
+ */
+func (s *Service) Send(ctx context.Context, numbers []string, tpl string, args []string) error {
+	log.Printf("短信 numbers: %v, tpl: %s, args: %v", numbers, tpl, args)
+	return nil
+}
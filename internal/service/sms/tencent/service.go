@@ -3,13 +3,118 @@
  * @Date: 2023-09-07 18:27:25
  * @LastEditors: p_hanxichen
  * @FilePath: /go/src/webook/internal/service/sms/tencent/service.go
- * @Description:
+ * @Description: 腾讯云短信
  *
  * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
  */
 package tencent
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	sdkerrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	tencentsms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
+)
+
 type Service struct {
-	appId     string
-	signature string
+	client    *tencentsms.Client
+	appId     *string
+	signature *string
+}
+
+func NewService(client *tencentsms.Client, appId string, signature string) sms.Service {
+	return &Service{
+		client:    client,
+		appId:     common.StringPtr(appId),
+		signature: common.StringPtr(signature),
+	}
+}
+
+/**
+ * @description: 发送短信，逐个号码检查发送状态
+ * @param {context.Context} ctx
+ * @param {[]string} numbers
+ * @param {string} tpl 模板id
+ * @param {[]string} args 模板参数
+ * @return {error}
+ */
+func (s *Service) Send(ctx context.Context, numbers []string, tpl string, args []string) error {
+	req := tencentsms.NewSendSmsRequest()
+	req.SmsSdkAppId = s.appId
+	req.SignName = s.signature
+	req.TemplateId = common.StringPtr(tpl)
+	req.PhoneNumberSet = common.StringPtrs(numbers)
+	req.TemplateParamSet = common.StringPtrs(args)
+
+	resp, err := s.client.SendSmsWithContext(ctx, req)
+	if err != nil {
+		var sdkErr *sdkerrors.TencentCloudSDKError
+		if errors.As(err, &sdkErr) {
+			return fmt.Errorf("%w: %s %s", mapErrCode(sdkErr.GetCode()), sdkErr.GetCode(), sdkErr.GetMessage())
+		}
+		return err
+	}
+	if resp.Response == nil {
+		return fmt.Errorf("%w: 腾讯云返回为空", sms.ErrSendFailed)
+	}
+
+	var errs []error
+	for _, status := range resp.Response.SendStatusSet {
+		if status == nil || status.Code == nil {
+			errs = append(errs, fmt.Errorf("%w: 发送状态为空", sms.ErrSendFailed))
+			continue
+		}
+		if *status.Code == "Ok" {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%w: %s %s %s", mapErrCode(*status.Code),
+			stringValue(status.PhoneNumber), *status.Code, stringValue(status.Message)))
+	}
+	if len(resp.Response.SendStatusSet) < len(numbers) {
+		errs = append(errs, fmt.Errorf("%w: 发送状态缺失，期望 %d 条，实际 %d 条", sms.ErrSendFailed,
+			len(numbers), len(resp.Response.SendStatusSet)))
+	}
+	return errors.Join(errs...)
+}
+
+/**
+ * @description: 腾讯云错误码映射到 sms 包的错误
+ * @param {string} code
+ * @return {error}
+ */
+func mapErrCode(code string) error {
+	switch {
+	case strings.HasPrefix(code, "AuthFailure"):
+		return sms.ErrAuthFailed
+	case strings.HasPrefix(code, "LimitExceeded"),
+		strings.HasPrefix(code, "RequestLimitExceeded"):
+		return sms.ErrLimitExceeded
+	case code == "FailedOperation.InsufficientBalanceInSmsPackage":
+		return sms.ErrInsufficientBalance
+	case code == "FailedOperation.SignatureIncorrectOrUnapproved",
+		code == "FailedOperation.TemplateIncorrectOrUnapproved",
+		code == "FailedOperation.TemplateParamSetNotMatchApprovedTemplate",
+		strings.HasPrefix(code, "InvalidParameterValue.Template"),
+		strings.HasPrefix(code, "UnauthorizedOperation.SmsSdkAppIdVerifyFail"):
+		return sms.ErrTemplateInvalid
+	case code == "InvalidParameterValue.IncorrectPhoneNumber",
+		code == "FailedOperation.PhoneNumberInBlacklist",
+		code == "UnsupportedOperation.ContainDomesticAndInternationalPhoneNumber",
+		code == "UnsupportedOperation.UnsupportedRegion":
+		return sms.ErrInvalidNumber
+	default:
+		return sms.ErrSendFailed
+	}
+}
+
+func stringValue(ptr *string) string {
+	if ptr == nil {
+		return ""
+	}
+	return *ptr
 }
@@ -10,33 +10,157 @@
 package tencent
 
 import (
-	"fmt"
-	"os"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
-	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	tencentsms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
 )
 
-func TestSend(t *testing.T) {
-	credential := common.NewCredential(
-		os.Getenv("TENCENTCLOUD_SECRET_ID"),
-		os.Getenv("TENCENTCLOUD_SECRET_KEY"),
-	)
-	client, _ := cvm.NewClient(credential, regions.Guangzhou, profile.NewClientProfile())
-
-	request := cvm.NewDescribeInstancesRequest()
-	response, err := client.DescribeInstances(request)
+// sendSmsReq 腾讯云 SendSms 接口的请求体，只取用到的字段
+type sendSmsReq struct {
+	PhoneNumberSet   []string
+	SmsSdkAppId      string
+	SignName         string
+	TemplateId       string
+	TemplateParamSet []string
+}
 
-	if _, ok := err.(*errors.TencentCloudSDKError); ok {
-		fmt.Printf("An API error has returned: %s", err)
-		return
+func TestService_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		numbers []string
+		// 本地模拟的腾讯云接口
+		handler func(t *testing.T, req sendSmsReq) (int, string)
+		wantErr []error
+	}{
+		{
+			name:    "正常",
+			numbers: []string{"+8613800138000", "+8613800138001"},
+			handler: func(t *testing.T, req sendSmsReq) (int, string) {
+				assert.Equal(t, "1400842696", req.SmsSdkAppId)
+				assert.Equal(t, "小微书", req.SignName)
+				assert.Equal(t, "1877556", req.TemplateId)
+				assert.Equal(t, []string{"123456"}, req.TemplateParamSet)
+				assert.Equal(t, []string{"+8613800138000", "+8613800138001"}, req.PhoneNumberSet)
+				return http.StatusOK, `{"Response": {"SendStatusSet": [
+					{"PhoneNumber": "+8613800138000", "Code": "Ok", "Message": "send success"},
+					{"PhoneNumber": "+8613800138001", "Code": "Ok", "Message": "send success"}
+				], "RequestId": "1"}}`
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "部分号码失败",
+			numbers: []string{"+8613800138000", "+86138"},
+			handler: func(t *testing.T, req sendSmsReq) (int, string) {
+				return http.StatusOK, `{"Response": {"SendStatusSet": [
+					{"PhoneNumber": "+8613800138000", "Code": "Ok", "Message": "send success"},
+					{"PhoneNumber": "+86138", "Code": "InvalidParameterValue.IncorrectPhoneNumber", "Message": "incorrect number"}
+				], "RequestId": "2"}}`
+			},
+			wantErr: []error{sms.ErrInvalidNumber},
+		},
+		{
+			name:    "单号码频率限制",
+			numbers: []string{"+8613800138000"},
+			handler: func(t *testing.T, req sendSmsReq) (int, string) {
+				return http.StatusOK, `{"Response": {"SendStatusSet": [
+					{"PhoneNumber": "+8613800138000", "Code": "LimitExceeded.PhoneNumberThirtySecondLimit", "Message": "limit"}
+				], "RequestId": "3"}}`
+			},
+			wantErr: []error{sms.ErrLimitExceeded},
+		},
+		{
+			name:    "套餐余量不足",
+			numbers: []string{"+8613800138000"},
+			handler: func(t *testing.T, req sendSmsReq) (int, string) {
+				return http.StatusOK, `{"Response": {"SendStatusSet": [
+					{"PhoneNumber": "+8613800138000", "Code": "FailedOperation.InsufficientBalanceInSmsPackage", "Message": "no balance"}
+				], "RequestId": "4"}}`
+			},
+			wantErr: []error{sms.ErrInsufficientBalance},
+		},
+		{
+			name:    "发送状态缺失",
+			numbers: []string{"+8613800138000"},
+			handler: func(t *testing.T, req sendSmsReq) (int, string) {
+				return http.StatusOK, `{"Response": {"SendStatusSet": [], "RequestId": "5"}}`
+			},
+			wantErr: []error{sms.ErrSendFailed},
+		},
+		{
+			name:    "鉴权失败",
+			numbers: []string{"+8613800138000"},
+			handler: func(t *testing.T, req sendSmsReq) (int, string) {
+				return http.StatusOK, `{"Response": {"Error": {"Code": "AuthFailure.SignatureFailure", "Message": "signature failure"}, "RequestId": "6"}}`
+			},
+			wantErr: []error{sms.ErrAuthFailed},
+		},
+		{
+			name:    "模板未审核",
+			numbers: []string{"+8613800138000"},
+			handler: func(t *testing.T, req sendSmsReq) (int, string) {
+				return http.StatusOK, `{"Response": {"Error": {"Code": "FailedOperation.TemplateIncorrectOrUnapproved", "Message": "template"}, "RequestId": "7"}}`
+			},
+			wantErr: []error{sms.ErrTemplateInvalid},
+		},
+		{
+			name:    "腾讯云挂了",
+			numbers: []string{"+8613800138000"},
+			handler: func(t *testing.T, req sendSmsReq) (int, string) {
+				return http.StatusBadGateway, `bad gateway`
+			},
+			wantErr: []error{sms.ErrSendFailed},
+		},
 	}
-	if err != nil {
-		panic(err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "SendSms", r.Header.Get("X-TC-Action"))
+				assert.Equal(t, "2021-01-11", r.Header.Get("X-TC-Version"))
+				assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "TC3-HMAC-SHA256"))
+
+				var req sendSmsReq
+				err := json.NewDecoder(r.Body).Decode(&req)
+				require.NoError(t, err)
+
+				code, body := tt.handler(t, req)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(code)
+				_, _ = w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			svc := NewService(newTestClient(t, server), "1400842696", "小微书")
+			err := svc.Send(context.Background(), tt.numbers, "1877556", []string{"123456"})
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, wantErr := range tt.wantErr {
+				assert.True(t, errors.Is(err, wantErr), "期望 %v，实际 %v", wantErr, err)
+			}
+		})
 	}
-	fmt.Printf("%s\n", response.ToJsonString())
+}
+
+// newTestClient 把腾讯云 SDK 的请求指到本地的模拟服务
+func newTestClient(t *testing.T, server *httptest.Server) *tencentsms.Client {
+	cpf := profile.NewClientProfile()
+	cpf.HttpProfile.Scheme = "HTTP"
+	cpf.HttpProfile.Endpoint = strings.TrimPrefix(server.URL, "http://")
+	cpf.HttpProfile.ReqTimeout = 5
+	client, err := tencentsms.NewClient(common.NewCredential("test-id", "test-key"), "ap-guangzhou", cpf)
+	require.NoError(t, err)
+	return client
 }
@@ -9,7 +9,20 @@
  */
 package sms
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// 各家短信服务商的错误统一映射成下面几种，具体原因用 %w 包在里面
+var (
+	ErrInvalidNumber       = errors.New("手机号不正确")
+	ErrLimitExceeded       = errors.New("短信发送超过频率限制")
+	ErrInsufficientBalance = errors.New("短信套餐余量不足")
+	ErrTemplateInvalid     = errors.New("短信签名或模板不正确")
+	ErrAuthFailed          = errors.New("短信服务鉴权失败")
+	ErrSendFailed          = errors.New("短信发送失败")
+)
 
 type Service interface {
 	Send(ctx context.Context, numbers []string, tpl string, args []string) error
@@ -15,14 +15,31 @@ type UserService interface {
 	Login(ctx context.Context, user *domain.User) (*domain.User, error)
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
 	FindById(ctx context.Context, id uint64) (*domain.User, error)
+	FindOrCreateByPhone(ctx context.Context, phone string) (*domain.User, error)
 	FindProfileByUser(ctx context.Context, user *domain.User) (*domain.Profile, error)
 	AddProfile(ctx context.Context, user *domain.User, profile *domain.Profile) (*domain.Profile, error)
+	ChangePassword(ctx context.Context, id uint64, oldPassword string, newPassword string) error
+	// ChangeEmail 新邮箱的验证码由调用方先校验
+	ChangeEmail(ctx context.Context, id uint64, email string) error
+	// DeleteAccount 注销账号，要先核对密码
+	DeleteAccount(ctx context.Context, id uint64, password string) error
+	// DeleteAccountPhone 没设密码的账号注销时改用手机验证码确认，返回收验证码的手机号
+	DeleteAccountPhone(ctx context.Context, id uint64) (string, error)
+	// DeleteAccountByCode 没设密码的账号注销，手机验证码由调用方先校验
+	DeleteAccountByCode(ctx context.Context, id uint64) error
 }
 
 var (
 	ErrEmailConflict   = repository.ErrEmailConflict
 	ErrUserNotFound    = repository.ErrUserNotFound
+	ErrProfileNotFound = repository.ErrProfileNotFound
 	ErrPasswordInvalid = errors.New("密码不正确")
+	ErrUserPending     = errors.New("邮箱还没有验证")
+	ErrUserDisabled    = errors.New("账号已被禁用")
+	// ErrPasswordNotSet 手机号注册的账号没有密码，要用手机验证码确认
+	ErrPasswordNotSet = errors.New("账号没有设置密码")
+	// ErrPasswordRequired 设了密码的账号只能用密码确认
+	ErrPasswordRequired = errors.New("账号设置了密码")
 )
 
 type UserServiceInstance struct {
@@ -36,7 +53,7 @@ func NewUserService(repo repository.UserRepository) UserService {
 }
 
 /**
- * @description: 注册
+ * @description: 注册，邮箱验证之前账号是待验证状态
  * @param {context.Context} ctx
  * @param {*domain.User} user
  * @return {error}
@@ -47,6 +64,7 @@ func (svc *UserServiceInstance) SignUp(ctx context.Context, user *domain.User) e
 		return err
 	}
 	user.Password = string(hash)
+	user.Status = domain.UserStatusPending
 	return svc.repo.Create(ctx, user)
 }
 
@@ -66,6 +84,11 @@ func (svc *UserServiceInstance) Login(ctx context.Context, user *domain.User) (*
 	if err != nil {
 		return &domain.User{}, ErrPasswordInvalid
 	}
+	// 密码对了才告诉状态，免得被用来探测账号
+	err = svc.checkStatus(findUser)
+	if err != nil {
+		return &domain.User{}, err
+	}
 	return findUser, nil
 }
 
@@ -89,6 +112,95 @@ func (svc *UserServiceInstance) FindById(ctx context.Context, id uint64) (*domai
 	return svc.repo.FindById(ctx, id)
 }
 
+/**
+ * @description: 根据手机号获取用户，首次登录自动注册
+ * @param {context.Context} ctx
+ * @param {string} phone
+ * @return {*domain.User, error}
+ */
+func (svc *UserServiceInstance) FindOrCreateByPhone(ctx context.Context, phone string) (*domain.User, error) {
+	user, err := svc.repo.FindOrCreateByPhone(ctx, phone)
+	if err != nil {
+		return &domain.User{}, err
+	}
+	err = svc.checkStatus(user)
+	if err != nil {
+		return &domain.User{}, err
+	}
+	return user, nil
+}
+
+/**
+ * @description: 注销账号，要先核对密码，没设过密码的账号要走手机验证码
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {string} password 明文密码
+ * @return {error}
+ */
+func (svc *UserServiceInstance) DeleteAccount(ctx context.Context, id uint64, password string) error {
+	user, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" {
+		return ErrPasswordNotSet
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if err != nil {
+		return ErrPasswordInvalid
+	}
+	return svc.repo.Delete(ctx, id)
+}
+
+/**
+ * @description: 没设密码的账号注销前要发验证码的手机号，设了密码的不给发，免得绕过密码
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @return {string, error}
+ */
+func (svc *UserServiceInstance) DeleteAccountPhone(ctx context.Context, id uint64) (string, error) {
+	user, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if user.Password != "" || user.Phone == "" {
+		return "", ErrPasswordRequired
+	}
+	return user.Phone, nil
+}
+
+/**
+ * @description: 没设密码的账号注销，手机验证码由调用方先校验
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @return {error}
+ */
+func (svc *UserServiceInstance) DeleteAccountByCode(ctx context.Context, id uint64) error {
+	_, err := svc.DeleteAccountPhone(ctx, id)
+	if err != nil {
+		return err
+	}
+	return svc.repo.Delete(ctx, id)
+}
+
+/**
+ * @description: 登录前检查账号状态
+ * @param {*domain.User} user
+ * @return {error}
+ */
+func (svc *UserServiceInstance) checkStatus(user *domain.User) error {
+	switch user.Status {
+	case domain.UserStatusPending:
+		return ErrUserPending
+	case domain.UserStatusDisabled:
+		return ErrUserDisabled
+	case domain.UserStatusDeleted:
+		// 注销了就当没有这个人
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 /**
  * @description: 根据用户获取档案
  * @param {context.Context} ctx
@@ -97,8 +209,7 @@ func (svc *UserServiceInstance) FindById(ctx context.Context, id uint64) (*domai
  */
 func (svc *UserServiceInstance) FindProfileByUser(ctx context.Context, user *domain.User) (*domain.Profile, error) {
 	findProfile, err := svc.repo.FindProfileByUser(ctx, dao.User{
-		Id:    user.Id,
-		Email: user.Email,
+		Id: user.Id,
 	})
 	if err != nil {
 		return &domain.Profile{}, err
@@ -117,3 +228,40 @@ func (svc *UserServiceInstance) AddProfile(ctx context.Context, user *domain.Use
 	return svc.repo.AddProfile(ctx, user, profile)
 
 }
+
+/**
+ * @description: 修改密码，要先核对原密码
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {string} oldPassword 明文原密码
+ * @param {string} newPassword 明文新密码
+ * @return {error}
+ */
+func (svc *UserServiceInstance) ChangePassword(ctx context.Context, id uint64, oldPassword string, newPassword string) error {
+	user, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	// 手机号注册的用户没有密码，也走这里报原密码不正确
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword))
+	if err != nil {
+		return ErrPasswordInvalid
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return svc.repo.UpdatePassword(ctx, id, string(hash))
+}
+
+/**
+ * @description: 修改邮箱
+ * @param {context.Context} ctx
+ * @param {uint64} id
+ * @param {string} email 新邮箱
+ * @return {error}
+ */
+func (svc *UserServiceInstance) ChangeEmail(ctx context.Context, id uint64, email string) error {
+	return svc.repo.UpdateEmail(ctx, id, email)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/gz4z2b/go-webook/internal/repository"
 	repomocks "github.com/gz4z2b/go-webook/internal/repository/mocks"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestUserServiceInstance_SignUp(t *testing.T) {
@@ -37,7 +38,14 @@ func TestUserServiceInstance_SignUp(t *testing.T) {
 			},
 			mock: func(ctrl *gomock.Controller) repository.UserRepository {
 				repo := repomocks.NewMockUserRepository(ctrl)
-				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, user *domain.User) error {
+						// 验证邮箱之前不能登录
+						if user.Status != domain.UserStatusPending {
+							return errors.New("状态不对")
+						}
+						return nil
+					})
 				return repo
 			},
 			wantErr: nil,
@@ -118,6 +126,63 @@ func TestUserServiceInstance_Login(t *testing.T) {
 			wantUser: &domain.User{},
 			wantErr:  ErrPasswordInvalid,
 		},
+		{
+			name: "邮箱还没验证",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				user := &domain.User{
+					Email:    "gz4z2b@163.com",
+					Password: "$2a$10$2/zoj94WMfc7xvGv9NNsmuptftGX3MnyBiycLYc0lmYsKrGJGOkNK",
+					Status:   domain.UserStatusPending,
+				}
+				repo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(user, nil)
+				return repo
+			},
+			inputUser: &domain.User{
+				Email:    "gz4z2b@163.com",
+				Password: "19890821Xi_",
+			},
+			wantUser: &domain.User{},
+			wantErr:  ErrUserPending,
+		},
+		{
+			name: "账号被禁用",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				user := &domain.User{
+					Email:    "gz4z2b@163.com",
+					Password: "$2a$10$2/zoj94WMfc7xvGv9NNsmuptftGX3MnyBiycLYc0lmYsKrGJGOkNK",
+					Status:   domain.UserStatusDisabled,
+				}
+				repo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(user, nil)
+				return repo
+			},
+			inputUser: &domain.User{
+				Email:    "gz4z2b@163.com",
+				Password: "19890821Xi_",
+			},
+			wantUser: &domain.User{},
+			wantErr:  ErrUserDisabled,
+		},
+		{
+			name: "禁用的账号密码错了还是报密码错",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				user := &domain.User{
+					Email:    "gz4z2b@163.com",
+					Password: "$2a$10$2/zoj94WMfc7xvGv9NNsmuptftGX3MnyBiycLYc0lmYsKrGJGOkNK",
+					Status:   domain.UserStatusDisabled,
+				}
+				repo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(user, nil)
+				return repo
+			},
+			inputUser: &domain.User{
+				Email:    "gz4z2b@163.com",
+				Password: "19890821Xi",
+			},
+			wantUser: &domain.User{},
+			wantErr:  ErrPasswordInvalid,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -324,3 +389,205 @@ func TestUserServiceInstance_AddProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestUserServiceInstance_ChangePassword(t *testing.T) {
+	const hash = "$2a$10$2/zoj94WMfc7xvGv9NNsmuptftGX3MnyBiycLYc0lmYsKrGJGOkNK"
+	tests := []struct {
+		name        string
+		mock        func(ctrl *gomock.Controller) repository.UserRepository
+		oldPassword string
+		wantErr     error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Password: hash}, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), uint64(1), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, id uint64, password string) error {
+						// 存的是新密码加密后的值
+						if bcrypt.CompareHashAndPassword([]byte(password), []byte("Hello@123")) != nil {
+							return errors.New("新密码不对")
+						}
+						return nil
+					})
+				return repo
+			},
+			oldPassword: "19890821Xi_",
+		},
+		{
+			name: "原密码不正确",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Password: hash}, nil)
+				return repo
+			},
+			oldPassword: "19890821Xi",
+			wantErr:     ErrPasswordInvalid,
+		},
+		{
+			name: "手机号用户没有密码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Phone: "13800138000"}, nil)
+				return repo
+			},
+			oldPassword: "",
+			wantErr:     ErrPasswordInvalid,
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{}, ErrUserNotFound)
+				return repo
+			},
+			oldPassword: "19890821Xi_",
+			wantErr:     ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewUserService(tt.mock(ctrl))
+			err := svc.ChangePassword(context.Background(), uint64(1), tt.oldPassword, "Hello@123")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestUserServiceInstance_ChangeEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) repository.UserRepository
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateEmail(gomock.Any(), uint64(1), "new@163.com").Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "邮箱已被占用",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().UpdateEmail(gomock.Any(), uint64(1), "new@163.com").Return(ErrEmailConflict)
+				return repo
+			},
+			wantErr: ErrEmailConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewUserService(tt.mock(ctrl))
+			err := svc.ChangeEmail(context.Background(), uint64(1), "new@163.com")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestUserServiceInstance_DeleteAccount(t *testing.T) {
+	const hash = "$2a$10$2/zoj94WMfc7xvGv9NNsmuptftGX3MnyBiycLYc0lmYsKrGJGOkNK"
+	tests := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) repository.UserRepository
+		password string
+		wantErr  error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Password: hash}, nil)
+				repo.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+				return repo
+			},
+			password: "19890821Xi_",
+		},
+		{
+			name: "密码不正确",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Password: hash}, nil)
+				return repo
+			},
+			password: "19890821Xi",
+			wantErr:  ErrPasswordInvalid,
+		},
+		{
+			name: "手机号注册没设密码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Phone: "13800138000"}, nil)
+				return repo
+			},
+			password: "19890821Xi_",
+			wantErr:  ErrPasswordNotSet,
+		},
+		{
+			name: "已经注销过",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{}, ErrUserNotFound)
+				return repo
+			},
+			password: "19890821Xi_",
+			wantErr:  ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewUserService(tt.mock(ctrl))
+			err := svc.DeleteAccount(context.Background(), uint64(1), tt.password)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestUserServiceInstance_DeleteAccountByCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) repository.UserRepository
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Phone: "13800138000"}, nil)
+				repo.EXPECT().Delete(gomock.Any(), uint64(1)).Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "设了密码不能用验证码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Phone: "13800138000", Password: "hash"}, nil)
+				return repo
+			},
+			wantErr: ErrPasswordRequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewUserService(tt.mock(ctrl))
+			err := svc.DeleteAccountByCode(context.Background(), uint64(1))
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 20:41:09
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/verify.go
+ * @Description: 注册邮箱验证
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	"github.com/gz4z2b/go-webook/internal/service/email"
+)
+
+var ErrVerifyTokenInvalid = errors.New("邮箱验证链接无效或已过期")
+
+const verifyEmailSubject = "验证你的小微书邮箱"
+
+type EmailVerifyService interface {
+	// SendVerifyEmail 邮箱没注册或者已经验证过都返回 nil，不发邮件
+	SendVerifyEmail(ctx context.Context, email string) error
+	// VerifyEmail 已经验证过的再点一次链接也算成功
+	VerifyEmail(ctx context.Context, token string) error
+}
+
+type EmailVerifyServiceInstance struct {
+	userRepo   repository.UserRepository
+	emailSvc   email.Service
+	throttle   *EmailThrottle
+	signer     linkSigner
+	linkUrl    string
+	expiretion time.Duration
+	now        func() time.Time
+}
+
+/**
+ * @description: 注册邮箱验证服务
+ * @param {repository.UserRepository} userRepo
+ * @param {email.Service} emailSvc
+ * @param {*EmailThrottle} throttle 跟找回密码共用
+ * @param {string} key 验证链接的签名key
+ * @param {string} linkUrl 前端验证邮箱页面，%s 替换成 token
+ * @param {time.Duration} expiretion 链接有效期
+ * @return {EmailVerifyService}
+ */
+func NewEmailVerifyService(userRepo repository.UserRepository, emailSvc email.Service, throttle *EmailThrottle,
+	key string, linkUrl string, expiretion time.Duration) EmailVerifyService {
+	return &EmailVerifyServiceInstance{
+		userRepo:   userRepo,
+		emailSvc:   emailSvc,
+		throttle:   throttle,
+		signer:     newLinkSigner(key),
+		linkUrl:    linkUrl,
+		expiretion: expiretion,
+		now:        time.Now,
+	}
+}
+
+/**
+ * @description: 给待验证的账号发验证邮件，链接在有效期内可以重复点，同一个邮箱有重发间隔
+ * @param {context.Context} ctx
+ * @param {string} email
+ * @return {error}
+ */
+func (svc *EmailVerifyServiceInstance) SendVerifyEmail(ctx context.Context, email string) error {
+	err := svc.throttle.Allow(ctx, EmailBizVerifyEmail, email)
+	if err != nil {
+		return err
+	}
+	user, err := svc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if err == repository.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+	if user.Status != domain.UserStatusPending {
+		return nil
+	}
+
+	link := fmt.Sprintf(svc.linkUrl, url.QueryEscape(svc.signToken(user.Id, user.Email, svc.now().Add(svc.expiretion))))
+	body := fmt.Sprintf(`<p>你好：</p>
+<p>欢迎注册小微书，请在 %d 小时内打开下面的链接验证邮箱，验证之后才能登录：</p>
+<p><a href="%s">%s</a></p>
+<p>如果不是你本人注册，请忽略这封邮件。</p>`, int(svc.expiretion.Hours()), link, link)
+	return svc.emailSvc.Send(ctx, user.Email, verifyEmailSubject, body)
+}
+
+/**
+ * @description: 用验证链接里的token激活账号
+ * @param {context.Context} ctx
+ * @param {string} token
+ * @return {error}
+ */
+func (svc *EmailVerifyServiceInstance) VerifyEmail(ctx context.Context, token string) error {
+	uid, email, err := svc.verifyToken(token)
+	if err != nil {
+		return err
+	}
+	user, err := svc.userRepo.FindById(ctx, uid)
+	if err != nil {
+		if err == repository.ErrUserNotFound {
+			return ErrVerifyTokenInvalid
+		}
+		return err
+	}
+	// 发邮件之后改过邮箱，旧链接不能拿来验证新邮箱
+	if user.Email != email {
+		return ErrVerifyTokenInvalid
+	}
+	switch user.Status {
+	case domain.UserStatusActive:
+		return nil
+	case domain.UserStatusPending:
+		return svc.userRepo.UpdateStatus(ctx, uid, domain.UserStatusActive)
+	default:
+		// 禁用、注销的账号不能靠验证邮箱恢复
+		return ErrVerifyTokenInvalid
+	}
+}
+
+/**
+ * @description: token 格式是 uid.过期时间.邮箱.签名，邮箱里有 . 要先编码
+ * @param {uint64} uid
+ * @param {string} email
+ * @param {time.Time} expireAt
+ * @return {string}
+ */
+func (svc *EmailVerifyServiceInstance) signToken(uid uint64, email string, expireAt time.Time) string {
+	return svc.signer.sign(strconv.FormatUint(uid, 10), strconv.FormatInt(expireAt.Unix(), 10),
+		base64.RawURLEncoding.EncodeToString([]byte(email)))
+}
+
+/**
+ * @description: 校验签名和过期时间
+ * @param {string} token
+ * @return {uint64, string, error} uid 和邮箱
+ */
+func (svc *EmailVerifyServiceInstance) verifyToken(token string) (uint64, string, error) {
+	segs, ok := svc.signer.verify(token, 3)
+	if !ok {
+		return 0, "", ErrVerifyTokenInvalid
+	}
+	uid, err := strconv.ParseUint(segs[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrVerifyTokenInvalid
+	}
+	expireAt, err := strconv.ParseInt(segs[1], 10, 64)
+	if err != nil || !svc.now().Before(time.Unix(expireAt, 0)) {
+		return 0, "", ErrVerifyTokenInvalid
+	}
+	email, err := base64.RawURLEncoding.DecodeString(segs[2])
+	if err != nil {
+		return 0, "", ErrVerifyTokenInvalid
+	}
+	return uid, string(email), nil
+}
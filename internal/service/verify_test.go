@@ -0,0 +1,216 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 21:02:16
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/service/verify_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	repomocks "github.com/gz4z2b/go-webook/internal/repository/mocks"
+	"github.com/gz4z2b/go-webook/internal/service/email"
+	emailmocks "github.com/gz4z2b/go-webook/internal/service/email/mocks"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const testVerifyUrl = "http://localhost:3000/users/verify_email?token=%s"
+
+func newTestEmailVerifyService(userRepo repository.UserRepository, emailSvc email.Service) *EmailVerifyServiceInstance {
+	throttle := NewEmailThrottle(ratelimit.NewLocalSlidingWindowLimiter(time.Minute, 1))
+	svc := NewEmailVerifyService(userRepo, emailSvc, throttle, "verify-key-for-test", testVerifyUrl, time.Hour*24).(*EmailVerifyServiceInstance)
+	svc.now = func() time.Time {
+		return testResetNow
+	}
+	return svc
+}
+
+func TestEmailVerifyServiceInstance_SendVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (repository.UserRepository, email.Service)
+		wantErr error
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, email.Service) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				emailSvc := emailmocks.NewMockService(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{
+					Id:     1,
+					Email:  "gz4z2b@163.com",
+					Status: domain.UserStatusPending,
+				}, nil)
+				emailSvc.EXPECT().Send(gomock.Any(), "gz4z2b@163.com", verifyEmailSubject, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, to string, subject string, body string) error {
+						uid, gotEmail, err := newTestEmailVerifyService(nil, nil).verifyToken(extractResetToken(t, body))
+						assert.NoError(t, err)
+						assert.Equal(t, uint64(1), uid)
+						assert.Equal(t, "gz4z2b@163.com", gotEmail)
+						return nil
+					})
+				return userRepo, emailSvc
+			},
+		},
+		{
+			name: "已经验证过不再发",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, email.Service) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{
+					Id:     1,
+					Email:  "gz4z2b@163.com",
+					Status: domain.UserStatusActive,
+				}, nil)
+				return userRepo, emailmocks.NewMockService(ctrl)
+			},
+		},
+		{
+			name: "邮箱没注册",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, email.Service) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{}, repository.ErrUserNotFound)
+				return userRepo, emailmocks.NewMockService(ctrl)
+			},
+		},
+		{
+			name: "数据库炸了",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, email.Service) {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{}, errors.New("数据库炸了"))
+				return userRepo, emailmocks.NewMockService(ctrl)
+			},
+			wantErr: errors.New("数据库炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := newTestEmailVerifyService(tt.mock(ctrl))
+			err := svc.SendVerifyEmail(context.Background(), "gz4z2b@163.com")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestEmailVerifyServiceInstance_SendVerifyEmailTooMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{}, repository.ErrUserNotFound).Times(2)
+
+	// 跟找回密码共用一个 throttle，两种邮件各算各的
+	throttle := NewEmailThrottle(ratelimit.NewLocalSlidingWindowLimiter(time.Minute, 1))
+	verifySvc := NewEmailVerifyService(userRepo, emailmocks.NewMockService(ctrl), throttle, "verify-key-for-test", testVerifyUrl, time.Hour*24)
+	passwordSvc := NewPasswordService(userRepo, nil, nil, nil, throttle, testResetKey, testResetUrl, time.Minute*30)
+	assert.NoError(t, verifySvc.SendVerifyEmail(context.Background(), "gz4z2b@163.com"))
+	assert.NoError(t, passwordSvc.SendResetEmail(context.Background(), "gz4z2b@163.com"))
+	assert.Equal(t, ErrEmailSendTooMany, verifySvc.SendVerifyEmail(context.Background(), "gz4z2b@163.com"))
+}
+
+func TestEmailVerifyServiceInstance_VerifyEmail(t *testing.T) {
+	signer := newTestEmailVerifyService(nil, nil)
+	validToken := signer.signToken(1, "gz4z2b@163.com", testResetNow.Add(time.Hour))
+
+	tests := []struct {
+		name    string
+		token   string
+		mock    func(ctrl *gomock.Controller) repository.UserRepository
+		wantErr error
+	}{
+		{
+			name:  "正常",
+			token: validToken,
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{
+					Id:     1,
+					Email:  "gz4z2b@163.com",
+					Status: domain.UserStatusPending,
+				}, nil)
+				userRepo.EXPECT().UpdateStatus(gomock.Any(), uint64(1), domain.UserStatusActive).Return(nil)
+				return userRepo
+			},
+		},
+		{
+			name:  "重复点链接",
+			token: validToken,
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{
+					Id:     1,
+					Email:  "gz4z2b@163.com",
+					Status: domain.UserStatusActive,
+				}, nil)
+				return userRepo
+			},
+		},
+		{
+			name:  "邮箱已经改了",
+			token: validToken,
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{
+					Id:     1,
+					Email:  "new@163.com",
+					Status: domain.UserStatusPending,
+				}, nil)
+				return userRepo
+			},
+			wantErr: ErrVerifyTokenInvalid,
+		},
+		{
+			name:  "禁用的账号不能激活",
+			token: validToken,
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{
+					Id:     1,
+					Email:  "gz4z2b@163.com",
+					Status: domain.UserStatusDisabled,
+				}, nil)
+				return userRepo
+			},
+			wantErr: ErrVerifyTokenInvalid,
+		},
+		{
+			name:  "过期",
+			token: signer.signToken(1, "gz4z2b@163.com", testResetNow),
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				return repomocks.NewMockUserRepository(ctrl)
+			},
+			wantErr: ErrVerifyTokenInvalid,
+		},
+		{
+			name:  "重置密码的token不能拿来验证邮箱",
+			token: newTestPasswordService(nil, nil, nil).signToken(1, testResetNow.Add(time.Hour), "bm9uY2U"),
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				return repomocks.NewMockUserRepository(ctrl)
+			},
+			wantErr: ErrVerifyTokenInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := newTestEmailVerifyService(tt.mock(ctrl), nil)
+			err := svc.VerifyEmail(context.Background(), tt.token)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 21:10:34
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/auth.go
+ * @Description: 从 gin.Context 取登录态
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
+)
+
+// LoginUser 登录中间件从token里解析出来的当前用户
+type LoginUser struct {
+	// 迁移期内的旧token只有邮箱，Uid 为 0
+	Uid   uint64
+	Email string
+	Ssid  string
+}
+
+/**
+ * @description: 取当前登录用户，没有登录态返回 false
+ * @param {*gin.Context} ctx
+ * @return {LoginUser, bool}
+ */
+func GetUser(ctx *gin.Context) (LoginUser, bool) {
+	val, exist := ctx.Get(ijwt.ClaimsKey)
+	if !exist {
+		return LoginUser{}, false
+	}
+	claims, ok := val.(*domain.UserClaims)
+	if !ok {
+		return LoginUser{}, false
+	}
+	return LoginUser{
+		Uid:   claims.Uid,
+		Email: claims.Email,
+		Ssid:  claims.Ssid,
+	}, true
+}
+
+/**
+ * @description: 取当前登录用户，只能用在登录中间件后面的路由，取不到说明路由配置有问题，直接 panic
+ * @param {*gin.Context} ctx
+ * @return {LoginUser}
+ */
+func MustUser(ctx *gin.Context) LoginUser {
+	user, ok := GetUser(ctx)
+	if !ok {
+		panic(errNotLogin)
+	}
+	return user
+}
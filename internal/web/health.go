@@ -0,0 +1,122 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 09:12:36
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/health.go
+ * @Description: 存活和就绪探针
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package web
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gz4z2b/go-webook/internal/web/i18n"
+)
+
+// HealthCheck 检查一个依赖是否可用，比如 ping 一下 mysql
+type HealthCheck func(ctx context.Context) error
+
+const (
+	healthUp   = "up"
+	healthDown = "down"
+)
+
+type HealthHandler struct {
+	checks map[string]HealthCheck
+	// 单次就绪检查最多等多久，探针本身也有超时，不能比它长
+	timeout time.Duration
+	// 开始停机之后就绪检查一律失败，让 k8s 先把流量摘掉
+	draining atomic.Bool
+}
+
+/**
+ * @description: 探针处理器
+ * @param {map[string]HealthCheck} checks 依赖名到检查方法的映射
+ * @param {time.Duration} timeout
+ * @return {*HealthHandler}
+ */
+func NewHealthHandler(checks map[string]HealthCheck, timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// RegisterRoutes 探针要在中间件之前注册，不用登录也不算限流
+func (h *HealthHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/healthz", h.Healthz)
+	server.GET("/readyz", h.Readyz)
+}
+
+// Drain 停机前调用，之后 /readyz 返回 503，进程还在正常处理请求
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Healthz 存活探针，进程能响应就算活着，不查依赖，免得数据库一抖所有实例都被重启
+func (h *HealthHandler) Healthz(ctx *gin.Context) {
+	writeOK(ctx, msgOK, nil)
+}
+
+// Readyz 就绪探针，依赖都通了才接流量
+func (h *HealthHandler) Readyz(ctx *gin.Context) {
+	if h.draining.Load() {
+		writeError(ctx, errNotReady)
+		return
+	}
+
+	status, ok := h.check(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(errNotReady.status, Result{
+			Code: errNotReady.code,
+			Msg:  i18n.T(locales(ctx), errNotReady.key()),
+			Data: status,
+		})
+		return
+	}
+	writeOK(ctx, msgOK, status)
+}
+
+/**
+ * @description: 并发检查所有依赖，具体错误只记日志，不透给调用方
+ * @param {context.Context} ctx
+ * @return {map[string]string, bool} 每个依赖的状态，是否全部可用
+ */
+func (h *HealthHandler) check(ctx context.Context) (map[string]string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var (
+		lock   sync.Mutex
+		wg     sync.WaitGroup
+		ok     = true
+		status = make(map[string]string, len(h.checks))
+	)
+	for name, check := range h.checks {
+		name, check := name, check
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := check(ctx)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				log.Printf("就绪检查 %s 失败: %v", name, err)
+				status[name] = healthDown
+				ok = false
+				return
+			}
+			status[name] = healthUp
+		}()
+	}
+	wg.Wait()
+	return status, ok
+}
@@ -0,0 +1,112 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 10:02:51
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/health_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler_Healthz(t *testing.T) {
+	// 依赖挂了也算活着
+	hdl := NewHealthHandler(map[string]HealthCheck{
+		"mysql": func(ctx context.Context) error {
+			return errors.New("连不上")
+		},
+	}, time.Second)
+	server := InitWebService(nil, hdl, []gin.HandlerFunc{func(ctx *gin.Context) {
+		// 探针不经过其他中间件
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+	}})
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `{"code":0,"msg":"成功"}`, resp.Body.String())
+}
+
+func TestHealthHandler_Readyz(t *testing.T) {
+	up := func(ctx context.Context) error {
+		return nil
+	}
+	tests := []struct {
+		name     string
+		checks   map[string]HealthCheck
+		drain    bool
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "依赖都通",
+			checks: map[string]HealthCheck{
+				"mysql": up,
+				"redis": up,
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"成功","data":{"mysql":"up","redis":"up"}}`,
+		},
+		{
+			name: "有依赖不通",
+			checks: map[string]HealthCheck{
+				"mysql": up,
+				"redis": func(ctx context.Context) error {
+					return errors.New("dial tcp 127.0.0.1:6379: connect: connection refused")
+				},
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: `{"code":503001,"msg":"服务未就绪","data":{"mysql":"up","redis":"down"}}`,
+		},
+		{
+			name: "检查超时",
+			checks: map[string]HealthCheck{
+				"mysql": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: `{"code":503001,"msg":"服务未就绪","data":{"mysql":"down"}}`,
+		},
+		{
+			name: "停机中",
+			checks: map[string]HealthCheck{
+				"mysql": up,
+			},
+			drain:    true,
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: `{"code":503001,"msg":"服务未就绪"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hdl := NewHealthHandler(tt.checks, time.Millisecond*50)
+			if tt.drain {
+				hdl.Drain()
+			}
+			server := InitWebService(nil, hdl, []gin.HandlerFunc{})
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+		})
+	}
+}
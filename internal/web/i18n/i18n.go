@@ -0,0 +1,152 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 16:12:45
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/i18n/i18n.go
+ * @Description: 接口提示文案的多语言，按 Accept-Language 选语言
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale 客户端要的语言都不支持，或者文案缺了的时候最后用它
+const DefaultLocale = "zh-CN"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalogs 语言 -> 文案key -> 文案，key 是错误码或者成功提示的名字
+var catalogs = loadCatalogs()
+
+// supported 排好序的语言列表，按主语言匹配时结果固定
+var supported = func() []string {
+	res := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		res = append(res, locale)
+	}
+	sort.Strings(res)
+	return res
+}()
+
+/**
+ * @description: 加载 locales 目录下的文案，文件名就是语言，文件有问题说明打包出错，直接 panic
+ * @return {map[string]map[string]string}
+ */
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	res := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err = json.Unmarshal(data, &catalog); err != nil {
+			panic(err)
+		}
+		res[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+	return res
+}
+
+/**
+ * @description: 按 Accept-Language 协商语言，返回支持的语言列表，最后一定是默认语言
+ * en-GB 这种没有完全一样的会退到 en，zh 会匹配到 zh-CN
+ * @param {string} acceptLanguage 比如 en-GB,en;q=0.9,zh-CN;q=0.8
+ * @return {[]string}
+ */
+func Negotiate(acceptLanguage string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				q = v
+			}
+		}
+		// q=0 表示明确不要这个语言
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	locales := make([]string, 0, len(tags)+1)
+	seen := make(map[string]bool, len(tags)+1)
+	for _, t := range tags {
+		locale, ok := match(t.tag)
+		if ok && !seen[locale] {
+			seen[locale] = true
+			locales = append(locales, locale)
+		}
+	}
+	if !seen[DefaultLocale] {
+		locales = append(locales, DefaultLocale)
+	}
+	return locales
+}
+
+/**
+ * @description: 按协商出来的语言顺序找文案，都没有就原样返回 key
+ * @param {[]string} locales Negotiate 的结果
+ * @param {string} key
+ * @return {string}
+ */
+func T(locales []string, key string) string {
+	for _, locale := range locales {
+		if msg, ok := catalogs[locale][key]; ok {
+			return msg
+		}
+	}
+	if msg, ok := catalogs[DefaultLocale][key]; ok {
+		return msg
+	}
+	return key
+}
+
+/**
+ * @description: 先完全匹配，再按主语言匹配
+ * @param {string} tag
+ * @return {string, bool}
+ */
+func match(tag string) (string, bool) {
+	base := strings.SplitN(tag, "-", 2)[0]
+	var baseMatch string
+	for _, locale := range supported {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+		if baseMatch == "" && strings.EqualFold(strings.SplitN(locale, "-", 2)[0], base) {
+			baseMatch = locale
+		}
+	}
+	return baseMatch, baseMatch != ""
+}
@@ -0,0 +1,85 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 16:41:08
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/i18n/i18n_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           []string
+	}{
+		{
+			name: "没带",
+			want: []string{"zh-CN"},
+		},
+		{
+			name:           "完全匹配",
+			acceptLanguage: "en",
+			want:           []string{"en", "zh-CN"},
+		},
+		{
+			name:           "按主语言匹配",
+			acceptLanguage: "en-GB",
+			want:           []string{"en", "zh-CN"},
+		},
+		{
+			name:           "zh匹配到zh-CN",
+			acceptLanguage: "zh",
+			want:           []string{"zh-CN"},
+		},
+		{
+			name:           "按q排序",
+			acceptLanguage: "zh-CN;q=0.5,en-US;q=0.8",
+			want:           []string{"en", "zh-CN"},
+		},
+		{
+			name:           "不支持的跳过",
+			acceptLanguage: "fr-FR,ja;q=0.9,en;q=0.8,*;q=0.1",
+			want:           []string{"en", "zh-CN"},
+		},
+		{
+			name:           "q=0不要",
+			acceptLanguage: "en;q=0",
+			want:           []string{"zh-CN"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Negotiate(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Internal server error", T([]string{"en", "zh-CN"}, "500001"))
+	assert.Equal(t, "系统错误", T([]string{"zh-CN"}, "500001"))
+	// 没协商过的按默认语言
+	assert.Equal(t, "系统错误", T(nil, "500001"))
+	// 都没有就原样返回
+	assert.Equal(t, "not_exist", T([]string{"en"}, "not_exist"))
+}
+
+func TestCatalogs(t *testing.T) {
+	// 每种语言的文案key要和默认语言一致，漏翻的会退回中文
+	for locale, catalog := range catalogs {
+		for key := range catalogs[DefaultLocale] {
+			assert.Contains(t, catalog, key, "%s 缺少 %s", locale, key)
+		}
+		for key := range catalog {
+			assert.Contains(t, catalogs[DefaultLocale], key, "%s 多了 %s", locale, key)
+		}
+	}
+}
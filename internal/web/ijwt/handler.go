@@ -0,0 +1,246 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 20:12:27
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/ijwt/handler.go
+ * @Description: 短token + 长token，长token按会话可以作废
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ijwt
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+)
+
+type JWTHandler struct {
+	cache             cache.SessionCache
+	accessKeys        KeyStore
+	refreshKeys       KeyStore
+	accessExpiretion  time.Duration
+	refreshExpiretion time.Duration
+}
+
+func NewJWTHandler(cache cache.SessionCache, accessKeys KeyStore, refreshKeys KeyStore) Handler {
+	return &JWTHandler{
+		cache:             cache,
+		accessKeys:        accessKeys,
+		refreshKeys:       refreshKeys,
+		accessExpiretion:  time.Minute * 30,
+		refreshExpiretion: time.Hour * 24 * 7,
+	}
+}
+
+/**
+ * @description: 登录成功后开一个新会话，同时下发短token和长token
+ * @param {*gin.Context} ctx
+ * @param {*domain.User} user
+ * @return {error}
+ */
+func (h *JWTHandler) SetLoginToken(ctx *gin.Context, user *domain.User) error {
+	ssid := uuid.New().String()
+	err := h.SetJWTToken(ctx, user.Id, user.Email, ssid)
+	if err != nil {
+		return err
+	}
+	return h.setRefreshToken(ctx, user.Id, user.Email, ssid)
+}
+
+/**
+ * @description: 下发短token
+ * @param {*gin.Context} ctx
+ * @param {uint64} uid
+ * @param {string} email
+ * @param {string} ssid
+ * @return {error}
+ */
+func (h *JWTHandler) SetJWTToken(ctx *gin.Context, uid uint64, email string, ssid string) error {
+	now := time.Now()
+	userClaims := domain.UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(h.accessExpiretion)),
+		},
+		Uid:           uid,
+		Email:         email,
+		Ssid:          ssid,
+		UserAgent:     ctx.Request.UserAgent(),
+		IssuedAtMilli: now.UnixMilli(),
+	}
+	tokenStr, err := h.accessKeys.Sign(userClaims)
+	if err != nil {
+		return err
+	}
+	ctx.Header("x-jwt-token", tokenStr)
+	return nil
+}
+
+func (h *JWTHandler) setRefreshToken(ctx *gin.Context, uid uint64, email string, ssid string) error {
+	now := time.Now()
+	refreshClaims := domain.RefreshClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(h.refreshExpiretion)),
+		},
+		Uid:           uid,
+		Email:         email,
+		Ssid:          ssid,
+		UserAgent:     ctx.Request.UserAgent(),
+		IssuedAtMilli: now.UnixMilli(),
+	}
+	tokenStr, err := h.refreshKeys.Sign(refreshClaims)
+	if err != nil {
+		return err
+	}
+	ctx.Header("x-refresh-token", tokenStr)
+	return nil
+}
+
+/**
+ * @description: 从 Authorization 头取出token，格式是 Bearer xxx
+ * @param {*gin.Context} ctx
+ * @return {string}
+ */
+func (h *JWTHandler) ExtractToken(ctx *gin.Context) string {
+	tokenHeader := ctx.GetHeader("Authorization")
+	segs := strings.Split(tokenHeader, " ")
+	if len(segs) != 2 {
+		return ""
+	}
+	return segs[1]
+}
+
+/**
+ * @description: 解析短token，并校验 UserAgent
+ * @param {*gin.Context} ctx
+ * @param {string} tokenStr
+ * @return {*domain.UserClaims, error}
+ */
+func (h *JWTHandler) ParseAccessToken(ctx *gin.Context, tokenStr string) (*domain.UserClaims, error) {
+	claims := &domain.UserClaims{}
+	err := h.parse(tokenStr, claims, h.accessKeys)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Request.UserAgent() != claims.UserAgent {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+/**
+ * @description: 解析长token，并校验 UserAgent
+ * @param {*gin.Context} ctx
+ * @param {string} tokenStr
+ * @return {*domain.RefreshClaims, error}
+ */
+func (h *JWTHandler) ParseRefreshToken(ctx *gin.Context, tokenStr string) (*domain.RefreshClaims, error) {
+	claims := &domain.RefreshClaims{}
+	err := h.parse(tokenStr, claims, h.refreshKeys)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Request.UserAgent() != claims.UserAgent || claims.Ssid == "" {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+func (h *JWTHandler) parse(tokenStr string, claims jwt.Claims, verifier TokenVerifier) error {
+	if tokenStr == "" {
+		return ErrTokenInvalid
+	}
+	if err := verifier.Verify(tokenStr, claims); err != nil {
+		return ErrTokenInvalid
+	}
+	return nil
+}
+
+/**
+ * @description: 公开短token的验证公钥
+ * @return {JWKS}
+ */
+func (h *JWTHandler) JWKS() JWKS {
+	return h.accessKeys.JWKS()
+}
+
+/**
+ * @description: 检查会话是否已经作废，包括单个会话被登出和用户所有会话被作废两种情况
+ * @param {*gin.Context} ctx
+ * @param {uint64} uid 旧token没有 uid 时传 0，只检查会话
+ * @param {string} ssid 旧token没有会话时传空，只检查用户
+ * @param {time.Time} issuedAt token的签发时间，用 claims 的 IssuedTime，零值按很早以前签发的处理
+ * @return {error}
+ */
+func (h *JWTHandler) CheckSession(ctx *gin.Context, uid uint64, ssid string, issuedAt time.Time) error {
+	if ssid != "" {
+		revoked, err := h.cache.IsRevoked(ctx, ssid)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrSessionRevoked
+		}
+	}
+	if uid != 0 {
+		before, err := h.cache.UserRevokedBefore(ctx, uid)
+		if err != nil {
+			return err
+		}
+		// 都精确到毫秒，作废之后才签发的token照常放行；只有秒级 iat 的旧token按整秒算，同一秒里签发的也算作废
+		if !before.IsZero() && issuedAt.Before(before) {
+			return ErrSessionRevoked
+		}
+	}
+	return nil
+}
+
+/**
+ * @description: 作废用户现在为止签发的所有token，改密码之后所有设备都要重新登录
+ * @param {*gin.Context} ctx
+ * @param {uint64} uid
+ * @return {error}
+ */
+func (h *JWTHandler) RevokeUserSessions(ctx *gin.Context, uid uint64) error {
+	return h.cache.RevokeUser(ctx, uid, time.Now(), h.refreshExpiretion)
+}
+
+/**
+ * @description: 作废会话，记录保留到长token过期为止
+ * @param {*gin.Context} ctx
+ * @param {string} ssid
+ * @return {error}
+ */
+func (h *JWTHandler) RevokeSession(ctx *gin.Context, ssid string) error {
+	return h.cache.Revoke(ctx, ssid, h.refreshExpiretion)
+}
+
+/**
+ * @description: 登出，作废当前会话，短token和长token一起失效
+ * @param {*gin.Context} ctx
+ * @return {error}
+ */
+func (h *JWTHandler) ClearToken(ctx *gin.Context) error {
+	ctx.Header("x-jwt-token", "")
+	ctx.Header("x-refresh-token", "")
+	val, exist := ctx.Get(ClaimsKey)
+	if !exist {
+		return ErrTokenInvalid
+	}
+	claims, ok := val.(*domain.UserClaims)
+	if !ok {
+		return ErrTokenInvalid
+	}
+	if claims.Ssid == "" {
+		// 引入会话之前签发的token没有 Ssid，只能等它自然过期
+		return nil
+	}
+	return h.RevokeSession(ctx, claims.Ssid)
+}
@@ -0,0 +1,191 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 20:40:16
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/ijwt/handler_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ijwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coocood/freecache"
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestContext(userAgent string) (*gin.Context, *httptest.ResponseRecorder) {
+	resp := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(resp)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/users/login", nil)
+	ctx.Request.Header.Set("User-Agent", userAgent)
+	return ctx, resp
+}
+
+func newTestHandler(t *testing.T) Handler {
+	accessKeys, err := NewKeySetFromConf([]conf.JwtKeyConf{
+		{Id: "access-hs512-v1", Alg: "HS512", Key: "webook-test-access-key-0123456789abcdef0123456789abcdef", Legacy: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshKeys, err := NewKeySetFromConf([]conf.JwtKeyConf{
+		{Id: "refresh-hs512-v1", Alg: "HS512", Key: "webook-test-refresh-key-0123456789abcdef0123456789abcdef", Legacy: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewJWTHandler(cache.NewSessionMemoryCache(freecache.NewCache(1024*1024)), accessKeys, refreshKeys)
+}
+
+func TestJWTHandler_SetLoginToken(t *testing.T) {
+	hdl := newTestHandler(t)
+	ctx, resp := newTestContext("webook-test")
+	err := hdl.SetLoginToken(ctx, &domain.User{
+		Id:    1,
+		Email: "gz4z2b@163.com",
+	})
+	assert.NoError(t, err)
+
+	accessToken := resp.Header().Get("x-jwt-token")
+	refreshToken := resp.Header().Get("x-refresh-token")
+	assert.NotEmpty(t, accessToken)
+	assert.NotEmpty(t, refreshToken)
+
+	userClaims, err := hdl.ParseAccessToken(ctx, accessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), userClaims.Uid)
+	assert.Equal(t, "gz4z2b@163.com", userClaims.Email)
+
+	refreshClaims, err := hdl.ParseRefreshToken(ctx, refreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), refreshClaims.Uid)
+	assert.NotEmpty(t, refreshClaims.Ssid)
+	assert.Equal(t, refreshClaims.Ssid, userClaims.Ssid)
+
+	// 两种token用不同的key签名，不能混用
+	_, err = hdl.ParseAccessToken(ctx, refreshToken)
+	assert.Equal(t, ErrTokenInvalid, err)
+	_, err = hdl.ParseRefreshToken(ctx, accessToken)
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	// 换个设备拿来用
+	otherCtx, _ := newTestContext("other-agent")
+	_, err = hdl.ParseAccessToken(otherCtx, accessToken)
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	assert.NoError(t, hdl.CheckSession(ctx, refreshClaims.Uid, refreshClaims.Ssid, refreshClaims.IssuedTime()))
+	assert.NoError(t, hdl.RevokeSession(ctx, refreshClaims.Ssid))
+	assert.Equal(t, ErrSessionRevoked, hdl.CheckSession(ctx, refreshClaims.Uid, refreshClaims.Ssid, refreshClaims.IssuedTime()))
+}
+
+func TestJWTHandler_RevokeUserSessions(t *testing.T) {
+	hdl := newTestHandler(t)
+	ctx, _ := newTestContext("webook-test")
+	now := time.Now()
+
+	assert.NoError(t, hdl.CheckSession(ctx, 1, "ssid-1", now.Add(-time.Hour)))
+	assert.NoError(t, hdl.RevokeUserSessions(ctx, 1))
+
+	// 作废之前签发的都不能用了，没有签发时间的旧token也一样
+	assert.Equal(t, ErrSessionRevoked, hdl.CheckSession(ctx, 1, "ssid-1", now.Add(-time.Hour)))
+	assert.Equal(t, ErrSessionRevoked, hdl.CheckSession(ctx, 1, "", time.Time{}))
+	// 重新登录拿到的token不受影响，跟作废在同一秒里签发的也一样
+	assert.NoError(t, hdl.CheckSession(ctx, 1, "ssid-2", time.Now()))
+	// 别的用户不受影响
+	assert.NoError(t, hdl.CheckSession(ctx, 2, "ssid-3", now.Add(-time.Hour)))
+
+	// 作废之后马上登录，iat 只到秒，毫秒签发时间放在自己的字段里
+	loginCtx, resp := newTestContext("webook-test")
+	assert.NoError(t, hdl.SetLoginToken(loginCtx, &domain.User{Id: 1}))
+	claims, err := hdl.ParseAccessToken(loginCtx, resp.Header().Get("x-jwt-token"))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, jwt.TimePrecision)
+	assert.NotZero(t, claims.IssuedAtMilli)
+	assert.NoError(t, hdl.CheckSession(loginCtx, 1, claims.Ssid, claims.IssuedTime()))
+}
+
+func TestJWTHandler_ExtractToken(t *testing.T) {
+	hdl := &JWTHandler{}
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{
+			name:   "正常",
+			header: "Bearer abc",
+			want:   "abc",
+		},
+		{
+			name:   "没带",
+			header: "",
+			want:   "",
+		},
+		{
+			name:   "格式不对",
+			header: "abc",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := newTestContext("webook-test")
+			ctx.Request.Header.Set("Authorization", tt.header)
+			assert.Equal(t, tt.want, hdl.ExtractToken(ctx))
+		})
+	}
+}
+
+func TestJWTHandler_ClearToken(t *testing.T) {
+	hdl := newTestHandler(t)
+	tests := []struct {
+		name        string
+		claims      any
+		wantErr     error
+		wantRevoked bool
+	}{
+		{
+			name: "正常",
+			claims: &domain.UserClaims{
+				Uid:  1,
+				Ssid: "ssid-1",
+			},
+			wantRevoked: true,
+		},
+		{
+			name: "旧token没有ssid",
+			claims: &domain.UserClaims{
+				Email: "gz4z2b@163.com",
+			},
+		},
+		{
+			name:    "没有登录态",
+			wantErr: ErrTokenInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, resp := newTestContext("webook-test")
+			if tt.claims != nil {
+				ctx.Set(ClaimsKey, tt.claims)
+			}
+			err := hdl.ClearToken(ctx)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Empty(t, resp.Header().Get("x-jwt-token"))
+			if tt.wantRevoked {
+				assert.Equal(t, ErrSessionRevoked, hdl.CheckSession(ctx, 0, "ssid-1", time.Time{}))
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 20:05:41
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/ijwt/interface.go
+ * @Description: 登录token接口
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ijwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gz4z2b/go-webook/internal/domain"
+)
+
+// ClaimsKey 登录中间件把解析出来的 *domain.UserClaims 放在 gin.Context 里的 key
+const ClaimsKey = "user_claims"
+
+var (
+	ErrTokenInvalid   = errors.New("token无效")
+	ErrSessionRevoked = errors.New("会话已失效")
+)
+
+type Handler interface {
+	// SetLoginToken 登录成功后开一个新会话，同时下发短token和长token
+	SetLoginToken(ctx *gin.Context, user *domain.User) error
+	// SetJWTToken 只下发短token，ssid 沿用长token的会话
+	SetJWTToken(ctx *gin.Context, uid uint64, email string, ssid string) error
+	// ExtractToken 从 Authorization 头取出token
+	ExtractToken(ctx *gin.Context) string
+	ParseAccessToken(ctx *gin.Context, tokenStr string) (*domain.UserClaims, error)
+	ParseRefreshToken(ctx *gin.Context, tokenStr string) (*domain.RefreshClaims, error)
+	// CheckSession 会话被登出或者用户所有会话被作废时返回 ErrSessionRevoked
+	CheckSession(ctx *gin.Context, uid uint64, ssid string, issuedAt time.Time) error
+	RevokeSession(ctx *gin.Context, ssid string) error
+	// RevokeUserSessions 作废用户到现在为止签发的所有token
+	RevokeUserSessions(ctx *gin.Context, uid uint64) error
+	// ClearToken 登出，作废当前会话并清掉前端的token
+	ClearToken(ctx *gin.Context) error
+	// JWKS 公开短token的验证公钥，给别的服务验证我们签发的token
+	JWKS() JWKS
+}
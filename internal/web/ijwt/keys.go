@@ -0,0 +1,276 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 22:05:18
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/ijwt/keys.go
+ * @Description: token签名key，按 kid 区分，支持多个key同时验证方便轮换
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ijwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/gz4z2b/go-webook/conf"
+)
+
+var (
+	ErrKeyInvalid  = errors.New("token签名key配置错误")
+	ErrKeyNotFound = errors.New("找不到token对应的签名key")
+)
+
+type TokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
+}
+
+type TokenVerifier interface {
+	Verify(tokenStr string, claims jwt.Claims) error
+}
+
+type KeyStore interface {
+	TokenSigner
+	TokenVerifier
+	// JWKS 公开的验证key，对称key不会出现在里面
+	JWKS() JWKS
+}
+
+// Key 一把签名key，只配了公钥的只能用来验证
+type Key struct {
+	Id        string
+	Method    jwt.SigningMethod
+	SignKey   any
+	VerifyKey any
+}
+
+/**
+ * @description: 按配置解析签名key，HS512 是密钥本身，RS256/EdDSA 是PEM编码的私钥或公钥
+ * @param {conf.JwtKeyConf} c
+ * @return {Key, error}
+ */
+func ParseKey(c conf.JwtKeyConf) (Key, error) {
+	if c.Id == "" {
+		return Key{}, fmt.Errorf("%w: kid不能为空", ErrKeyInvalid)
+	}
+	switch c.Alg {
+	case jwt.SigningMethodHS512.Alg():
+		if c.Key == "" {
+			return Key{}, fmt.Errorf("%w: %s 密钥为空", ErrKeyInvalid, c.Id)
+		}
+		return Key{
+			Id:        c.Id,
+			Method:    jwt.SigningMethodHS512,
+			SignKey:   []byte(c.Key),
+			VerifyKey: []byte(c.Key),
+		}, nil
+	case jwt.SigningMethodRS256.Alg():
+		return parseAsymmetricKey(c, jwt.SigningMethodRS256, func(pemBytes []byte) (any, error) {
+			return jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		}, func(pemBytes []byte) (any, error) {
+			return jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+		}, func(privateKey any) any {
+			return &privateKey.(*rsa.PrivateKey).PublicKey
+		})
+	case jwt.SigningMethodEdDSA.Alg():
+		return parseAsymmetricKey(c, jwt.SigningMethodEdDSA, func(pemBytes []byte) (any, error) {
+			return jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		}, func(pemBytes []byte) (any, error) {
+			return jwt.ParseEdPublicKeyFromPEM(pemBytes)
+		}, func(privateKey any) any {
+			return privateKey.(ed25519.PrivateKey).Public()
+		})
+	}
+	return Key{}, fmt.Errorf("%w: %s 不支持的算法 %s", ErrKeyInvalid, c.Id, c.Alg)
+}
+
+func parseAsymmetricKey(c conf.JwtKeyConf, method jwt.SigningMethod,
+	parsePrivate func([]byte) (any, error), parsePublic func([]byte) (any, error),
+	publicOf func(any) any) (Key, error) {
+	block, _ := pem.Decode([]byte(c.Key))
+	if block == nil {
+		return Key{}, fmt.Errorf("%w: %s 不是PEM格式", ErrKeyInvalid, c.Id)
+	}
+	if strings.Contains(block.Type, "PUBLIC KEY") {
+		publicKey, err := parsePublic([]byte(c.Key))
+		if err != nil {
+			return Key{}, fmt.Errorf("%w: %s %w", ErrKeyInvalid, c.Id, err)
+		}
+		return Key{
+			Id:        c.Id,
+			Method:    method,
+			VerifyKey: publicKey,
+		}, nil
+	}
+	privateKey, err := parsePrivate([]byte(c.Key))
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %s %w", ErrKeyInvalid, c.Id, err)
+	}
+	return Key{
+		Id:        c.Id,
+		Method:    method,
+		SignKey:   privateKey,
+		VerifyKey: publicOf(privateKey),
+	}, nil
+}
+
+// KeySet 第一把key用来签发，所有key都能验证；轮换时新key放第一位，旧key留到它签发的token过期
+type KeySet struct {
+	keys    []Key
+	keysMap map[string]Key
+	// 引入 kid 之前签发的token用这把key验证
+	legacyKid string
+	methods   []string
+}
+
+/**
+ * @description: 创建签名key集合
+ * @param {[]Key} keys 第一把用来签发
+ * @param {string} legacyKid 没有 kid 的token用哪把key验证，为空表示不接受
+ * @return {*KeySet, error}
+ */
+func NewKeySet(keys []Key, legacyKid string) (*KeySet, error) {
+	if len(keys) == 0 || keys[0].SignKey == nil {
+		return nil, fmt.Errorf("%w: 第一把key必须能用来签发", ErrKeyInvalid)
+	}
+	keysMap := make(map[string]Key, len(keys))
+	methods := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, exist := keysMap[key.Id]; exist {
+			return nil, fmt.Errorf("%w: kid %s 重复", ErrKeyInvalid, key.Id)
+		}
+		keysMap[key.Id] = key
+		methods = append(methods, key.Method.Alg())
+	}
+	if _, exist := keysMap[legacyKid]; legacyKid != "" && !exist {
+		return nil, fmt.Errorf("%w: 找不到旧token的key %s", ErrKeyInvalid, legacyKid)
+	}
+	return &KeySet{
+		keys:      keys,
+		keysMap:   keysMap,
+		legacyKid: legacyKid,
+		methods:   methods,
+	}, nil
+}
+
+/**
+ * @description: 按配置创建签名key集合
+ * @param {[]conf.JwtKeyConf} confs
+ * @return {*KeySet, error}
+ */
+func NewKeySetFromConf(confs []conf.JwtKeyConf) (*KeySet, error) {
+	keys := make([]Key, 0, len(confs))
+	legacyKid := ""
+	for _, c := range confs {
+		key, err := ParseKey(c)
+		if err != nil {
+			return nil, err
+		}
+		if c.Legacy {
+			legacyKid = c.Id
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(keys, legacyKid)
+}
+
+/**
+ * @description: 用第一把key签发token，头部带上 kid
+ * @param {jwt.Claims} claims
+ * @return {string, error}
+ */
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := s.keys[0]
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Id
+	return token.SignedString(key.SignKey)
+}
+
+/**
+ * @description: 按 kid 找到对应的key验证token
+ * @param {string} tokenStr
+ * @param {jwt.Claims} claims
+ * @return {error}
+ */
+func (s *KeySet) Verify(tokenStr string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			kid = s.legacyKid
+		}
+		key, exist := s.keysMap[kid]
+		if !exist {
+			return nil, ErrKeyNotFound
+		}
+		// 防止拿公钥当 HMAC 密钥伪造token
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, ErrKeyNotFound
+		}
+		return key.VerifyKey, nil
+	}, jwt.WithValidMethods(s.methods))
+	if err != nil {
+		return err
+	}
+	if token == nil || !token.Valid {
+		return ErrTokenInvalid
+	}
+	return nil
+}
+
+// JWK 见 RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+/**
+ * @description: 公开所有非对称key的公钥，给别的服务验证我们签发的token
+ * @return {JWKS}
+ */
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{
+		Keys: []JWK{},
+	}
+	for _, key := range s.keys {
+		switch verifyKey := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.Id,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(verifyKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(verifyKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.Id,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(verifyKey),
+			})
+		}
+	}
+	return jwks
+}
@@ -0,0 +1,205 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 22:48:02
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/ijwt/keys_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ijwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRSAPem(t *testing.T) (string, string) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicDer, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer}))
+}
+
+func newEd25519Pem(t *testing.T) (string, string) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDer, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDer, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDer})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer}))
+}
+
+func newClaims() *domain.UserClaims {
+	return &domain.UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Uid:  1,
+		Ssid: "ssid-1",
+	}
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	rsaPrivate, _ := newRSAPem(t)
+	edPrivate, _ := newEd25519Pem(t)
+	tests := []struct {
+		name string
+		conf conf.JwtKeyConf
+	}{
+		{
+			name: "HS512",
+			conf: conf.JwtKeyConf{Id: "hs-v1", Alg: "HS512", Key: "secret"},
+		},
+		{
+			name: "RS256",
+			conf: conf.JwtKeyConf{Id: "rs-v1", Alg: "RS256", Key: rsaPrivate},
+		},
+		{
+			name: "EdDSA",
+			conf: conf.JwtKeyConf{Id: "ed-v1", Alg: "EdDSA", Key: edPrivate},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := NewKeySetFromConf([]conf.JwtKeyConf{tt.conf})
+			require.NoError(t, err)
+			tokenStr, err := keys.Sign(newClaims())
+			require.NoError(t, err)
+
+			claims := &domain.UserClaims{}
+			assert.NoError(t, keys.Verify(tokenStr, claims))
+			assert.Equal(t, uint64(1), claims.Uid)
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenStr, &domain.UserClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.conf.Id, token.Header["kid"])
+			assert.Equal(t, tt.conf.Alg, token.Header["alg"])
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldPrivate, oldPublic := newRSAPem(t)
+	newPrivate, _ := newEd25519Pem(t)
+
+	oldKeys, err := NewKeySetFromConf([]conf.JwtKeyConf{
+		{Id: "rs-v1", Alg: "RS256", Key: oldPrivate},
+	})
+	require.NoError(t, err)
+	oldToken, err := oldKeys.Sign(newClaims())
+	require.NoError(t, err)
+
+	// 新key签发，旧key只留公钥用来验证没过期的token
+	keys, err := NewKeySetFromConf([]conf.JwtKeyConf{
+		{Id: "ed-v2", Alg: "EdDSA", Key: newPrivate},
+		{Id: "rs-v1", Alg: "RS256", Key: oldPublic},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, keys.Verify(oldToken, &domain.UserClaims{}))
+
+	newToken, err := keys.Sign(newClaims())
+	require.NoError(t, err)
+	assert.NoError(t, keys.Verify(newToken, &domain.UserClaims{}))
+	// 旧key集合不认识新key
+	assert.Error(t, oldKeys.Verify(newToken, &domain.UserClaims{}))
+
+	// 只有公钥的key不能放第一位
+	_, err = NewKeySetFromConf([]conf.JwtKeyConf{
+		{Id: "rs-v1", Alg: "RS256", Key: oldPublic},
+	})
+	assert.True(t, errors.Is(err, ErrKeyInvalid))
+}
+
+func TestKeySet_Verify(t *testing.T) {
+	rsaPrivate, rsaPublic := newRSAPem(t)
+	keys, err := NewKeySetFromConf([]conf.JwtKeyConf{
+		{Id: "rs-v1", Alg: "RS256", Key: rsaPrivate},
+		{Id: "hs-v0", Alg: "HS512", Key: "secret", Legacy: true},
+	})
+	require.NoError(t, err)
+
+	signHS512 := func(kid string, key string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS512, newClaims())
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		tokenStr, err := token.SignedString([]byte(key))
+		require.NoError(t, err)
+		return tokenStr
+	}
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "没有kid的旧token",
+			token: signHS512("", "secret"),
+		},
+		{
+			name:    "不认识的kid",
+			token:   signHS512("hs-v9", "secret"),
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:    "拿公钥当HMAC密钥伪造",
+			token:   signHS512("rs-v1", rsaPublic),
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:    "密钥不对",
+			token:   signHS512("hs-v0", "other"),
+			wantErr: jwt.ErrSignatureInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := keys.Verify(tt.token, &domain.UserClaims{})
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.wantErr), "期望 %v，实际 %v", tt.wantErr, err)
+		})
+	}
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	rsaPrivate, _ := newRSAPem(t)
+	_, edPublic := newEd25519Pem(t)
+	keys, err := NewKeySetFromConf([]conf.JwtKeyConf{
+		{Id: "rs-v2", Alg: "RS256", Key: rsaPrivate},
+		{Id: "ed-v1", Alg: "EdDSA", Key: edPublic},
+		{Id: "hs-v0", Alg: "HS512", Key: "secret", Legacy: true},
+	})
+	require.NoError(t, err)
+
+	jwks := keys.JWKS()
+	// 对称key不能公开
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "rs-v2", jwks.Keys[0].Kid)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	assert.NotEmpty(t, jwks.Keys[0].N)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Crv)
+	assert.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+	assert.NotEmpty(t, jwks.Keys[1].X)
+}
@@ -10,30 +10,61 @@
 package web
 
 import (
+	"log"
 	"net/http"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
 	"github.com/gz4z2b/go-webook/internal/web/middleware"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
 )
 
-func InitWebService(userHandler *UserHandler, mids []gin.HandlerFunc) *gin.Engine {
+func InitWebService(userHandler *UserHandler, healthHandler *HealthHandler, mids []gin.HandlerFunc) *gin.Engine {
 	server := gin.Default()
+	trustProxies(server)
+	// gin 注册路由时才把中间件拼进去，先注册的探针不经过下面的中间件
+	healthHandler.RegisterRoutes(server)
 	server.Use(mids...)
 	registerUserRoutes(server, userHandler)
 	return server
 }
 
-func InitUserMidleware() []gin.HandlerFunc {
+/**
+ * @description: ClientIP 只认配置里的代理转过来的 X-Forwarded-For，gin 默认谁带的都认
+ * @param {*gin.Engine} server
+ * @return {*}
+ */
+func trustProxies(server *gin.Engine) {
+	server.TrustedPlatform = conf.Server.TrustedPlatform
+	err := server.SetTrustedProxies(conf.Server.TrustedProxies)
+	if err != nil {
+		// 启动时已经校验过，真到这里也是一个都不信，宁可把同一个代理后面的人算成一个IP
+		log.Printf("可信代理配置有误，不认 X-Forwarded-For: %v", err)
+		_ = server.SetTrustedProxies(nil)
+	}
+}
+
+/**
+ * @description: 全局中间件
+ * @param {ijwt.Handler} jwtHdl
+ * @param {ratelimit.NewLimiterFunc} newLimiter 限流规则在 conf.RateLimit 里配
+ * @return {[]gin.HandlerFunc}
+ */
+func InitUserMidleware(jwtHdl ijwt.Handler, newLimiter ratelimit.NewLimiterFunc) []gin.HandlerFunc {
 	return []gin.HandlerFunc{
 		cors.New(cors.Config{
 			//AllowOrigins: []string{"*"},
-			//AllowMethods: []string{"POST", "GET"},
+			// 不配的话预检请求不带 Allow-Methods，浏览器只放行 GET、POST，DELETE /users/me 会被拦
+			AllowMethods: []string{"GET", "POST", "DELETE"},
 			AllowHeaders: []string{"Content-Type", "Authorization"},
 			// 你不加这个，前端是拿不到的
-			ExposeHeaders: []string{"x-jwt-token"},
+			ExposeHeaders: []string{"x-jwt-token", "x-refresh-token", "Retry-After"},
 			// 是否允许你带 cookie 之类的东西
 			AllowCredentials: true,
 			AllowOriginFunc: func(origin string) bool {
@@ -45,7 +76,59 @@ func InitUserMidleware() []gin.HandlerFunc {
 			},
 			MaxAge: 12 * time.Hour,
 		}),
-		middleware.NewLoginMiddlewareBuilder().IgnorePath("/users/signup").IgnorePath("/users/login").IgnorePath("/hello").Build(),
+		// 按IP、按接口的放在登录前面，没带登录态被401的请求也要占名额，不然刷接口的根本限不住
+		initRateLimitMiddleware(newLimiter, middleware.LimitByIP, middleware.LimitByRoute),
+		middleware.NewLoginMiddlewareBuilder(jwtHdl).
+			IgnorePath("/users/signup").
+			IgnorePath("/users/login").
+			IgnorePath("/users/refresh_token").
+			IgnorePath("/users/login_sms/code/send").
+			IgnorePath("/users/login_sms").
+			IgnorePath("/users/password/forgot").
+			IgnorePath("/users/password/reset").
+			IgnorePath("/users/verify_email").
+			IgnorePath("/users/verify_email/send").
+			IgnorePath("/hello").
+			IgnorePath("/.well-known/jwks.json").
+			AllowLegacyTokenUntil(conf.Auth.LegacyTokenDeadline).
+			SessionFailOpen(conf.Auth.SessionFailOpen).
+			Build(),
+		// 按用户计数要拿登录态，只能放在登录后面
+		initRateLimitMiddleware(newLimiter, middleware.LimitByUser),
+	}
+}
+
+/**
+ * @description: 按配置的规则限流，只取 keyBys 里这几种计数方式的规则，规则热更新时整个重建，redis 里的计数按 key 接着用，本地限流器的计数会清零
+ * @param {ratelimit.NewLimiterFunc} newLimiter
+ * @param {...string} keyBys
+ * @return {gin.HandlerFunc}
+ */
+func initRateLimitMiddleware(newLimiter ratelimit.NewLimiterFunc, keyBys ...string) gin.HandlerFunc {
+	wanted := make(map[string]bool, len(keyBys))
+	for _, keyBy := range keyBys {
+		wanted[keyBy] = true
+	}
+	build := func(rules []conf.RateLimitRule) *gin.HandlerFunc {
+		builder := middleware.NewRateLimitMiddlewareBuilder(newLimiter)
+		for _, rule := range rules {
+			if !wanted[rule.KeyBy] {
+				continue
+			}
+			builder.Rule(rule.Path, rule.KeyBy, rule.Window, rule.Threshold)
+		}
+		hdl := builder.Build()
+		return &hdl
+	}
+	var current atomic.Pointer[gin.HandlerFunc]
+	current.Store(build(conf.RateLimit.Rules))
+	conf.OnReload(func(old *conf.Config, cur *conf.Config) {
+		if !reflect.DeepEqual(old.RateLimit.Rules, cur.RateLimit.Rules) {
+			current.Store(build(cur.RateLimit.Rules))
+		}
+	})
+	return func(ctx *gin.Context) {
+		(*current.Load())(ctx)
 	}
 }
 
@@ -55,10 +138,24 @@ func registerUserRoutes(server *gin.Engine, user *UserHandler) {
 		ctx.String(http.StatusOK, "Hello World")
 	})
 
+	server.GET("/.well-known/jwks.json", user.JWKS)
+
 	userGroup := server.Group("/users")
 	userGroup.POST("/signup", user.Signup)
 	userGroup.GET("/:uid", user.Profile)
 	userGroup.POST("/login", user.Login)
+	userGroup.POST("/login_sms/code/send", user.SendLoginSMSCode)
+	userGroup.POST("/login_sms", user.LoginSMS)
+	userGroup.POST("/refresh_token", user.RefreshToken)
 	userGroup.POST("/edit", user.Edit)
 	userGroup.POST("/logout", user.Logout)
+	userGroup.POST("/password/forgot", user.ForgotPassword)
+	userGroup.POST("/password/reset", user.ResetPassword)
+	userGroup.POST("/password/change", user.ChangePassword)
+	userGroup.POST("/email/code/send", user.SendChangeEmailCode)
+	userGroup.POST("/email/change", user.ChangeEmail)
+	userGroup.POST("/verify_email", user.VerifyEmail)
+	userGroup.POST("/verify_email/send", user.SendVerifyEmail)
+	userGroup.POST("/me/code/send", user.SendDeleteMeCode)
+	userGroup.DELETE("/me", user.DeleteMe)
 }
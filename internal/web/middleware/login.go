@@ -10,22 +10,27 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	jwt "github.com/golang-jwt/jwt/v5"
-	"github.com/gz4z2b/go-webook/conf"
-	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
 )
 
 type LoginMiddlewareBuilder struct {
-	paths []string
+	paths  []string
+	jwtHdl ijwt.Handler
+	// 缺 Uid 或 Ssid 的旧token在这个时间之前照常放行
+	legacyDeadline time.Time
+	// 查不了会话有没有作废时放行，默认不放
+	sessionFailOpen bool
 }
 
-func NewLoginMiddlewareBuilder() *LoginMiddlewareBuilder {
-	return &LoginMiddlewareBuilder{}
+func NewLoginMiddlewareBuilder(jwtHdl ijwt.Handler) *LoginMiddlewareBuilder {
+	return &LoginMiddlewareBuilder{
+		jwtHdl: jwtHdl,
+	}
 }
 
 func (loginMiddlewareBuilder *LoginMiddlewareBuilder) IgnorePath(path string) *LoginMiddlewareBuilder {
@@ -33,6 +38,17 @@ func (loginMiddlewareBuilder *LoginMiddlewareBuilder) IgnorePath(path string) *L
 	return loginMiddlewareBuilder
 }
 
+func (loginMiddlewareBuilder *LoginMiddlewareBuilder) AllowLegacyTokenUntil(deadline time.Time) *LoginMiddlewareBuilder {
+	loginMiddlewareBuilder.legacyDeadline = deadline
+	return loginMiddlewareBuilder
+}
+
+// SessionFailOpen 会话存储出问题时照常放行，代价是这段时间里退出登录、改过密码的token又能用了
+func (loginMiddlewareBuilder *LoginMiddlewareBuilder) SessionFailOpen(failOpen bool) *LoginMiddlewareBuilder {
+	loginMiddlewareBuilder.sessionFailOpen = failOpen
+	return loginMiddlewareBuilder
+}
+
 func (loginMiddlewareBuilder *LoginMiddlewareBuilder) Build() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		for _, value := range loginMiddlewareBuilder.paths {
@@ -41,56 +57,44 @@ func (loginMiddlewareBuilder *LoginMiddlewareBuilder) Build() gin.HandlerFunc {
 			}
 		}
 
-		tokenHeader := ctx.GetHeader("Authorization")
-		if tokenHeader == "" {
-			ctx.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
-		segs := strings.Split(tokenHeader, " ")
-		if len(segs) != 2 {
-			ctx.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
-		tokenStr := segs[1]
-		claims := &domain.UserClaims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
-			return []byte(conf.Keys.AuthorizationKey), nil
-		})
+		claims, err := loginMiddlewareBuilder.jwtHdl.ParseAccessToken(ctx, loginMiddlewareBuilder.jwtHdl.ExtractToken(ctx))
 		if err != nil {
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
-		if token == nil || !token.Valid || len(claims.Email) == 0 {
-			ctx.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
 
-		if ctx.Request.UserAgent() != claims.UserAgent {
+		// 手机号登录的用户没有邮箱，两者至少要有一个
+		if claims.Uid == 0 && claims.Email == "" {
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
-		if claims.Email == "" {
+		// 只带邮箱、没有会话的是改造前签发的旧token，迁移期过了就要求重新登录
+		legacy := claims.Uid == 0 || claims.Ssid == ""
+		if legacy && !time.Now().Before(loginMiddlewareBuilder.legacyDeadline) {
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
-		if claims.ExpiresAt.Sub(time.Now()) < time.Minute*30 {
-			userClaims := domain.UserClaims{
-				RegisteredClaims: jwt.RegisteredClaims{
-					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
-				},
-				Email:     claims.Email,
-				UserAgent: ctx.Request.UserAgent(),
+		if claims.Uid != 0 || claims.Ssid != "" {
+			err = loginMiddlewareBuilder.jwtHdl.CheckSession(ctx, claims.Uid, claims.Ssid, claims.IssuedTime())
+			if err == ijwt.ErrSessionRevoked {
+				ctx.AbortWithStatus(http.StatusUnauthorized)
+				return
 			}
-			token := jwt.NewWithClaims(jwt.SigningMethodHS512, userClaims)
-			tokenStr, err := token.SignedString([]byte(conf.Keys.AuthorizationKey))
 			if err != nil {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
+				log.Printf("检查登录会话失败: %v", err)
+				// 不知道有没有作废就当作废了，返回 503 让前端重试，不要当成登录失效把人踢出去
+				if !loginMiddlewareBuilder.sessionFailOpen {
+					ctx.AbortWithStatus(http.StatusServiceUnavailable)
+					return
+				}
 			}
-			ctx.Header("x-jwt-token", tokenStr)
 		}
-		ctx.Set("user_email", claims.Email)
+
+		// 短token过期后由前端拿长token调 /users/refresh_token 换新的，这里不再续期
+		// 处理函数用 web.MustUser 取登录态
+		ctx.Set(ijwt.ClaimsKey, claims)
 
 		// session := sessions.Default(ctx)
 		// session.Options(sessions.Options{
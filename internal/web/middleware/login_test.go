@@ -0,0 +1,184 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 21:35:50
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/middleware/login_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coocood/freecache"
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+	cachemocks "github.com/gz4z2b/go-webook/internal/repository/cache/mocks"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// 测试用的签名key，和配置文件里的无关
+const (
+	testAccessKey  = "webook-test-access-key-0123456789abcdef0123456789abcdef"
+	testRefreshKey = "webook-test-refresh-key-0123456789abcdef0123456789abcdef"
+)
+
+func TestLoginMiddlewareBuilder_Build(t *testing.T) {
+	const userAgent = "webook-test"
+	// 不带 kid，按旧token的key验证
+	sign := func(claims domain.UserClaims) string {
+		claims.UserAgent = userAgent
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+		tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte(testAccessKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenStr
+	}
+	tests := []struct {
+		name           string
+		path           string
+		token          string
+		legacyDeadline time.Time
+		revoke         string
+		wantCode       int
+	}{
+		{
+			name:     "正常",
+			path:     "/users/profile",
+			token:    sign(domain.UserClaims{Uid: 1, Ssid: "ssid-1"}),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "忽略的路径",
+			path:     "/users/login",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "没带token",
+			path:     "/users/profile",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "会话已作废",
+			path:     "/users/profile",
+			token:    sign(domain.UserClaims{Uid: 1, Ssid: "ssid-1"}),
+			revoke:   "ssid-1",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "迁移期内的旧token",
+			path:           "/users/profile",
+			token:          sign(domain.UserClaims{Email: "gz4z2b@163.com"}),
+			legacyDeadline: time.Now().Add(time.Hour),
+			wantCode:       http.StatusOK,
+		},
+		{
+			name:           "迁移期过后的旧token",
+			path:           "/users/profile",
+			token:          sign(domain.UserClaims{Email: "gz4z2b@163.com"}),
+			legacyDeadline: time.Now().Add(-time.Hour),
+			wantCode:       http.StatusUnauthorized,
+		},
+		{
+			name:           "没有会话的token",
+			path:           "/users/profile",
+			token:          sign(domain.UserClaims{Uid: 1}),
+			legacyDeadline: time.Now().Add(-time.Hour),
+			wantCode:       http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessKeys, err := ijwt.NewKeySetFromConf([]conf.JwtKeyConf{{Id: "access-hs512-v1", Alg: "HS512", Key: testAccessKey, Legacy: true}})
+			assert.NoError(t, err)
+			refreshKeys, err := ijwt.NewKeySetFromConf([]conf.JwtKeyConf{{Id: "refresh-hs512-v1", Alg: "HS512", Key: testRefreshKey, Legacy: true}})
+			assert.NoError(t, err)
+			jwtHdl := ijwt.NewJWTHandler(cache.NewSessionMemoryCache(freecache.NewCache(1024*1024)), accessKeys, refreshKeys)
+			if tt.revoke != "" {
+				ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+				assert.NoError(t, jwtHdl.RevokeSession(ctx, tt.revoke))
+			}
+			server := gin.New()
+			server.Use(NewLoginMiddlewareBuilder(jwtHdl).
+				IgnorePath("/users/login").
+				AllowLegacyTokenUntil(tt.legacyDeadline).
+				Build())
+			server.Any("/*path", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			req.Header.Set("User-Agent", userAgent)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
+
+func TestLoginMiddlewareBuilder_SessionFailOpen(t *testing.T) {
+	const userAgent = "webook-test"
+	claims := domain.UserClaims{Uid: 1, Ssid: "ssid-1", UserAgent: userAgent}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte(testAccessKey))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		failOpen bool
+		wantCode int
+	}{
+		{
+			name:     "默认不放行",
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "配置了照常放行",
+			failOpen: true,
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessions := cachemocks.NewMockSessionCache(ctrl)
+			sessions.EXPECT().IsRevoked(gomock.Any(), "ssid-1").Return(false, errors.New("redis 连不上"))
+			accessKeys, err := ijwt.NewKeySetFromConf([]conf.JwtKeyConf{{Id: "access-hs512-v1", Alg: "HS512", Key: testAccessKey, Legacy: true}})
+			assert.NoError(t, err)
+			refreshKeys, err := ijwt.NewKeySetFromConf([]conf.JwtKeyConf{{Id: "refresh-hs512-v1", Alg: "HS512", Key: testRefreshKey, Legacy: true}})
+			assert.NoError(t, err)
+
+			server := gin.New()
+			server.Use(NewLoginMiddlewareBuilder(ijwt.NewJWTHandler(sessions, accessKeys, refreshKeys)).
+				SessionFailOpen(tt.failOpen).
+				Build())
+			server.Any("/*path", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users/profile", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("User-Agent", userAgent)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 00:12:27
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/middleware/ratelimit_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+	limitmocks "github.com/gz4z2b/go-webook/pkg/ratelimit/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type rateLimitReq struct {
+	path string
+	ip   string
+	uid  uint64
+}
+
+func TestRateLimitMiddlewareBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name           string
+		rules          func(b *RateLimitMiddlewareBuilder)
+		reqs           []rateLimitReq
+		wantCode       int
+		wantRetryAfter string
+	}{
+		{
+			name: "没超",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("", LimitByIP, time.Minute, 2)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/login", ip: "192.0.2.1"},
+				{path: "/users/login", ip: "192.0.2.1"},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "按IP超了",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("", LimitByIP, time.Millisecond*1500, 2)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/login", ip: "192.0.2.1"},
+				{path: "/hello", ip: "192.0.2.1"},
+				{path: "/users/signup", ip: "192.0.2.1"},
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "2",
+		},
+		{
+			name: "不同IP分开算",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("", LimitByIP, time.Minute, 1)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/login", ip: "192.0.2.1"},
+				{path: "/users/login", ip: "192.0.2.2"},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "路由前缀不匹配的不算",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("/users/login", LimitByIP, time.Minute, 1)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/login", ip: "192.0.2.1"},
+				{path: "/users/login_sms", ip: "192.0.2.1"},
+				{path: "/users/signup", ip: "192.0.2.1"},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "路由前缀匹配子路径",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("/users/login_sms/", LimitByIP, time.Minute, 1)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/login_sms", ip: "192.0.2.1"},
+				{path: "/users/login_sms/code/send", ip: "192.0.2.1"},
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "60",
+		},
+		{
+			name: "按用户计数换IP也没用",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("/users", LimitByUser, time.Minute, 1)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/profile", ip: "192.0.2.1", uid: 1},
+				{path: "/users/profile", ip: "192.0.2.2", uid: 1},
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "60",
+		},
+		{
+			name: "按用户计数没登录的按IP算",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("/users", LimitByUser, time.Minute, 1)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/profile", ip: "192.0.2.1", uid: 1},
+				{path: "/users/login", ip: "192.0.2.1"},
+				{path: "/users/login", ip: "192.0.2.2"},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "按路由计数所有人共用",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("/users/signup", LimitByRoute, time.Minute, 1)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/signup", ip: "192.0.2.1"},
+				{path: "/users/signup", ip: "192.0.2.2"},
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "60",
+		},
+		{
+			name: "多条规则有一条超了就拒绝",
+			rules: func(b *RateLimitMiddlewareBuilder) {
+				b.Rule("", LimitByIP, time.Minute, 100).
+					Rule("/users/signup", LimitByIP, time.Second*30, 1)
+			},
+			reqs: []rateLimitReq{
+				{path: "/users/signup", ip: "192.0.2.1"},
+				{path: "/users/signup", ip: "192.0.2.1"},
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "30",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewRateLimitMiddlewareBuilder(ratelimit.NewLocalSlidingWindowLimiter)
+			tt.rules(builder)
+			server := newRateLimitServer(builder.Build())
+
+			var resp *httptest.ResponseRecorder
+			for _, r := range tt.reqs {
+				resp = serveRateLimitReq(server, r)
+			}
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantRetryAfter, resp.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestRateLimitMiddlewareBuilder_LimiterError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 限流器出错放行
+	limiter := limitmocks.NewMockLimiter(ctrl)
+	limiter.EXPECT().Limit(gomock.Any(), "webook:ratelimit::ip:192.0.2.1").Return(false, errors.New("redis 连不上"))
+	builder := NewRateLimitMiddlewareBuilder(func(window time.Duration, threshold int) ratelimit.Limiter {
+		return limiter
+	}).Rule("", LimitByIP, time.Minute, 1)
+
+	resp := serveRateLimitReq(newRateLimitServer(builder.Build()), rateLimitReq{path: "/users/login", ip: "192.0.2.1"})
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func newRateLimitServer(limitHdl gin.HandlerFunc) *gin.Engine {
+	server := gin.New()
+	// 模拟登录中间件放进去的登录态
+	server.Use(func(ctx *gin.Context) {
+		if uid, err := strconv.ParseUint(ctx.GetHeader("X-Test-Uid"), 10, 64); err == nil {
+			ctx.Set(ijwt.ClaimsKey, &domain.UserClaims{Uid: uid})
+		}
+	}, limitHdl)
+	server.NoRoute(func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	return server
+}
+
+func serveRateLimitReq(server *gin.Engine, r rateLimitReq) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, r.path, nil)
+	req.RemoteAddr = r.ip + ":12345"
+	if r.uid != 0 {
+		req.Header.Set("X-Test-Uid", strconv.FormatUint(r.uid, 10))
+	}
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	return resp
+}
@@ -0,0 +1,169 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 14:05:12
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/result.go
+ * @Description: 统一响应格式和错误码
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gz4z2b/go-webook/internal/service"
+	"github.com/gz4z2b/go-webook/internal/web/i18n"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
+	"github.com/gz4z2b/go-webook/internal/web/validate"
+)
+
+// Result 所有接口统一的响应格式，Code 为 0 表示成功
+type Result struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data any    `json:"data,omitempty"`
+}
+
+// bizError 业务错误，前端按 code 判断，不要依赖 msg 的文案，文案按错误码在 i18n 里配
+type bizError struct {
+	status int
+	code   int
+}
+
+func (e bizError) Error() string {
+	return i18n.T(nil, e.key())
+}
+
+func (e bizError) key() string {
+	return strconv.Itoa(e.code)
+}
+
+// 错误码 = HTTP状态码 * 1000 + 序号，已经给出去的码不要改
+// 400002~400009 是以前逐个字段的校验错误，已经统一成 400011，不要复用
+var (
+	errBadRequest         = bizError{status: http.StatusBadRequest, code: 400001}
+	errSMSCodeInvalid     = bizError{status: http.StatusBadRequest, code: 400010}
+	errInvalidParams      = bizError{status: http.StatusBadRequest, code: 400011}
+	errResetTokenInvalid  = bizError{status: http.StatusBadRequest, code: 400012}
+	errOldPasswordInvalid = bizError{status: http.StatusBadRequest, code: 400013}
+	errVerifyTokenInvalid = bizError{status: http.StatusBadRequest, code: 400014}
+	errPasswordIncorrect  = bizError{status: http.StatusBadRequest, code: 400015}
+	errPasswordNotSet     = bizError{status: http.StatusBadRequest, code: 400016}
+	errPasswordRequired   = bizError{status: http.StatusBadRequest, code: 400017}
+	errEmailCodeInvalid   = bizError{status: http.StatusBadRequest, code: 400018}
+	errUnauthorized       = bizError{status: http.StatusUnauthorized, code: 401001}
+	errLoginFailed        = bizError{status: http.StatusUnauthorized, code: 401002}
+	errTokenInvalid       = bizError{status: http.StatusUnauthorized, code: 401003}
+	errUserPending        = bizError{status: http.StatusForbidden, code: 403001}
+	errUserDisabled       = bizError{status: http.StatusForbidden, code: 403002}
+	errSignupClosed       = bizError{status: http.StatusForbidden, code: 403003}
+	errUserNotFound       = bizError{status: http.StatusNotFound, code: 404001}
+	errEmailConflict      = bizError{status: http.StatusConflict, code: 409001}
+	errCodeSendTooMany    = bizError{status: http.StatusTooManyRequests, code: 429001}
+	errCodeVerifyTooMany  = bizError{status: http.StatusTooManyRequests, code: 429002}
+	errLoginTooFrequent   = bizError{status: http.StatusTooManyRequests, code: 429003}
+	errLoginLocked        = bizError{status: http.StatusTooManyRequests, code: 429004}
+	errInternal           = bizError{status: http.StatusInternalServerError, code: 500001}
+	errNotReady           = bizError{status: http.StatusServiceUnavailable, code: 503001}
+)
+
+// errCodes 下层返回的错误到业务错误的映射，没有列出来的一律按系统错误处理
+var errCodes = map[error]bizError{
+	errNotLogin:                       errUnauthorized,
+	service.ErrEmailConflict:          errEmailConflict,
+	service.ErrPasswordInvalid:        errLoginFailed,
+	service.ErrUserNotFound:           errUserNotFound,
+	service.ErrCodeSendTooMany:        errCodeSendTooMany,
+	service.ErrEmailSendTooMany:       errCodeSendTooMany,
+	service.ErrCodeVerifyTooManyTimes: errCodeVerifyTooMany,
+	service.ErrResetTokenInvalid:      errResetTokenInvalid,
+	service.ErrVerifyTokenInvalid:     errVerifyTokenInvalid,
+	service.ErrUserPending:            errUserPending,
+	service.ErrUserDisabled:           errUserDisabled,
+	service.ErrLoginTooFrequent:       errLoginTooFrequent,
+	service.ErrLoginLocked:            errLoginLocked,
+	service.ErrPasswordNotSet:         errPasswordNotSet,
+	service.ErrPasswordRequired:       errPasswordRequired,
+	ijwt.ErrTokenInvalid:              errTokenInvalid,
+	ijwt.ErrSessionRevoked:            errTokenInvalid,
+}
+
+// 成功提示的文案key
+const (
+	msgOK              = "ok"
+	msgSignupOK        = "signup_ok"
+	msgLoginOK         = "login_ok"
+	msgCodeSent        = "code_sent"
+	msgProfileUpdated  = "profile_updated"
+	msgTokenRefreshed  = "token_refreshed"
+	msgLogoutOK        = "logout_ok"
+	msgResetEmailSent  = "reset_email_sent"
+	msgPasswordReset   = "password_reset"
+	msgPasswordChanged = "password_changed"
+	msgEmailChanged    = "email_changed"
+	msgEmailVerified   = "email_verified"
+	msgVerifyEmailSent = "verify_email_sent"
+	msgAccountDeleted  = "account_deleted"
+)
+
+/**
+ * @description: 成功响应
+ * @param {*gin.Context} ctx
+ * @param {string} msgKey 成功提示的文案key
+ * @param {any} data 没有数据传 nil
+ * @return {*}
+ */
+func writeOK(ctx *gin.Context, msgKey string, data any) {
+	ctx.JSON(http.StatusOK, Result{
+		Msg:  i18n.T(locales(ctx), msgKey),
+		Data: data,
+	})
+}
+
+/**
+ * @description: 失败响应，按错误映射HTTP状态码和错误码，内部错误只记日志不透给前端
+ * @param {*gin.Context} ctx
+ * @param {error} err
+ * @return {*}
+ */
+func writeError(ctx *gin.Context, err error) {
+	be, ok := err.(bizError)
+	if !ok {
+		be, ok = errCodes[err]
+	}
+	if !ok {
+		log.Printf("%s %s 系统错误: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+		be = errInternal
+	}
+	ctx.AbortWithStatusJSON(be.status, Result{
+		Code: be.code,
+		Msg:  i18n.T(locales(ctx), be.key()),
+	})
+}
+
+/**
+ * @description: 参数校验失败，把所有字段的错误一起返回
+ * @param {*gin.Context} ctx
+ * @param {[]validate.FieldError} fieldErrs
+ * @return {*}
+ */
+func writeInvalid(ctx *gin.Context, fieldErrs []validate.FieldError) {
+	ctx.AbortWithStatusJSON(errInvalidParams.status, Result{
+		Code: errInvalidParams.code,
+		Msg:  i18n.T(locales(ctx), errInvalidParams.key()),
+		Data: fieldErrs,
+	})
+}
+
+/**
+ * @description: 当前请求协商出来的语言，按优先级排列
+ * @param {*gin.Context} ctx
+ * @return {[]string}
+ */
+func locales(ctx *gin.Context) []string {
+	return i18n.Negotiate(ctx.GetHeader("Accept-Language"))
+}
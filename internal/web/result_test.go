@@ -0,0 +1,80 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 14:40:26
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/result_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gz4z2b/go-webook/internal/service"
+	"github.com/gz4z2b/go-webook/internal/web/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		acceptLanguage string
+		wantCode       int
+		wantBody       string
+	}{
+		{
+			name:     "业务错误",
+			err:      errSMSCodeInvalid,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400010,"msg":"验证码有误"}`,
+		},
+		{
+			name:     "下层错误按映射转换",
+			err:      service.ErrEmailConflict,
+			wantCode: http.StatusConflict,
+			wantBody: `{"code":409001,"msg":"邮箱已被注册"}`,
+		},
+		{
+			name:     "没有映射的错误不透给前端",
+			err:      errors.New("dial tcp 127.0.0.1:13316: connect: connection refused"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":500001,"msg":"系统错误"}`,
+		},
+		{
+			name:           "英文",
+			err:            service.ErrEmailConflict,
+			acceptLanguage: "en-US,en;q=0.9",
+			wantCode:       http.StatusConflict,
+			wantBody:       `{"code":409001,"msg":"This email is already registered"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(resp)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/users/signup", nil)
+			ctx.Request.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			writeError(ctx, tt.err)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+			assert.True(t, ctx.IsAborted())
+		})
+	}
+}
+
+func TestErrCodesHaveMessages(t *testing.T) {
+	for _, be := range errCodes {
+		for _, locale := range []string{"zh-CN", "en"} {
+			assert.NotEqual(t, be.key(), i18n.T([]string{locale}, be.key()), "%s 缺少 %d 的文案", locale, be.code)
+		}
+	}
+}
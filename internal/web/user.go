@@ -10,107 +10,123 @@
 package web
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
-	regexp "github.com/dlclark/regexp2"
 	"github.com/gin-gonic/gin"
-	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/gz4z2b/go-webook/conf"
 	"github.com/gz4z2b/go-webook/internal/domain"
 	"github.com/gz4z2b/go-webook/internal/service"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
+	"github.com/gz4z2b/go-webook/internal/web/validate"
 )
 
+var errNotLogin = errors.New("未登录")
+
+// revokeRetryTimes 作废登录态最多试几次
+const revokeRetryTimes = 3
+
 // UserHandler 我准备在上面定义跟用户有关的路由
 type UserHandler struct {
-	svc                       service.UserService
-	emailExpersion            *regexp.Regexp
-	passwordExpersion         *regexp.Regexp
-	birthdayRegexExpersion    *regexp.Regexp
-	nickNameRegexExpersion    *regexp.Regexp
-	descriptionRegexExpersion *regexp.Regexp
+	svc         service.UserService
+	codeSvc     service.CodeService
+	passwordSvc service.PasswordService
+	verifySvc   service.EmailVerifyService
+	attemptSvc  service.LoginAttemptService
+	jwtHdl      ijwt.Handler
+	validator   *validate.Validator
 }
 
 // UserHandler构造方法
-func NewUserHandler(svc service.UserService) *UserHandler {
-	const (
-		passwordRegexpPattern   = `^(?=.*[a-z])(?=.*[A-Z])(?=.*\d)(?=.*[@$!%*?&_])[A-Za-z\d@$!%*?&_]{8,72}$`
-		emailRegextPattern      = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
-		nickNameRegexPattern    = `^[\u4E00-\u9FFFa-zA-Z0-9!@#$%^&*()_+=\-[\]{}|\\:;"'<>,.?/~]{1,64}$`
-		birthdayRegexPattern    = `^\d{4}-\d{2}-\d{2}$`
-		descriptionRegexPattern = `^[\u4E00-\u9FFFa-zA-Z0-9!@#$%^&*()_+=\-[\]{}|\\:;。，？！……「」【】；'<>,.?/~]{1,10240}$`
-	)
-	passwordExpersion := regexp.MustCompile(passwordRegexpPattern, regexp.None)
-	emailExpersion := regexp.MustCompile(emailRegextPattern, regexp.None)
-	nickNameRegexExpersion := regexp.MustCompile(nickNameRegexPattern, regexp.None)
-	birthdayRegexExpersion := regexp.MustCompile(birthdayRegexPattern, regexp.None)
-	descriptionRegexExpersion := regexp.MustCompile(descriptionRegexPattern, regexp.None)
-
+func NewUserHandler(svc service.UserService, codeSvc service.CodeService, passwordSvc service.PasswordService,
+	verifySvc service.EmailVerifyService, attemptSvc service.LoginAttemptService, jwtHdl ijwt.Handler) *UserHandler {
 	return &UserHandler{
-		emailExpersion:            emailExpersion,
-		passwordExpersion:         passwordExpersion,
-		nickNameRegexExpersion:    nickNameRegexExpersion,
-		birthdayRegexExpersion:    birthdayRegexExpersion,
-		descriptionRegexExpersion: descriptionRegexExpersion,
-		svc:                       svc,
+		svc:         svc,
+		codeSvc:     codeSvc,
+		passwordSvc: passwordSvc,
+		verifySvc:   verifySvc,
+		attemptSvc:  attemptSvc,
+		jwtHdl:      jwtHdl,
+		validator:   validate.New(),
 	}
 }
 
 // Signup 注册
 func (u *UserHandler) Signup(ctx *gin.Context) {
 	// 注册
+	if conf.Current().Features.DisableSignup {
+		writeError(ctx, errSignupClosed)
+		return
+	}
 	type signupReq struct {
-		Email           string `json:"email"`
-		Password        string `json:"password"`
-		ConfirmPassword string `json:"confirmPassword"`
+		Email           string `json:"email" validate:"required,email"`
+		Password        string `json:"password" validate:"required,password"`
+		ConfirmPassword string `json:"confirmPassword" validate:"eqfield=Password"`
 	}
 	var req signupReq
-
-	if err := ctx.BindJSON(&req); err != nil {
-		ctx.String(http.StatusOK, "数据格式错误")
+	if !u.bind(ctx, &req) {
 		return
 	}
 
-	if req.Password != req.ConfirmPassword {
-		ctx.String(http.StatusOK, "两次输入的密码不一致")
+	// 用户存储
+	err := u.svc.SignUp(ctx, &domain.User{
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
 
-	ok, err := u.passwordExpersion.MatchString(req.Password)
+	err = u.verifySvc.SendVerifyEmail(ctx, req.Email)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
+		// 账号已经建好了，邮件没发出去可以在登录页重发
+		log.Printf("发送 %s 的验证邮件失败: %v", req.Email, err)
 	}
-	if !ok {
-		ctx.String(http.StatusOK, "密码复杂度不够")
+
+	writeOK(ctx, msgSignupOK, nil)
+}
+
+// VerifyEmail 用注册邮件里的链接验证邮箱
+func (u *UserHandler) VerifyEmail(ctx *gin.Context) {
+	type verifyReq struct {
+		Token string `json:"token" validate:"required"`
+	}
+	var req verifyReq
+	if !u.bind(ctx, &req) {
 		return
 	}
 
-	ok, err = u.emailExpersion.MatchString(req.Email)
+	err := u.verifySvc.VerifyEmail(ctx, req.Token)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		writeError(ctx, err)
 		return
 	}
-	if !ok {
-		ctx.String(http.StatusOK, "邮箱格式不正确")
+
+	writeOK(ctx, msgEmailVerified, nil)
+}
+
+// SendVerifyEmail 重发验证邮件，邮箱有没有注册、验没验证过都返回一样的结果
+func (u *UserHandler) SendVerifyEmail(ctx *gin.Context) {
+	type sendReq struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	var req sendReq
+	if !u.bind(ctx, &req) {
 		return
 	}
 
-	// 用户存储
-	err = u.svc.SignUp(ctx, &domain.User{
-		Email:    req.Email,
-		Password: req.Password,
-	})
+	err := u.verifySvc.SendVerifyEmail(ctx, req.Email)
 	if err != nil {
-		if err == service.ErrEmailConflict {
-			ctx.String(http.StatusOK, "邮箱冲突啦~~")
-			return
-		}
-		ctx.String(http.StatusOK, err.Error())
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.String(http.StatusOK, "success")
+	writeOK(ctx, msgVerifyEmailSent, nil)
 }
 
 // Login 登录
@@ -118,165 +134,548 @@ func (u *UserHandler) Login(ctx *gin.Context) {
 
 	// 登录
 	type loginReq struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required"`
+		Password string `json:"password" validate:"required"`
 	}
 	var req loginReq
-	err := ctx.BindJSON(&req)
+	if !u.bind(ctx, &req) {
+		return
+	}
+
+	// ClientIP 只认 server.trusted_proxies 转过来的 X-Forwarded-For，客户端伪造的换不了按IP锁定的名额
+	wait, err := u.attemptSvc.Check(ctx, req.Email, ctx.ClientIP())
 	if err != nil {
-		ctx.String(http.StatusOK, "输入数据格式错误")
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(ctx, err)
 		return
 	}
 
-	_, err = u.svc.Login(ctx, &domain.User{
+	user, err := u.svc.Login(ctx, &domain.User{
 		Email:    req.Email,
 		Password: req.Password,
 	})
 	if err != nil {
 		if err == service.ErrUserNotFound || err == service.ErrPasswordInvalid {
-			ctx.String(http.StatusOK, "邮箱或密码错误")
-			return
+			if err := u.attemptSvc.Fail(ctx, req.Email, ctx.ClientIP()); err != nil {
+				log.Printf("记录登录失败出错: %v", err)
+			}
 		}
-		ctx.String(http.StatusOK, "系统错误")
+		// 不告诉前端是邮箱不存在还是密码错了，免得被用来探测注册过的邮箱
+		if err == service.ErrUserNotFound {
+			err = errLoginFailed
+		}
+		writeError(ctx, err)
 		return
 	}
-
-	userClaims := domain.UserClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
-		},
-		Email:     req.Email,
-		UserAgent: ctx.Request.UserAgent(),
+	if err := u.attemptSvc.Succeed(ctx, req.Email); err != nil {
+		log.Printf("清除登录失败记录出错: %v", err)
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, userClaims)
-	tokenStr, err := token.SignedString([]byte(conf.Keys.AuthorizationKey))
+
+	err = u.jwtHdl.SetLoginToken(ctx, user)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+		writeError(ctx, err)
+		return
 	}
-	ctx.Header("x-jwt-token", tokenStr)
 
-	ctx.String(http.StatusOK, "登录成功")
+	writeOK(ctx, msgLoginOK, nil)
 }
 
-// Edit 修改
-func (u *UserHandler) Edit(ctx *gin.Context) {
-	// 修改
-	type editReq struct {
-		NickName    string `json:"nick_name"`
-		BirthDay    string `json:"birth_day"`
-		Description string `json:"description"`
+// SendLoginSMSCode 发送登录验证码
+func (u *UserHandler) SendLoginSMSCode(ctx *gin.Context) {
+	type sendReq struct {
+		Phone string `json:"phone" validate:"required,mobile"`
 	}
-	var req editReq
-	err := ctx.BindJSON(&req)
+	var req sendReq
+	if !u.bind(ctx, &req) {
+		return
+	}
+
+	err := u.codeSvc.Send(ctx, service.CodeBizLogin, req.Phone)
 	if err != nil {
-		ctx.String(http.StatusOK, "参数错误")
+		writeError(ctx, err)
+		return
+	}
+
+	writeOK(ctx, msgCodeSent, nil)
+}
+
+// LoginSMS 短信验证码登录，首次登录自动注册
+func (u *UserHandler) LoginSMS(ctx *gin.Context) {
+	type loginSMSReq struct {
+		Phone string `json:"phone" validate:"required,mobile"`
+		Code  string `json:"code" validate:"required"`
+	}
+	var req loginSMSReq
+	if !u.bind(ctx, &req) {
 		return
 	}
 
-	ok, err := u.nickNameRegexExpersion.MatchString(req.NickName)
+	ok, err := u.codeSvc.Verify(ctx, service.CodeBizLogin, req.Phone, req.Code)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		writeError(ctx, err)
 		return
 	}
 	if !ok {
-		ctx.String(http.StatusOK, "昵称含非法字符")
+		writeError(ctx, errSMSCodeInvalid)
 		return
 	}
 
-	ok, err = u.birthdayRegexExpersion.MatchString(req.BirthDay)
+	user, err := u.svc.FindOrCreateByPhone(ctx, req.Phone)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		writeError(ctx, err)
 		return
 	}
-	if !ok {
-		ctx.String(http.StatusOK, "生日格式不对")
+
+	err = u.jwtHdl.SetLoginToken(ctx, user)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	writeOK(ctx, msgLoginOK, nil)
+}
+
+// ForgotPassword 发重置密码邮件，邮箱有没有注册都返回一样的结果
+func (u *UserHandler) ForgotPassword(ctx *gin.Context) {
+	type forgotReq struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	var req forgotReq
+	if !u.bind(ctx, &req) {
+		return
+	}
+
+	err := u.passwordSvc.SendResetEmail(ctx, req.Email)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	writeOK(ctx, msgResetEmailSent, nil)
+}
+
+// ResetPassword 用邮件里的链接重置密码，成功后所有设备都要重新登录
+func (u *UserHandler) ResetPassword(ctx *gin.Context) {
+	type resetReq struct {
+		Token           string `json:"token" validate:"required"`
+		Password        string `json:"password" validate:"required,password"`
+		ConfirmPassword string `json:"confirmPassword" validate:"eqfield=Password"`
+	}
+	var req resetReq
+	if !u.bind(ctx, &req) {
+		return
+	}
+
+	uid, err := u.passwordSvc.ResetPassword(ctx, req.Token, req.Password)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = u.revokeUserSessions(ctx, uid)
+	if err != nil {
+		// 找回密码是账号被盗后的补救，别人的登录态没踢掉就不能报成功
+		writeError(ctx, err)
+		return
+	}
+
+	writeOK(ctx, msgPasswordReset, nil)
+}
+
+// ChangePassword 登录后修改密码，成功后所有设备都要重新登录
+func (u *UserHandler) ChangePassword(ctx *gin.Context) {
+	type changeReq struct {
+		OldPassword     string `json:"oldPassword" validate:"required"`
+		Password        string `json:"password" validate:"required,password,nefield=OldPassword"`
+		ConfirmPassword string `json:"confirmPassword" validate:"eqfield=Password"`
+	}
+	var req changeReq
+	if !u.bind(ctx, &req) {
+		return
+	}
+
+	uid, err := u.loginUid(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = u.svc.ChangePassword(ctx, uid, req.OldPassword, req.Password)
+	if err != nil {
+		// 这里密码不对是原密码填错了，不是登录失败
+		if err == service.ErrPasswordInvalid {
+			err = errOldPasswordInvalid
+		}
+		writeError(ctx, err)
+		return
+	}
+
+	err = u.revokeUserSessions(ctx, uid)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	writeOK(ctx, msgPasswordChanged, nil)
+}
+
+// SendChangeEmailCode 给新邮箱发验证码，邮箱有没有被注册都一样发
+func (u *UserHandler) SendChangeEmailCode(ctx *gin.Context) {
+	type sendReq struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	var req sendReq
+	if !u.bind(ctx, &req) {
+		return
+	}
+
+	// 注册过的邮箱也照常发，不然登录用户能拿这个接口探测哪些邮箱注册过，冲突等 ChangeEmail 时唯一索引报
+	err := u.codeSvc.SendEmail(ctx, service.CodeBizChangeEmail, req.Email)
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
 
-	ok, err = u.descriptionRegexExpersion.MatchString(req.Description)
+	writeOK(ctx, msgCodeSent, nil)
+}
+
+// ChangeEmail 用新邮箱收到的验证码修改邮箱
+func (u *UserHandler) ChangeEmail(ctx *gin.Context) {
+	type changeReq struct {
+		Email string `json:"email" validate:"required,email"`
+		Code  string `json:"code" validate:"required"`
+	}
+	var req changeReq
+	if !u.bind(ctx, &req) {
+		return
+	}
+
+	uid, err := u.loginUid(ctx)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		writeError(ctx, err)
+		return
+	}
+
+	ok, err := u.codeSvc.Verify(ctx, service.CodeBizChangeEmail, req.Email, req.Code)
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
 	if !ok {
-		ctx.String(http.StatusOK, "简介含非法字符")
+		writeError(ctx, errEmailCodeInvalid)
+		return
+	}
+
+	err = u.svc.ChangeEmail(ctx, uid, req.Email)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	writeOK(ctx, msgEmailChanged, nil)
+}
+
+// SendDeleteMeCode 没设密码的账号注销前，给绑定的手机发验证码
+func (u *UserHandler) SendDeleteMeCode(ctx *gin.Context) {
+	uid, err := u.loginUid(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	phone, err := u.svc.DeleteAccountPhone(ctx, uid)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = u.codeSvc.Send(ctx, service.CodeBizDeleteAccount, phone)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	writeOK(ctx, msgCodeSent, nil)
+}
+
+// DeleteMe 注销当前账号，要再输一次密码，手机号注册没设密码的用短信验证码，成功后所有设备都会退出登录
+func (u *UserHandler) DeleteMe(ctx *gin.Context) {
+	type deleteReq struct {
+		Password string `json:"password" validate:"required_without=Code"`
+		Code     string `json:"code"`
+	}
+	var req deleteReq
+	if !u.bind(ctx, &req) {
+		return
+	}
+
+	uid, err := u.loginUid(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if req.Password != "" {
+		err = u.svc.DeleteAccount(ctx, uid, req.Password)
+	} else {
+		err = u.deleteMeByCode(ctx, uid, req.Code)
+	}
+	if err != nil {
+		if err == service.ErrPasswordInvalid {
+			err = errPasswordIncorrect
+		}
+		writeError(ctx, err)
 		return
 	}
 
-	email, exist := ctx.Get("user_email")
-	if !exist {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
+	err = u.revokeUserSessions(ctx, uid)
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
-	emailStr, ok := email.(string)
+
+	writeOK(ctx, msgAccountDeleted, nil)
+}
+
+/**
+ * @description: 用手机验证码注销没设密码的账号
+ * @param {*gin.Context} ctx
+ * @param {uint64} uid
+ * @param {string} code
+ * @return {error}
+ */
+func (u *UserHandler) deleteMeByCode(ctx *gin.Context, uid uint64, code string) error {
+	phone, err := u.svc.DeleteAccountPhone(ctx, uid)
+	if err != nil {
+		return err
+	}
+	ok, err := u.codeSvc.Verify(ctx, service.CodeBizDeleteAccount, phone, code)
+	if err != nil {
+		return err
+	}
 	if !ok {
-		ctx.String(http.StatusOK, "登录态初始化错误")
+		return errSMSCodeInvalid
+	}
+	return u.svc.DeleteAccountByCode(ctx, uid)
+}
+
+// Edit 修改
+func (u *UserHandler) Edit(ctx *gin.Context) {
+	// 修改
+	type editReq struct {
+		NickName    string `json:"nick_name" validate:"required,nickname"`
+		BirthDay    string `json:"birth_day" validate:"required,date"`
+		Description string `json:"description" validate:"required,description"`
+		// public 或 private，不传默认仅自己可见
+		BirthdayVisibility string `json:"birthday_visibility" validate:"omitempty,oneof=public private"`
+		EmailVisibility    string `json:"email_visibility" validate:"omitempty,oneof=public private"`
+	}
+	var req editReq
+	if !u.bind(ctx, &req) {
 		return
 	}
-	user, err := u.svc.FindByEmail(ctx, emailStr)
+
+	uid, err := u.loginUid(ctx)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
+		writeError(ctx, err)
 		return
 	}
 
 	birthDay, err := time.ParseInLocation("2006-01-02", req.BirthDay, time.Local)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		writeError(ctx, err)
 		return
 	}
 
-	_, err = u.svc.AddProfile(ctx, user, &domain.Profile{
-		NickName:    req.NickName,
-		BirthDay:    birthDay.UnixMilli(),
-		Description: req.Description,
+	_, err = u.svc.AddProfile(ctx, &domain.User{Id: uid}, &domain.Profile{
+		NickName:           req.NickName,
+		BirthDay:           birthDay.UnixMilli(),
+		Description:        req.Description,
+		BirthdayVisibility: parseVisibility(req.BirthdayVisibility),
+		EmailVisibility:    parseVisibility(req.EmailVisibility),
 	})
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.String(http.StatusOK, "修改成功")
+	writeOK(ctx, msgProfileUpdated, nil)
 
 }
 
-// Profile 个人档案
+// Profile 用户档案，别人只能看到公开的字段
 func (u *UserHandler) Profile(ctx *gin.Context) {
-	// 个人档案
-	email, exist := ctx.Get("user_email")
-	if !exist {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
+	type profileVo struct {
+		Uid         uint64 `json:"uid"`
+		NickName    string `json:"nick_name"`
+		Description string `json:"description"`
+		BirthDay    string `json:"birth_day,omitempty"`
+		Email       string `json:"email,omitempty"`
+		// 只有自己看的时候返回可见性设置
+		BirthdayVisibility string `json:"birthday_visibility,omitempty"`
+		EmailVisibility    string `json:"email_visibility,omitempty"`
+	}
+
+	viewerUid, err := u.loginUid(ctx)
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
-	emailStr, ok := email.(string)
-	if !ok {
-		ctx.String(http.StatusOK, "登录态初始化错误")
+
+	uid := viewerUid
+	// 兼容老前端的 /users/profile
+	if param := ctx.Param("uid"); param != "profile" {
+		uid, err = strconv.ParseUint(param, 10, 64)
+		if err != nil || uid == 0 {
+			writeError(ctx, errBadRequest)
+			return
+		}
+	}
+
+	user, err := u.svc.FindById(ctx, uid)
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
-	user, err := u.svc.FindByEmail(ctx, emailStr)
+
+	// 没填过档案的用户按空档案返回
+	profile, err := u.svc.FindProfileByUser(ctx, user)
+	if err != nil && err != service.ErrProfileNotFound {
+		writeError(ctx, err)
+		return
+	}
+
+	self := viewerUid == uid
+	vo := profileVo{
+		Uid:         uid,
+		NickName:    profile.NickName,
+		Description: profile.Description,
+	}
+	if profile.BirthDay != 0 && profile.BirthdayVisibility.VisibleTo(self) {
+		vo.BirthDay = time.UnixMilli(profile.BirthDay).Format("2006-01-02")
+	}
+	if profile.EmailVisibility.VisibleTo(self) {
+		vo.Email = user.Email
+	}
+	if self {
+		vo.BirthdayVisibility = formatVisibility(profile.BirthdayVisibility)
+		vo.EmailVisibility = formatVisibility(profile.EmailVisibility)
+	}
+
+	writeOK(ctx, msgOK, vo)
+}
+
+/**
+ * @description: 解析前端传的可见性，取值已经校验过，不传按仅自己可见
+ * @param {string} visibility
+ * @return {domain.Visibility}
+ */
+func parseVisibility(visibility string) domain.Visibility {
+	if visibility == "public" {
+		return domain.VisibilityPublic
+	}
+	return domain.VisibilityPrivate
+}
+
+func formatVisibility(visibility domain.Visibility) string {
+	if visibility == domain.VisibilityPublic {
+		return "public"
+	}
+	return "private"
+}
+
+// RefreshToken 用长token换新的短token
+func (u *UserHandler) RefreshToken(ctx *gin.Context) {
+	claims, err := u.jwtHdl.ParseRefreshToken(ctx, u.jwtHdl.ExtractToken(ctx))
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
+		writeError(ctx, err)
 		return
 	}
-	profile, _ := u.svc.FindProfileByUser(ctx, user)
 
-	ctx.JSON(http.StatusOK, profile)
+	err = u.jwtHdl.CheckSession(ctx, claims.Uid, claims.Ssid, claims.IssuedTime())
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = u.jwtHdl.SetJWTToken(ctx, claims.Uid, claims.Email, claims.Ssid)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
 
+	writeOK(ctx, msgTokenRefreshed, nil)
 }
 
-// Logout 登出
+// JWKS 公开短token的验证公钥，按 RFC 7517 的格式返回，不套统一响应格式
+func (u *UserHandler) JWKS(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, u.jwtHdl.JWKS())
+}
+
+// Logout 登出，当前会话的短token和长token都会失效
 func (u *UserHandler) Logout(ctx *gin.Context) {
-	userClaims := domain.UserClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
-		},
-		Email:     "",
-		UserAgent: ctx.Request.UserAgent(),
+	err := u.jwtHdl.ClearToken(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	writeOK(ctx, msgLogoutOK, nil)
+}
+
+/**
+ * @description: 作废用户到现在为止签发的所有token，失败了马上重试，几次都不行返回错误
+ * @param {*gin.Context} ctx
+ * @param {uint64} uid
+ * @return {error}
+ */
+func (u *UserHandler) revokeUserSessions(ctx *gin.Context, uid uint64) error {
+	var err error
+	for i := 0; i < revokeRetryTimes; i++ {
+		err = u.jwtHdl.RevokeUserSessions(ctx, uid)
+		if err == nil {
+			return nil
+		}
+		log.Printf("作废用户 %d 的登录态失败，第 %d 次: %v", uid, i+1, err)
+	}
+	return err
+}
+
+/**
+ * @description: 解析请求体并按 validate 标签校验，失败时已经写好响应
+ * @param {*gin.Context} ctx
+ * @param {any} req 请求结构体指针
+ * @return {bool} 是否通过
+ */
+func (u *UserHandler) bind(ctx *gin.Context, req any) bool {
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		writeError(ctx, errBadRequest)
+		return false
+	}
+	fieldErrs, err := u.validator.Struct(req, locales(ctx)...)
+	if err != nil {
+		writeError(ctx, err)
+		return false
+	}
+	if len(fieldErrs) > 0 {
+		writeInvalid(ctx, fieldErrs)
+		return false
+	}
+	return true
+}
+
+/**
+ * @description: 取当前登录用户id，迁移期内只带邮箱的旧token要按邮箱回查一次
+ * @param {*gin.Context} ctx
+ * @return {uint64, error}
+ */
+func (u *UserHandler) loginUid(ctx *gin.Context) (uint64, error) {
+	user := MustUser(ctx)
+	if user.Uid != 0 {
+		return user.Uid, nil
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, userClaims)
-	tokenStr, err := token.SignedString([]byte(conf.Keys.AuthorizationKey))
+	found, err := u.svc.FindByEmail(ctx, user.Email)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return 0, err
 	}
-	ctx.Header("x-jwt-token", tokenStr)
-	ctx.String(http.StatusOK, "success")
+	return found.Id, nil
 }
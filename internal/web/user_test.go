@@ -17,12 +17,19 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
-	regexp "github.com/dlclark/regexp2"
+	"github.com/coocood/freecache"
 	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/gz4z2b/go-webook/conf"
 	"github.com/gz4z2b/go-webook/internal/domain"
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+	cachemocks "github.com/gz4z2b/go-webook/internal/repository/cache/mocks"
 	"github.com/gz4z2b/go-webook/internal/service"
 	svcmocks "github.com/gz4z2b/go-webook/internal/service/mocks"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"golang.org/x/crypto/bcrypt"
@@ -48,7 +55,7 @@ func TestUserHandler_Signup(t *testing.T) {
 	testCases := []struct {
 		name     string
 		input    []byte
-		mock     func(ctrl *gomock.Controller) service.UserService
+		mock     func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService)
 		wantCode int
 		wantBody string
 	}{
@@ -59,13 +66,32 @@ func TestUserHandler_Signup(t *testing.T) {
 				"password": "19890821Xi_",
 				"confirmPassword": "19890821Xi_"
 			}`),
-			mock: func(ctrl *gomock.Controller) service.UserService {
-				service := svcmocks.NewMockUserService(ctrl)
-				service.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(nil)
-				return service
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(nil)
+				verifySvc := svcmocks.NewMockEmailVerifyService(ctrl)
+				verifySvc.EXPECT().SendVerifyEmail(gomock.Any(), "gz4z2b@163.com").Return(nil)
+				return svc, verifySvc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"注册成功"}`,
+		},
+		{
+			name: "验证邮件没发出去也算注册成功",
+			input: []byte(`{
+				"email": "gz4z2b@163.com",
+				"password": "19890821Xi_",
+				"confirmPassword": "19890821Xi_"
+			}`),
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(nil)
+				verifySvc := svcmocks.NewMockEmailVerifyService(ctrl)
+				verifySvc.EXPECT().SendVerifyEmail(gomock.Any(), "gz4z2b@163.com").Return(errors.New("smtp 发送邮件失败"))
+				return svc, verifySvc
 			},
 			wantCode: http.StatusOK,
-			wantBody: "success",
+			wantBody: `{"code":0,"msg":"注册成功"}`,
 		},
 		{
 			name: "数据格式错误",
@@ -73,12 +99,12 @@ func TestUserHandler_Signup(t *testing.T) {
 				"email": "gz4z2b@163.com",
 				"password": "19890821Xi_",
 			}`),
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService) {
 				service := svcmocks.NewMockUserService(ctrl)
-				return service
+				return service, svcmocks.NewMockEmailVerifyService(ctrl)
 			},
 			wantCode: http.StatusBadRequest,
-			wantBody: "数据格式错误",
+			wantBody: `{"code":400001,"msg":"参数错误"}`,
 		},
 		{
 			name: "两次输入的密码不一致",
@@ -87,12 +113,12 @@ func TestUserHandler_Signup(t *testing.T) {
 				"password": "19890821Xi_",
 				"confirmPassword": "19890821Xi"
 			}`),
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService) {
 				service := svcmocks.NewMockUserService(ctrl)
-				return service
+				return service, svcmocks.NewMockEmailVerifyService(ctrl)
 			},
-			wantCode: http.StatusOK,
-			wantBody: "两次输入的密码不一致",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"confirmPassword","msg":"confirmPassword必须等于Password"}]}`,
 		},
 		{
 			name: "密码复杂度不够",
@@ -101,12 +127,12 @@ func TestUserHandler_Signup(t *testing.T) {
 				"password": "19890821Xi",
 				"confirmPassword": "19890821Xi"
 			}`),
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService) {
 				service := svcmocks.NewMockUserService(ctrl)
-				return service
+				return service, svcmocks.NewMockEmailVerifyService(ctrl)
 			},
-			wantCode: http.StatusOK,
-			wantBody: "密码复杂度不够",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"password","msg":"password需要8到72位，同时包含大小写字母、数字和特殊字符"}]}`,
 		},
 		{
 			name: "邮箱格式不正确",
@@ -115,12 +141,12 @@ func TestUserHandler_Signup(t *testing.T) {
 				"password": "19890821Xi_",
 				"confirmPassword": "19890821Xi_"
 			}`),
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService) {
 				service := svcmocks.NewMockUserService(ctrl)
-				return service
+				return service, svcmocks.NewMockEmailVerifyService(ctrl)
 			},
-			wantCode: http.StatusOK,
-			wantBody: "邮箱格式不正确",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"email","msg":"email必须是一个有效的邮箱"}]}`,
 		},
 		{
 			name: "邮箱冲突啦~~",
@@ -129,13 +155,13 @@ func TestUserHandler_Signup(t *testing.T) {
 				"password": "19890821Xi_",
 				"confirmPassword": "19890821Xi_"
 			}`),
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService) {
 				svc := svcmocks.NewMockUserService(ctrl)
 				svc.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(service.ErrEmailConflict)
-				return svc
+				return svc, svcmocks.NewMockEmailVerifyService(ctrl)
 			},
-			wantCode: http.StatusOK,
-			wantBody: "邮箱冲突啦~~",
+			wantCode: http.StatusConflict,
+			wantBody: `{"code":409001,"msg":"邮箱已被注册"}`,
 		},
 		{
 			name: "service出错",
@@ -144,13 +170,13 @@ func TestUserHandler_Signup(t *testing.T) {
 				"password": "19890821Xi_",
 				"confirmPassword": "19890821Xi_"
 			}`),
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.EmailVerifyService) {
 				service := svcmocks.NewMockUserService(ctrl)
 				service.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(errors.New("系统出错"))
-				return service
+				return service, svcmocks.NewMockEmailVerifyService(ctrl)
 			},
-			wantCode: http.StatusOK,
-			wantBody: "系统出错",
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":500001,"msg":"系统错误"}`,
 		},
 	}
 
@@ -162,8 +188,9 @@ func TestUserHandler_Signup(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			handler := NewUserHandler(tc.mock(ctrl))
-			server := InitWebService(handler, []gin.HandlerFunc{})
+			svc, verifySvc := tc.mock(ctrl)
+			handler := NewUserHandler(svc, nil, nil, verifySvc, nil, newJWTHandler())
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{})
 			server.ServeHTTP(resp, req)
 
 			assert.Equal(t, resp.Code, tc.wantCode)
@@ -186,11 +213,12 @@ func TestMock(t *testing.T) {
 
 func TestUserHandler_Login(t *testing.T) {
 	tests := []struct {
-		name     string
-		input    string
-		mock     func(ctrl *gomock.Controller) service.UserService
-		wantCode int
-		wantBody string
+		name           string
+		input          string
+		mock           func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService)
+		wantCode       int
+		wantBody       string
+		wantRetryAfter string
 	}{
 		{
 			name: "正常",
@@ -198,25 +226,27 @@ func TestUserHandler_Login(t *testing.T) {
 				"email": "gz4z2b@163.com",
 				"password": "19890821Xi_"
 			}`,
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
 				svc := svcmocks.NewMockUserService(ctrl)
+				attemptSvc := svcmocks.NewMockLoginAttemptService(ctrl)
+				attemptSvc.EXPECT().Check(gomock.Any(), "gz4z2b@163.com", "192.0.2.1").Return(time.Duration(0), nil)
 				svc.EXPECT().Login(gomock.Any(), gomock.Any()).Return(&domain.User{}, nil)
-				return svc
+				attemptSvc.EXPECT().Succeed(gomock.Any(), "gz4z2b@163.com").Return(nil)
+				return svc, attemptSvc
 			},
 			wantCode: http.StatusOK,
-			wantBody: "登录成功",
+			wantBody: `{"code":0,"msg":"登录成功"}`,
 		},
 		{
 			name: "输入数据格式错误",
 			input: `{
 				"email": "gz4z2b@163.com",
 			}`,
-			mock: func(ctrl *gomock.Controller) service.UserService {
-				svc := svcmocks.NewMockUserService(ctrl)
-				return svc
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
+				return svcmocks.NewMockUserService(ctrl), svcmocks.NewMockLoginAttemptService(ctrl)
 			},
 			wantCode: http.StatusBadRequest,
-			wantBody: "输入数据格式错误",
+			wantBody: `{"code":400001,"msg":"参数错误"}`,
 		},
 		{
 			name: "密码错误",
@@ -224,13 +254,97 @@ func TestUserHandler_Login(t *testing.T) {
 				"email": "gz4z2b@163.com",
 				"password": "19890821Xi"
 			}`,
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
 				svc := svcmocks.NewMockUserService(ctrl)
+				attemptSvc := svcmocks.NewMockLoginAttemptService(ctrl)
+				attemptSvc.EXPECT().Check(gomock.Any(), "gz4z2b@163.com", "192.0.2.1").Return(time.Duration(0), nil)
 				svc.EXPECT().Login(gomock.Any(), gomock.Any()).Return(&domain.User{}, service.ErrPasswordInvalid)
-				return svc
+				attemptSvc.EXPECT().Fail(gomock.Any(), "gz4z2b@163.com", "192.0.2.1").Return(nil)
+				return svc, attemptSvc
 			},
-			wantCode: http.StatusOK,
-			wantBody: "邮箱或密码错误",
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"code":401002,"msg":"邮箱或密码错误"}`,
+		},
+		{
+			name: "邮箱不存在也记失败",
+			input: `{
+				"email": "gz4z2b@163.com",
+				"password": "19890821Xi_"
+			}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				attemptSvc := svcmocks.NewMockLoginAttemptService(ctrl)
+				attemptSvc.EXPECT().Check(gomock.Any(), "gz4z2b@163.com", "192.0.2.1").Return(time.Duration(0), nil)
+				svc.EXPECT().Login(gomock.Any(), gomock.Any()).Return(&domain.User{}, service.ErrUserNotFound)
+				attemptSvc.EXPECT().Fail(gomock.Any(), "gz4z2b@163.com", "192.0.2.1").Return(errors.New("缓存炸了"))
+				return svc, attemptSvc
+			},
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"code":401002,"msg":"邮箱或密码错误"}`,
+		},
+		{
+			name: "失败太频繁",
+			input: `{
+				"email": "gz4z2b@163.com",
+				"password": "19890821Xi_"
+			}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
+				attemptSvc := svcmocks.NewMockLoginAttemptService(ctrl)
+				attemptSvc.EXPECT().Check(gomock.Any(), "gz4z2b@163.com", "192.0.2.1").
+					Return(time.Millisecond*1500, service.ErrLoginTooFrequent)
+				return svcmocks.NewMockUserService(ctrl), attemptSvc
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantBody:       `{"code":429003,"msg":"登录失败太频繁，请稍后再试"}`,
+			wantRetryAfter: "2",
+		},
+		{
+			name: "账号锁定",
+			input: `{
+				"email": "gz4z2b@163.com",
+				"password": "19890821Xi_"
+			}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
+				attemptSvc := svcmocks.NewMockLoginAttemptService(ctrl)
+				attemptSvc.EXPECT().Check(gomock.Any(), "gz4z2b@163.com", "192.0.2.1").
+					Return(time.Minute*15, service.ErrLoginLocked)
+				return svcmocks.NewMockUserService(ctrl), attemptSvc
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantBody:       `{"code":429004,"msg":"登录失败次数过多，账号暂时锁定，请稍后再试或者重置密码"}`,
+			wantRetryAfter: "900",
+		},
+		{
+			name: "邮箱还没验证",
+			input: `{
+				"email": "gz4z2b@163.com",
+				"password": "19890821Xi_"
+			}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				attemptSvc := svcmocks.NewMockLoginAttemptService(ctrl)
+				attemptSvc.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Duration(0), nil)
+				svc.EXPECT().Login(gomock.Any(), gomock.Any()).Return(&domain.User{}, service.ErrUserPending)
+				return svc, attemptSvc
+			},
+			wantCode: http.StatusForbidden,
+			wantBody: `{"code":403001,"msg":"邮箱还没有验证，请先点击验证邮件里的链接"}`,
+		},
+		{
+			name: "账号被禁用",
+			input: `{
+				"email": "gz4z2b@163.com",
+				"password": "19890821Xi_"
+			}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				attemptSvc := svcmocks.NewMockLoginAttemptService(ctrl)
+				attemptSvc.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Duration(0), nil)
+				svc.EXPECT().Login(gomock.Any(), gomock.Any()).Return(&domain.User{}, service.ErrUserDisabled)
+				return svc, attemptSvc
+			},
+			wantCode: http.StatusForbidden,
+			wantBody: `{"code":403002,"msg":"账号已被禁用"}`,
 		},
 		{
 			name: "系统错误",
@@ -238,13 +352,15 @@ func TestUserHandler_Login(t *testing.T) {
 				"email": "gz4z2b@163.com",
 				"password": "19890821Xi_"
 			}`,
-			mock: func(ctrl *gomock.Controller) service.UserService {
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAttemptService) {
 				svc := svcmocks.NewMockUserService(ctrl)
+				attemptSvc := svcmocks.NewMockLoginAttemptService(ctrl)
+				attemptSvc.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Duration(0), nil)
 				svc.EXPECT().Login(gomock.Any(), gomock.Any()).Return(&domain.User{}, errors.New("系统错误"))
-				return svc
+				return svc, attemptSvc
 			},
-			wantCode: http.StatusOK,
-			wantBody: "系统错误",
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":500001,"msg":"系统错误"}`,
 		},
 	}
 
@@ -254,42 +370,342 @@ func TestUserHandler_Login(t *testing.T) {
 			defer ctrl.Finish()
 
 			req := httptest.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer([]byte(tt.input)))
+			// 客户端自己带的 X-Forwarded-For 不能换掉按IP锁定用的地址，上面的期望都是对端地址 192.0.2.1
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
 			resp := httptest.NewRecorder()
 
-			handler := NewUserHandler(tt.mock(ctrl))
-			server := InitWebService(handler, InitUserMidleware())
+			jwtHdl := newJWTHandler()
+			svc, attemptSvc := tt.mock(ctrl)
+			handler := NewUserHandler(svc, nil, nil, nil, attemptSvc, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
 			server.ServeHTTP(resp, req)
 
 			assert.Equal(t, tt.wantCode, resp.Code)
 			assert.Equal(t, tt.wantBody, resp.Body.String())
+			assert.Equal(t, tt.wantRetryAfter, resp.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestUserHandler_SendLoginSMSCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		mock     func(ctrl *gomock.Controller) service.CodeService
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:  "正常",
+			input: `{"phone": "13800138000"}`,
+			mock: func(ctrl *gomock.Controller) service.CodeService {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), service.CodeBizLogin, "13800138000").Return(nil)
+				return codeSvc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"发送成功"}`,
+		},
+		{
+			name:  "手机号格式不正确",
+			input: `{"phone": "1380013800"}`,
+			mock: func(ctrl *gomock.Controller) service.CodeService {
+				return svcmocks.NewMockCodeService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"phone","msg":"phone必须是有效的手机号"}]}`,
+		},
+		{
+			name:  "发送太频繁",
+			input: `{"phone": "13800138000"}`,
+			mock: func(ctrl *gomock.Controller) service.CodeService {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), service.CodeBizLogin, "13800138000").Return(service.ErrCodeSendTooMany)
+				return codeSvc
+			},
+			wantCode: http.StatusTooManyRequests,
+			wantBody: `{"code":429001,"msg":"发送太频繁，请稍后再试"}`,
+		},
+		{
+			name:  "发送失败",
+			input: `{"phone": "13800138000"}`,
+			mock: func(ctrl *gomock.Controller) service.CodeService {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), service.CodeBizLogin, "13800138000").Return(errors.New("短信炸了"))
+				return codeSvc
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":500001,"msg":"系统错误"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
+			req := httptest.NewRequest(http.MethodPost, "/users/login_sms/code/send", bytes.NewBuffer([]byte(tt.input)))
+			resp := httptest.NewRecorder()
+
+			jwtHdl := newJWTHandler()
+			handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), tt.mock(ctrl), nil, nil, nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
 		})
 	}
 }
 
-func TestUserHandler_Edit(t *testing.T) {
+func TestUserHandler_LoginSMS(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		mock      func(ctrl *gomock.Controller) (service.UserService, service.CodeService)
+		wantCode  int
+		wantBody  string
+		wantToken bool
+	}{
+		{
+			name:  "正常",
+			input: `{"phone": "13800138000", "code": "123456"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizLogin, "13800138000", "123456").Return(true, nil)
+				svc.EXPECT().FindOrCreateByPhone(gomock.Any(), "13800138000").Return(&domain.User{
+					Id:    1,
+					Phone: "13800138000",
+				}, nil)
+				return svc, codeSvc
+			},
+			wantCode:  http.StatusOK,
+			wantBody:  `{"code":0,"msg":"登录成功"}`,
+			wantToken: true,
+		},
+		{
+			name:  "验证码有误",
+			input: `{"phone": "13800138000", "code": "123457"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizLogin, "13800138000", "123457").Return(false, nil)
+				return svcmocks.NewMockUserService(ctrl), codeSvc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400010,"msg":"验证码有误"}`,
+		},
+		{
+			name:  "验证次数太多",
+			input: `{"phone": "13800138000", "code": "123457"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizLogin, "13800138000", "123457").Return(false, service.ErrCodeVerifyTooManyTimes)
+				return svcmocks.NewMockUserService(ctrl), codeSvc
+			},
+			wantCode: http.StatusTooManyRequests,
+			wantBody: `{"code":429002,"msg":"验证次数太多，请重新获取验证码"}`,
+		},
+		{
+			name:  "创建用户失败",
+			input: `{"phone": "13800138000", "code": "123456"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizLogin, "13800138000", "123456").Return(true, nil)
+				svc.EXPECT().FindOrCreateByPhone(gomock.Any(), "13800138000").Return(&domain.User{}, errors.New("数据库炸了"))
+				return svc, codeSvc
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":500001,"msg":"系统错误"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/login_sms", bytes.NewBuffer([]byte(tt.input)))
+			resp := httptest.NewRecorder()
+
+			jwtHdl := newJWTHandler()
+			svc, codeSvc := tt.mock(ctrl)
+			handler := NewUserHandler(svc, codeSvc, nil, nil, nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+			assert.Equal(t, tt.wantToken, resp.Header().Get("x-jwt-token") != "")
+			assert.Equal(t, tt.wantToken, resp.Header().Get("x-refresh-token") != "")
+		})
+	}
+}
+
+func TestUserHandler_RefreshToken(t *testing.T) {
+	const userAgent = "webook-test"
+	signRefreshToken := func(key string, ssid string, userAgent string, expiresAt time.Time) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS512, domain.RefreshClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+			Uid:       1,
+			Email:     "gz4z2b@163.com",
+			Ssid:      ssid,
+			UserAgent: userAgent,
+		})
+		tokenStr, err := token.SignedString([]byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenStr
+	}
+	tests := []struct {
+		name      string
+		token     string
+		revoke    string
+		wantCode  int
+		wantToken bool
+	}{
+		{
+			name:      "正常",
+			token:     signRefreshToken(testRefreshKey, "ssid-1", userAgent, time.Now().Add(time.Hour)),
+			wantCode:  http.StatusOK,
+			wantToken: true,
+		},
+		{
+			name:     "用短token的key签的",
+			token:    signRefreshToken(testAccessKey, "ssid-1", userAgent, time.Now().Add(time.Hour)),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "长token过期",
+			token:    signRefreshToken(testRefreshKey, "ssid-1", userAgent, time.Now().Add(-time.Minute)),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "UserAgent不一致",
+			token:    signRefreshToken(testRefreshKey, "ssid-1", "other-agent", time.Now().Add(time.Hour)),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "会话已作废",
+			token:    signRefreshToken(testRefreshKey, "ssid-1", userAgent, time.Now().Add(time.Hour)),
+			revoke:   "ssid-1",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "没带token",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/refresh_token", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			req.Header.Set("User-Agent", userAgent)
+			resp := httptest.NewRecorder()
+
+			jwtHdl := newJWTHandler()
+			if tt.revoke != "" {
+				err := jwtHdl.RevokeSession(&gin.Context{}, tt.revoke)
+				assert.NoError(t, err)
+			}
+			handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil, nil, nil, nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantToken, resp.Header().Get("x-jwt-token") != "")
+			// 刷新只换短token，不换长token
+			assert.Empty(t, resp.Header().Get("x-refresh-token"))
+		})
+	}
+}
+
+func TestUserHandler_Logout(t *testing.T) {
+	const userAgent = "webook-test"
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jwtHdl := newJWTHandler()
+	handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil, nil, nil, nil, jwtHdl)
+	server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+
+	// 先登录拿到一对token
+	loginResp := httptest.NewRecorder()
+	loginCtx, _ := gin.CreateTestContext(loginResp)
+	loginCtx.Request = httptest.NewRequest(http.MethodPost, "/users/login", nil)
+	loginCtx.Request.Header.Set("User-Agent", userAgent)
+	err := jwtHdl.SetLoginToken(loginCtx, &domain.User{
+		Id:    1,
+		Email: "gz4z2b@163.com",
+	})
+	assert.NoError(t, err)
+	accessToken := loginResp.Header().Get("x-jwt-token")
+	refreshToken := loginResp.Header().Get("x-refresh-token")
+
+	request := func(path string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("User-Agent", userAgent)
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+		return resp
+	}
 
+	resp := request("/users/logout", accessToken)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `{"code":0,"msg":"登出成功"}`, resp.Body.String())
+
+	// 登出之后短token和长token都不能再用
+	resp = request("/users/logout", accessToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	resp = request("/users/refresh_token", refreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, `{"code":401003,"msg":"登录已失效，请重新登录"}`, resp.Body.String())
+}
+
+func TestUserHandler_ForgotPassword(t *testing.T) {
 	tests := []struct {
 		name     string
-		input    string
-		mock     func(ctrl *gomock.Controller) service.UserService
+		body     string
+		mock     func(ctrl *gomock.Controller) service.PasswordService
 		wantCode int
 		wantBody string
 	}{
 		{
 			name: "正常",
-			input: `{
-				"nick_name": "陈瀚禧",
-				"birth_day": "1989-08-21",
-				"description": "简介"
-			}`,
-			mock: func(ctrl *gomock.Controller) service.UserService {
-				svc := svcmocks.NewMockUserService(ctrl)
-				svc.EXPECT().AddProfile(gomock.Any(), gomock.Any(), gomock.Any()).Return(&domain.Profile{}, nil)
-				return svc
+			body: `{"email":"gz4z2b@163.com"}`,
+			mock: func(ctrl *gomock.Controller) service.PasswordService {
+				passwordSvc := svcmocks.NewMockPasswordService(ctrl)
+				passwordSvc.EXPECT().SendResetEmail(gomock.Any(), "gz4z2b@163.com").Return(nil)
+				return passwordSvc
 			},
 			wantCode: http.StatusOK,
-			wantBody: "修改成功",
+			wantBody: `{"code":0,"msg":"如果这个邮箱注册过，重置密码的邮件已经发出，请查收"}`,
+		},
+		{
+			name: "邮箱格式不对",
+			body: `{"email":"gz4z2b"}`,
+			mock: func(ctrl *gomock.Controller) service.PasswordService {
+				return svcmocks.NewMockPasswordService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"email","msg":"email必须是一个有效的邮箱"}]}`,
+		},
+		{
+			name: "发邮件失败",
+			body: `{"email":"gz4z2b@163.com"}`,
+			mock: func(ctrl *gomock.Controller) service.PasswordService {
+				passwordSvc := svcmocks.NewMockPasswordService(ctrl)
+				passwordSvc.EXPECT().SendResetEmail(gomock.Any(), "gz4z2b@163.com").Return(errors.New("smtp 发送邮件失败"))
+				return passwordSvc
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":500001,"msg":"系统错误"}`,
 		},
 	}
 	for _, tt := range tests {
@@ -297,49 +713,1013 @@ func TestUserHandler_Edit(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			req := httptest.NewRequest(http.MethodPost, "/users/edit", bytes.NewBuffer([]byte(tt.input)))
+			req := httptest.NewRequest(http.MethodPost, "/users/password/forgot", bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
 			resp := httptest.NewRecorder()
 
-			handler := NewUserHandler(tt.mock(ctrl))
-			server := InitWebService(handler, []gin.HandlerFunc{})
+			jwtHdl := newJWTHandler()
+			handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil, tt.mock(ctrl), nil, nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
 			server.ServeHTTP(resp, req)
 
-			assert.Equal(t, tt.wantBody, resp.Body.String())
 			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
 		})
 	}
 }
 
-func TestUserHandler_Profile(t *testing.T) {
-	type fields struct {
-		svc                       service.UserService
-		emailExpersion            *regexp.Regexp
-		passwordExpersion         *regexp.Regexp
-		birthdayRegexExpersion    *regexp.Regexp
-		nickNameRegexExpersion    *regexp.Regexp
-		descriptionRegexExpersion *regexp.Regexp
+func TestUserHandler_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mock       func(ctrl *gomock.Controller) service.PasswordService
+		wantCode   int
+		wantBody   string
+		wantRevoke bool
+	}{
+		{
+			name: "正常",
+			body: `{"token":"token","password":"Hello@123","confirmPassword":"Hello@123"}`,
+			mock: func(ctrl *gomock.Controller) service.PasswordService {
+				passwordSvc := svcmocks.NewMockPasswordService(ctrl)
+				passwordSvc.EXPECT().ResetPassword(gomock.Any(), "token", "Hello@123").Return(uint64(1), nil)
+				return passwordSvc
+			},
+			wantCode:   http.StatusOK,
+			wantBody:   `{"code":0,"msg":"密码已重置，请重新登录"}`,
+			wantRevoke: true,
+		},
+		{
+			name: "两次密码不一致",
+			body: `{"token":"token","password":"Hello@123","confirmPassword":"Hello@1234"}`,
+			mock: func(ctrl *gomock.Controller) service.PasswordService {
+				return svcmocks.NewMockPasswordService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"confirmPassword","msg":"confirmPassword必须等于Password"}]}`,
+		},
+		{
+			name: "链接无效",
+			body: `{"token":"token","password":"Hello@123","confirmPassword":"Hello@123"}`,
+			mock: func(ctrl *gomock.Controller) service.PasswordService {
+				passwordSvc := svcmocks.NewMockPasswordService(ctrl)
+				passwordSvc.EXPECT().ResetPassword(gomock.Any(), "token", "Hello@123").Return(uint64(0), service.ErrResetTokenInvalid)
+				return passwordSvc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400012,"msg":"重置密码链接无效或已过期"}`,
+		},
 	}
-	type args struct {
-		ctx *gin.Context
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/password/reset", bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			jwtHdl := newJWTHandler()
+			handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil, tt.mock(ctrl), nil, nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+
+			// 重置之前签发的token都不能再用
+			issuedAt := time.Now().Add(-time.Minute)
+			checkCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			err := jwtHdl.CheckSession(checkCtx, uint64(1), "", issuedAt)
+			assert.Equal(t, tt.wantRevoke, err != nil)
+		})
 	}
+}
+
+func TestUserHandler_RevokeSessionsFailed(t *testing.T) {
 	tests := []struct {
 		name   string
-		fields fields
-		args   args
+		method string
+		path   string
+		body   string
+		mock   func(ctrl *gomock.Controller) (service.UserService, service.PasswordService)
 	}{
-		// TODO: Add test cases.
+		{
+			name:   "重置密码",
+			method: http.MethodPost,
+			path:   "/users/password/reset",
+			body:   `{"token":"token","password":"Hello@123","confirmPassword":"Hello@123"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.PasswordService) {
+				passwordSvc := svcmocks.NewMockPasswordService(ctrl)
+				passwordSvc.EXPECT().ResetPassword(gomock.Any(), "token", "Hello@123").Return(uint64(1), nil)
+				return svcmocks.NewMockUserService(ctrl), passwordSvc
+			},
+		},
+		{
+			name:   "修改密码",
+			method: http.MethodPost,
+			path:   "/users/password/change",
+			body:   `{"oldPassword":"Hello@123","password":"Hello@1234","confirmPassword":"Hello@1234"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.PasswordService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().ChangePassword(gomock.Any(), uint64(1), "Hello@123", "Hello@1234").Return(nil)
+				return svc, svcmocks.NewMockPasswordService(ctrl)
+			},
+		},
+		{
+			name:   "注销账号",
+			method: http.MethodDelete,
+			path:   "/users/me",
+			body:   `{"password":"19890821Xi_"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.PasswordService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccount(gomock.Any(), uint64(1), "19890821Xi_").Return(nil)
+				return svc, svcmocks.NewMockPasswordService(ctrl)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			u := &UserHandler{
-				svc:                       tt.fields.svc,
-				emailExpersion:            tt.fields.emailExpersion,
-				passwordExpersion:         tt.fields.passwordExpersion,
-				birthdayRegexExpersion:    tt.fields.birthdayRegexExpersion,
-				nickNameRegexExpersion:    tt.fields.nickNameRegexExpersion,
-				descriptionRegexExpersion: tt.fields.descriptionRegexExpersion,
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessions := cachemocks.NewMockSessionCache(ctrl)
+			sessions.EXPECT().RevokeUser(gomock.Any(), uint64(1), gomock.Any(), gomock.Any()).
+				Return(errors.New("redis 连不上")).Times(revokeRetryTimes)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			svc, passwordSvc := tt.mock(ctrl)
+			handler := NewUserHandler(svc, nil, passwordSvc, nil, nil, newJWTHandlerWithSessions(sessions))
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{func(ctx *gin.Context) {
+				ctx.Set(ijwt.ClaimsKey, &domain.UserClaims{Uid: 1})
+			}})
+			server.ServeHTTP(resp, req)
+
+			// 别的设备没踢掉不能报成功
+			assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			assert.Equal(t, `{"code":500001,"msg":"系统错误"}`, resp.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mock       func(ctrl *gomock.Controller) service.UserService
+		wantCode   int
+		wantBody   string
+		wantRevoke bool
+	}{
+		{
+			name: "正常",
+			body: `{"oldPassword":"19890821Xi_","password":"Hello@123","confirmPassword":"Hello@123"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().ChangePassword(gomock.Any(), uint64(1), "19890821Xi_", "Hello@123").Return(nil)
+				return svc
+			},
+			wantCode:   http.StatusOK,
+			wantBody:   `{"code":0,"msg":"密码已修改，请重新登录"}`,
+			wantRevoke: true,
+		},
+		{
+			name: "新密码太简单",
+			body: `{"oldPassword":"19890821Xi_","password":"hello","confirmPassword":"hello"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				return svcmocks.NewMockUserService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"password","msg":"password需要8到72位，同时包含大小写字母、数字和特殊字符"}]}`,
+		},
+		{
+			name: "原密码不正确",
+			body: `{"oldPassword":"19890821Xi","password":"Hello@123","confirmPassword":"Hello@123"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().ChangePassword(gomock.Any(), uint64(1), "19890821Xi", "Hello@123").Return(service.ErrPasswordInvalid)
+				return svc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400013,"msg":"原密码不正确"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/password/change", bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			jwtHdl := newJWTHandler()
+			handler := NewUserHandler(tt.mock(ctrl), nil, nil, nil, nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{func(ctx *gin.Context) {
+				ctx.Set(ijwt.ClaimsKey, &domain.UserClaims{Uid: 1})
+			}})
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+
+			// 改密码之前签发的token都不能再用
+			checkCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			err := jwtHdl.CheckSession(checkCtx, uint64(1), "", time.Now().Add(-time.Minute))
+			assert.Equal(t, tt.wantRevoke, err != nil)
+		})
+	}
+}
+
+func TestUserHandler_SendChangeEmailCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		mock     func(ctrl *gomock.Controller) (service.UserService, service.CodeService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "正常",
+			body: `{"email":"new@163.com"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().SendEmail(gomock.Any(), service.CodeBizChangeEmail, "new@163.com").Return(nil)
+				return svc, codeSvc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"发送成功"}`,
+		},
+		{
+			name: "邮箱已被注册也照常发，改的时候才报冲突",
+			body: `{"email":"new@163.com"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().SendEmail(gomock.Any(), service.CodeBizChangeEmail, "new@163.com").Return(nil)
+				return svcmocks.NewMockUserService(ctrl), codeSvc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"发送成功"}`,
+		},
+		{
+			name: "发送太频繁",
+			body: `{"email":"new@163.com"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().SendEmail(gomock.Any(), service.CodeBizChangeEmail, "new@163.com").Return(service.ErrCodeSendTooMany)
+				return svc, codeSvc
+			},
+			wantCode: http.StatusTooManyRequests,
+			wantBody: `{"code":429001,"msg":"发送太频繁，请稍后再试"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/email/code/send", bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			svc, codeSvc := tt.mock(ctrl)
+			handler := NewUserHandler(svc, codeSvc, nil, nil, nil, newJWTHandler())
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{func(ctx *gin.Context) {
+				ctx.Set(ijwt.ClaimsKey, &domain.UserClaims{Uid: 1})
+			}})
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_ChangeEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		mock     func(ctrl *gomock.Controller) (service.UserService, service.CodeService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "正常",
+			body: `{"email":"new@163.com","code":"123456"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizChangeEmail, "new@163.com", "123456").Return(true, nil)
+				svc.EXPECT().ChangeEmail(gomock.Any(), uint64(1), "new@163.com").Return(nil)
+				return svc, codeSvc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"邮箱已修改"}`,
+		},
+		{
+			name: "验证码不对",
+			body: `{"email":"new@163.com","code":"000000"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizChangeEmail, "new@163.com", "000000").Return(false, nil)
+				return svcmocks.NewMockUserService(ctrl), codeSvc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400018,"msg":"邮箱验证码有误"}`,
+		},
+		{
+			name: "验证完邮箱被别人抢先注册",
+			body: `{"email":"new@163.com","code":"123456"}`,
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizChangeEmail, "new@163.com", "123456").Return(true, nil)
+				svc.EXPECT().ChangeEmail(gomock.Any(), uint64(1), "new@163.com").Return(service.ErrEmailConflict)
+				return svc, codeSvc
+			},
+			wantCode: http.StatusConflict,
+			wantBody: `{"code":409001,"msg":"邮箱已被注册"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/email/change", bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			svc, codeSvc := tt.mock(ctrl)
+			handler := NewUserHandler(svc, codeSvc, nil, nil, nil, newJWTHandler())
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{func(ctx *gin.Context) {
+				ctx.Set(ijwt.ClaimsKey, &domain.UserClaims{Uid: 1})
+			}})
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		mock     func(ctrl *gomock.Controller) service.EmailVerifyService
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "正常",
+			body: `{"token":"token"}`,
+			mock: func(ctrl *gomock.Controller) service.EmailVerifyService {
+				verifySvc := svcmocks.NewMockEmailVerifyService(ctrl)
+				verifySvc.EXPECT().VerifyEmail(gomock.Any(), "token").Return(nil)
+				return verifySvc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"邮箱验证成功，可以登录了"}`,
+		},
+		{
+			name: "没带token",
+			body: `{}`,
+			mock: func(ctrl *gomock.Controller) service.EmailVerifyService {
+				return svcmocks.NewMockEmailVerifyService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"token","msg":"token为必填字段"}]}`,
+		},
+		{
+			name: "链接无效",
+			body: `{"token":"token"}`,
+			mock: func(ctrl *gomock.Controller) service.EmailVerifyService {
+				verifySvc := svcmocks.NewMockEmailVerifyService(ctrl)
+				verifySvc.EXPECT().VerifyEmail(gomock.Any(), "token").Return(service.ErrVerifyTokenInvalid)
+				return verifySvc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400014,"msg":"邮箱验证链接无效或已过期"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/verify_email", bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			// 没登录也能验证
+			jwtHdl := newJWTHandler()
+			handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil, nil, tt.mock(ctrl), nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_SendVerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	verifySvc := svcmocks.NewMockEmailVerifyService(ctrl)
+	verifySvc.EXPECT().SendVerifyEmail(gomock.Any(), "gz4z2b@163.com").Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/verify_email/send", bytes.NewBuffer([]byte(`{"email":"gz4z2b@163.com"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	jwtHdl := newJWTHandler()
+	handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil, nil, verifySvc, nil, jwtHdl)
+	server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+	server.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `{"code":0,"msg":"如果这个邮箱注册过且还没验证，验证邮件已经发出，请查收"}`, resp.Body.String())
+}
+
+func TestUserHandler_DeleteMe(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mock       func(ctrl *gomock.Controller) service.UserService
+		codeMock   func(ctrl *gomock.Controller) service.CodeService
+		wantCode   int
+		wantBody   string
+		wantRevoke bool
+	}{
+		{
+			name: "正常",
+			body: `{"password":"19890821Xi_"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccount(gomock.Any(), uint64(1), "19890821Xi_").Return(nil)
+				return svc
+			},
+			wantCode:   http.StatusOK,
+			wantBody:   `{"code":0,"msg":"账号已注销"}`,
+			wantRevoke: true,
+		},
+		{
+			name: "没输密码",
+			body: `{}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				return svcmocks.NewMockUserService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"password","msg":"password为必填字段"}]}`,
+		},
+		{
+			name: "密码不正确",
+			body: `{"password":"19890821Xi"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccount(gomock.Any(), uint64(1), "19890821Xi").Return(service.ErrPasswordInvalid)
+				return svc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400015,"msg":"密码不正确"}`,
+		},
+		{
+			name: "没设密码用验证码",
+			body: `{"code":"123456"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccountPhone(gomock.Any(), uint64(1)).Return("13800138000", nil)
+				svc.EXPECT().DeleteAccountByCode(gomock.Any(), uint64(1)).Return(nil)
+				return svc
+			},
+			codeMock: func(ctrl *gomock.Controller) service.CodeService {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizDeleteAccount, "13800138000", "123456").Return(true, nil)
+				return codeSvc
+			},
+			wantCode:   http.StatusOK,
+			wantBody:   `{"code":0,"msg":"账号已注销"}`,
+			wantRevoke: true,
+		},
+		{
+			name: "验证码不对",
+			body: `{"code":"123456"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccountPhone(gomock.Any(), uint64(1)).Return("13800138000", nil)
+				return svc
+			},
+			codeMock: func(ctrl *gomock.Controller) service.CodeService {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Verify(gomock.Any(), service.CodeBizDeleteAccount, "13800138000", "123456").Return(false, nil)
+				return codeSvc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400010,"msg":"验证码有误"}`,
+		},
+		{
+			name: "设了密码不能用验证码",
+			body: `{"code":"123456"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccountPhone(gomock.Any(), uint64(1)).Return("", service.ErrPasswordRequired)
+				return svc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400017,"msg":"账号设置了密码，请用密码确认"}`,
+		},
+		{
+			name: "没设密码却输了密码",
+			body: `{"password":"19890821Xi_"}`,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccount(gomock.Any(), uint64(1), "19890821Xi_").Return(service.ErrPasswordNotSet)
+				return svc
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400016,"msg":"账号没有设置密码，请用手机验证码确认"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var codeSvc service.CodeService
+			if tt.codeMock != nil {
+				codeSvc = tt.codeMock(ctrl)
 			}
-			u.Profile(tt.args.ctx)
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/me", bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			jwtHdl := newJWTHandler()
+			handler := NewUserHandler(tt.mock(ctrl), codeSvc, nil, nil, nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{func(ctx *gin.Context) {
+				ctx.Set(ijwt.ClaimsKey, &domain.UserClaims{Uid: 1})
+			}})
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+
+			// 注销之后所有设备上的token都不能再用
+			checkCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			err := jwtHdl.CheckSession(checkCtx, uint64(1), "", time.Now().Add(-time.Minute))
+			assert.Equal(t, tt.wantRevoke, err != nil)
 		})
 	}
 }
+
+func TestUserHandler_Edit(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		input    string
+		claims   *domain.UserClaims
+		mock     func(ctrl *gomock.Controller) service.UserService
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "正常",
+			input: `{
+				"nick_name": "陈瀚禧",
+				"birth_day": "1989-08-21",
+				"description": "简介"
+			}`,
+			claims: &domain.UserClaims{
+				Uid:   1,
+				Email: "gz4z2b@163.com",
+				Ssid:  "ssid-1",
+			},
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				// token里带了 Uid，不用再查用户
+				svc.EXPECT().AddProfile(gomock.Any(), &domain.User{Id: 1}, gomock.Any()).Return(&domain.Profile{}, nil)
+				return svc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"修改成功"}`,
+		},
+		{
+			name: "迁移期内只带邮箱的旧token",
+			input: `{
+				"nick_name": "陈瀚禧",
+				"birth_day": "1989-08-21",
+				"description": "简介"
+			}`,
+			claims: &domain.UserClaims{
+				Email: "gz4z2b@163.com",
+			},
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{
+					Id:    1,
+					Email: "gz4z2b@163.com",
+				}, nil)
+				svc.EXPECT().AddProfile(gomock.Any(), &domain.User{Id: 1}, gomock.Any()).Return(&domain.Profile{}, nil)
+				return svc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"修改成功"}`,
+		},
+		{
+			name: "旧token的用户查不到",
+			input: `{
+				"nick_name": "陈瀚禧",
+				"birth_day": "1989-08-21",
+				"description": "简介"
+			}`,
+			claims: &domain.UserClaims{
+				Email: "gz4z2b@163.com",
+			},
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().FindByEmail(gomock.Any(), "gz4z2b@163.com").Return(&domain.User{}, service.ErrUserNotFound)
+				return svc
+			},
+			wantCode: http.StatusNotFound,
+			wantBody: `{"code":404001,"msg":"用户不存在"}`,
+		},
+		{
+			name: "设置公开生日",
+			input: `{
+				"nick_name": "陈瀚禧",
+				"birth_day": "1989-08-21",
+				"description": "简介",
+				"birthday_visibility": "public"
+			}`,
+			claims: &domain.UserClaims{
+				Uid:   1,
+				Email: "gz4z2b@163.com",
+				Ssid:  "ssid-1",
+			},
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().AddProfile(gomock.Any(), &domain.User{Id: 1}, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, user *domain.User, profile *domain.Profile) (*domain.Profile, error) {
+						assert.Equal(t, domain.VisibilityPublic, profile.BirthdayVisibility)
+						assert.Equal(t, domain.VisibilityPrivate, profile.EmailVisibility)
+						return profile, nil
+					})
+				return svc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"修改成功"}`,
+		},
+		{
+			name: "可见性不对",
+			input: `{
+				"nick_name": "陈瀚禧",
+				"birth_day": "1989-08-21",
+				"description": "简介",
+				"email_visibility": "friends"
+			}`,
+			claims: &domain.UserClaims{
+				Uid:   1,
+				Email: "gz4z2b@163.com",
+				Ssid:  "ssid-1",
+			},
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				return svcmocks.NewMockUserService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400011,"msg":"参数校验不通过","data":[{"field":"email_visibility","msg":"email_visibility必须是[public private]中的一个"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/edit", bytes.NewBuffer([]byte(tt.input)))
+			resp := httptest.NewRecorder()
+
+			handler := NewUserHandler(tt.mock(ctrl), nil, nil, nil, nil, newJWTHandler())
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{func(ctx *gin.Context) {
+				// 模拟登录态
+				ctx.Set(ijwt.ClaimsKey, tt.claims)
+			}})
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
+
+func TestMustUser(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	_, ok := GetUser(ctx)
+	assert.False(t, ok)
+	assert.Panics(t, func() {
+		MustUser(ctx)
+	})
+
+	ctx.Set(ijwt.ClaimsKey, &domain.UserClaims{
+		Uid:   1,
+		Email: "gz4z2b@163.com",
+		Ssid:  "ssid-1",
+	})
+	assert.Equal(t, LoginUser{
+		Uid:   1,
+		Email: "gz4z2b@163.com",
+		Ssid:  "ssid-1",
+	}, MustUser(ctx))
+}
+
+func TestUserHandler_Profile(t *testing.T) {
+	birthDay, _ := time.ParseInLocation("2006-01-02", "1989-08-21", time.Local)
+	self := &domain.UserClaims{
+		Uid:   1,
+		Email: "gz4z2b@163.com",
+		Ssid:  "ssid-1",
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		claims   *domain.UserClaims
+		mock     func(ctrl *gomock.Controller) service.UserService
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:   "看自己的档案",
+			path:   "/users/1",
+			claims: self,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Email: "gz4z2b@163.com"}, nil)
+				svc.EXPECT().FindProfileByUser(gomock.Any(), gomock.Any()).Return(&domain.Profile{
+					UserId:      1,
+					NickName:    "陈瀚禧",
+					BirthDay:    birthDay.UnixMilli(),
+					Description: "简介",
+				}, nil)
+				return svc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"成功","data":{"uid":1,"nick_name":"陈瀚禧","description":"简介","birth_day":"1989-08-21","email":"gz4z2b@163.com","birthday_visibility":"private","email_visibility":"private"}}`,
+		},
+		{
+			name:   "老前端的/users/profile",
+			path:   "/users/profile",
+			claims: self,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().FindById(gomock.Any(), uint64(1)).Return(&domain.User{Id: 1, Email: "gz4z2b@163.com"}, nil)
+				svc.EXPECT().FindProfileByUser(gomock.Any(), gomock.Any()).Return(&domain.Profile{}, service.ErrProfileNotFound)
+				return svc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"成功","data":{"uid":1,"nick_name":"","description":"","email":"gz4z2b@163.com","birthday_visibility":"private","email_visibility":"private"}}`,
+		},
+		{
+			name:   "别人看不到私密字段",
+			path:   "/users/2",
+			claims: self,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().FindById(gomock.Any(), uint64(2)).Return(&domain.User{Id: 2, Email: "other@163.com"}, nil)
+				svc.EXPECT().FindProfileByUser(gomock.Any(), gomock.Any()).Return(&domain.Profile{
+					UserId:      2,
+					NickName:    "别人",
+					BirthDay:    birthDay.UnixMilli(),
+					Description: "简介",
+				}, nil)
+				return svc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"成功","data":{"uid":2,"nick_name":"别人","description":"简介"}}`,
+		},
+		{
+			name:   "别人能看到公开字段",
+			path:   "/users/2",
+			claims: self,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().FindById(gomock.Any(), uint64(2)).Return(&domain.User{Id: 2, Email: "other@163.com"}, nil)
+				svc.EXPECT().FindProfileByUser(gomock.Any(), gomock.Any()).Return(&domain.Profile{
+					UserId:             2,
+					NickName:           "别人",
+					BirthDay:           birthDay.UnixMilli(),
+					Description:        "简介",
+					BirthdayVisibility: domain.VisibilityPublic,
+					EmailVisibility:    domain.VisibilityPublic,
+				}, nil)
+				return svc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"成功","data":{"uid":2,"nick_name":"别人","description":"简介","birth_day":"1989-08-21","email":"other@163.com"}}`,
+		},
+		{
+			name:   "用户不存在",
+			path:   "/users/3",
+			claims: self,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().FindById(gomock.Any(), uint64(3)).Return(&domain.User{}, service.ErrUserNotFound)
+				return svc
+			},
+			wantCode: http.StatusNotFound,
+			wantBody: `{"code":404001,"msg":"用户不存在"}`,
+		},
+		{
+			name:   "查档案出错",
+			path:   "/users/2",
+			claims: self,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().FindById(gomock.Any(), uint64(2)).Return(&domain.User{Id: 2}, nil)
+				svc.EXPECT().FindProfileByUser(gomock.Any(), gomock.Any()).Return(&domain.Profile{}, errors.New("缓存炸了"))
+				return svc
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":500001,"msg":"系统错误"}`,
+		},
+		{
+			name:   "uid不是数字",
+			path:   "/users/abc",
+			claims: self,
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				return svcmocks.NewMockUserService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400001,"msg":"参数错误"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+
+			handler := NewUserHandler(tt.mock(ctrl), nil, nil, nil, nil, newJWTHandler())
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{func(ctx *gin.Context) {
+				// 模拟登录态
+				ctx.Set(ijwt.ClaimsKey, tt.claims)
+			}})
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
+
+func TestUserHandler_SendDeleteMeCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (service.UserService, service.CodeService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "正常",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccountPhone(gomock.Any(), uint64(1)).Return("13800138000", nil)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), service.CodeBizDeleteAccount, "13800138000").Return(nil)
+				return svc, codeSvc
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"发送成功"}`,
+		},
+		{
+			name: "设了密码不发",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().DeleteAccountPhone(gomock.Any(), uint64(1)).Return("", service.ErrPasswordRequired)
+				return svc, svcmocks.NewMockCodeService(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":400017,"msg":"账号设置了密码，请用密码确认"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/me/code/send", nil)
+			resp := httptest.NewRecorder()
+
+			svc, codeSvc := tt.mock(ctrl)
+			handler := NewUserHandler(svc, codeSvc, nil, nil, nil, newJWTHandler())
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), []gin.HandlerFunc{func(ctx *gin.Context) {
+				ctx.Set(ijwt.ClaimsKey, &domain.UserClaims{Uid: 1})
+			}})
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantBody, resp.Body.String())
+		})
+	}
+}
+
+func TestInitUserMidleware_RateLimitBeforeLogin(t *testing.T) {
+	rules := conf.RateLimit.Rules
+	defer func() { conf.RateLimit.Rules = rules }()
+	conf.RateLimit.Rules = []conf.RateLimitRule{
+		{Path: "/users", KeyBy: "ip", Window: time.Minute, Threshold: 2},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jwtHdl := newJWTHandler()
+	handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil, nil, nil, nil, jwtHdl)
+	server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+
+	// 没登录被拦的请求也要算进按IP的名额里
+	var codes []int
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/users/edit", nil)
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+		codes = append(codes, resp.Code)
+	}
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}, codes)
+}
+
+func TestInitWebService_TrustedProxies(t *testing.T) {
+	rules, server := conf.RateLimit.Rules, conf.Server
+	defer func() { conf.RateLimit.Rules, conf.Server = rules, server }()
+	conf.RateLimit.Rules = []conf.RateLimitRule{
+		{Path: "/users/login", KeyBy: "ip", Window: time.Minute, Threshold: 2},
+	}
+
+	tests := []struct {
+		name    string
+		proxies []string
+		want    []int
+	}{
+		{
+			name: "没配可信代理，伪造 X-Forwarded-For 换不了IP",
+			want: []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			name:    "可信代理转过来的按 X-Forwarded-For 算",
+			proxies: []string{"192.0.2.0/24"},
+			want:    []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusBadRequest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf.Server.TrustedProxies = tt.proxies
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			jwtHdl := newJWTHandler()
+			handler := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil, nil, nil, nil, jwtHdl)
+			server := InitWebService(handler, NewHealthHandler(nil, time.Second), InitUserMidleware(jwtHdl, ratelimit.NewLocalSlidingWindowLimiter))
+
+			var codes []int
+			for i := 0; i < 3; i++ {
+				// httptest 的对端地址固定是 192.0.2.1，每次换一个 X-Forwarded-For
+				req := httptest.NewRequest(http.MethodPost, "/users/login", nil)
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+				resp := httptest.NewRecorder()
+				server.ServeHTTP(resp, req)
+				codes = append(codes, resp.Code)
+			}
+			assert.Equal(t, tt.want, codes)
+		})
+	}
+}
+
+// 测试用的签名key，和配置文件里的无关
+const (
+	testAccessKey  = "webook-test-access-key-0123456789abcdef0123456789abcdef"
+	testRefreshKey = "webook-test-refresh-key-0123456789abcdef0123456789abcdef"
+)
+
+func newJWTHandler() ijwt.Handler {
+	return newJWTHandlerWithSessions(cache.NewSessionMemoryCache(freecache.NewCache(1024 * 1024)))
+}
+
+func newJWTHandlerWithSessions(sessions cache.SessionCache) ijwt.Handler {
+	accessKeys, err := ijwt.NewKeySetFromConf([]conf.JwtKeyConf{{Id: "access-hs512-v1", Alg: "HS512", Key: testAccessKey, Legacy: true}})
+	if err != nil {
+		panic(err)
+	}
+	refreshKeys, err := ijwt.NewKeySetFromConf([]conf.JwtKeyConf{{Id: "refresh-hs512-v1", Alg: "HS512", Key: testRefreshKey, Legacy: true}})
+	if err != nil {
+		panic(err)
+	}
+	return ijwt.NewJWTHandler(sessions, accessKeys, refreshKeys)
+}
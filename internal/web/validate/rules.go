@@ -0,0 +1,92 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 15:18:02
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/validate/rules.go
+ * @Description: 自定义校验规则
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package validate
+
+import (
+	"time"
+
+	regexp "github.com/dlclark/regexp2"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	passwordRegexpPattern   = `^(?=.*[a-z])(?=.*[A-Z])(?=.*\d)(?=.*[@$!%*?&_])[A-Za-z\d@$!%*?&_]{8,72}$`
+	emailRegextPattern      = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
+	phoneRegexPattern       = `^1[3-9]\d{9}$`
+	nickNameRegexPattern    = `^[\u4E00-\u9FFFa-zA-Z0-9!@#$%^&*()_+=\-[\]{}|\\:;"'<>,.?/~]{1,64}$`
+	descriptionRegexPattern = `^[\u4E00-\u9FFFa-zA-Z0-9!@#$%^&*()_+=\-[\]{}|\\:;。，？！……「」【】；'<>,.?/~]{1,10240}$`
+)
+
+type rule struct {
+	tag string
+	fn  validator.Func
+	// 提示文案，{0} 是字段名
+	zh string
+	en string
+}
+
+var rules = []rule{
+	{
+		tag: "password",
+		fn:  matchRegexp(passwordRegexpPattern),
+		zh:  "{0}需要8到72位，同时包含大小写字母、数字和特殊字符",
+		en:  "{0} must be 8-72 characters and contain upper and lower case letters, a digit and a special character",
+	},
+	{
+		// 内置的 email 规则允许没有顶级域名的地址，沿用原来更严格的规则
+		tag: "email",
+		fn:  matchRegexp(emailRegextPattern),
+		zh:  "{0}必须是一个有效的邮箱",
+		en:  "{0} must be a valid email address",
+	},
+	{
+		tag: "mobile",
+		fn:  matchRegexp(phoneRegexPattern),
+		zh:  "{0}必须是有效的手机号",
+		en:  "{0} must be a valid mobile number",
+	},
+	{
+		tag: "nickname",
+		fn:  matchRegexp(nickNameRegexPattern),
+		zh:  "{0}只能包含中英文、数字和常用符号，最多64个字符",
+		en:  "{0} may only contain Chinese or English letters, digits and common symbols, up to 64 characters",
+	},
+	{
+		tag: "description",
+		fn:  matchRegexp(descriptionRegexPattern),
+		zh:  "{0}含非法字符",
+		en:  "{0} contains invalid characters",
+	},
+	{
+		tag: "date",
+		fn:  isDate,
+		zh:  "{0}必须是 YYYY-MM-DD 格式的日期",
+		en:  "{0} must be a date in YYYY-MM-DD format",
+	},
+}
+
+/**
+ * @description: 正则规则，用 regexp2 是因为密码规则要用到前瞻
+ * @param {string} pattern
+ * @return {validator.Func}
+ */
+func matchRegexp(pattern string) validator.Func {
+	expersion := regexp.MustCompile(pattern, regexp.None)
+	return func(fl validator.FieldLevel) bool {
+		ok, err := expersion.MatchString(fl.Field().String())
+		return err == nil && ok
+	}
+}
+
+// isDate 格式对但日期不存在的也不行，比如 2023-02-30
+func isDate(fl validator.FieldLevel) bool {
+	_, err := time.ParseInLocation("2006-01-02", fl.Field().String(), time.Local)
+	return err == nil
+}
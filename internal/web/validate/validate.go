@@ -0,0 +1,148 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 15:10:37
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/validate/validate.go
+ * @Description: 请求参数校验，请求结构体上用 validate 标签声明规则
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	zhtrans "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// DefaultLocale 请求没带能识别的语言时用中文提示
+const DefaultLocale = "zh"
+
+// FieldError 单个字段的校验失败原因，Field 是 json 里的字段名
+type FieldError struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+type Validator struct {
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+}
+
+/**
+ * @description: 创建校验器，注册自定义规则和中英文提示
+ * @return {*Validator}
+ */
+func New() *Validator {
+	validate := validator.New()
+	// 提示里用 json 字段名，前端好对应到输入框
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	zhLocale := zh.New()
+	uni := ut.New(zhLocale, zhLocale, en.New())
+	zhTranslator, _ := uni.GetTranslator("zh")
+	enTranslator, _ := uni.GetTranslator("en")
+	// 默认提示只在启动时注册一次，出错说明依赖库有问题，直接 panic
+	if err := zhtrans.RegisterDefaultTranslations(validate, zhTranslator); err != nil {
+		panic(err)
+	}
+	if err := entrans.RegisterDefaultTranslations(validate, enTranslator); err != nil {
+		panic(err)
+	}
+
+	for _, r := range rules {
+		if err := validate.RegisterValidation(r.tag, r.fn); err != nil {
+			panic(err)
+		}
+		if err := registerMessage(validate, zhTranslator, r.tag, r.zh); err != nil {
+			panic(err)
+		}
+		if err := registerMessage(validate, enTranslator, r.tag, r.en); err != nil {
+			panic(err)
+		}
+	}
+
+	return &Validator{
+		validate: validate,
+		uni:      uni,
+	}
+}
+
+/**
+ * @description: 校验请求结构体，不通过时返回所有字段的错误
+ * @param {any} obj 请求结构体指针
+ * @param {...string} locales 按优先级排列的语言，比如 zh-CN、en
+ * @return {[]FieldError, error} 规则本身有问题才返回 error
+ */
+func (v *Validator) Struct(obj any, locales ...string) ([]FieldError, error) {
+	err := v.validate.Struct(obj)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, err
+	}
+
+	trans := v.translator(locales...)
+	fieldErrs := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field: fe.Field(),
+			Msg:   fe.Translate(trans),
+		})
+	}
+	return fieldErrs, nil
+}
+
+/**
+ * @description: 按优先级找第一个支持的语言，zh-CN、en-US 这种按主语言匹配
+ * @param {...string} locales
+ * @return {ut.Translator}
+ */
+func (v *Validator) translator(locales ...string) ut.Translator {
+	for _, locale := range locales {
+		base := strings.ToLower(strings.SplitN(strings.TrimSpace(locale), "-", 2)[0])
+		if trans, ok := v.uni.GetTranslator(base); ok {
+			return trans
+		}
+	}
+	trans, _ := v.uni.GetTranslator(DefaultLocale)
+	return trans
+}
+
+/**
+ * @description: 注册自定义规则的提示文案，{0} 是字段名
+ * @param {*validator.Validate} validate
+ * @param {ut.Translator} trans
+ * @param {string} tag
+ * @param {string} msg
+ * @return {error}
+ */
+func registerMessage(validate *validator.Validate, trans ut.Translator, tag string, msg string) error {
+	return validate.RegisterTranslation(tag, trans, func(ut ut.Translator) error {
+		return ut.Add(tag, msg, true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, err := ut.T(tag, fe.Field())
+		if err != nil {
+			return fe.Error()
+		}
+		return t
+	})
+}
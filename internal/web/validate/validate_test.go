@@ -0,0 +1,81 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 15:46:19
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/internal/web/validate/validate_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidator_Struct(t *testing.T) {
+	type req struct {
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required,password"`
+		NickName string `json:"nick_name" validate:"omitempty,nickname"`
+		BirthDay string `json:"birth_day" validate:"omitempty,date"`
+		Phone    string `json:"phone" validate:"omitempty,mobile"`
+	}
+	tests := []struct {
+		name    string
+		req     req
+		locales []string
+		want    []FieldError
+	}{
+		{
+			name: "正常",
+			req: req{
+				Email:    "gz4z2b@163.com",
+				Password: "Hello@123",
+				NickName: "陈瀚禧",
+				BirthDay: "1989-08-21",
+				Phone:    "13800138000",
+			},
+		},
+		{
+			name: "多个字段一起报",
+			req: req{
+				Email:    "gz4z2b@163",
+				Password: "hello",
+				BirthDay: "2023-02-30",
+			},
+			want: []FieldError{
+				{Field: "email", Msg: "email必须是一个有效的邮箱"},
+				{Field: "password", Msg: "password需要8到72位，同时包含大小写字母、数字和特殊字符"},
+				{Field: "birth_day", Msg: "birth_day必须是 YYYY-MM-DD 格式的日期"},
+			},
+		},
+		{
+			name:    "英文提示",
+			req:     req{Password: "Hello@123", Phone: "12345"},
+			locales: []string{"en-US", "zh"},
+			want: []FieldError{
+				{Field: "email", Msg: "email is a required field"},
+				{Field: "phone", Msg: "phone must be a valid mobile number"},
+			},
+		},
+		{
+			name:    "不支持的语言用中文",
+			req:     req{Email: "gz4z2b@163.com", Password: "Hello@123", NickName: "<script>alert(1)</script> "},
+			locales: []string{"ja-JP"},
+			want: []FieldError{
+				{Field: "nick_name", Msg: "nick_name只能包含中英文、数字和常用符号，最多64个字符"},
+			},
+		},
+	}
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Struct(&tt.req, tt.locales...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
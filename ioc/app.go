@@ -0,0 +1,43 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 21:52:08
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/app.go
+ * @Description: 进程里要跑的服务和后台任务
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	"github.com/gz4z2b/go-webook/internal/service"
+	"github.com/gz4z2b/go-webook/internal/service/sms/async"
+	"github.com/gz4z2b/go-webook/internal/web"
+)
+
+// App web 服务之外还有后台任务，由 main 负责启动和停止
+type App struct {
+	Server *gin.Engine
+	// 停机时先通过它让就绪检查失败
+	Health   *web.HealthHandler
+	PurgeJob *service.UserPurgeJob
+	// 建邮箱布隆过滤器，建好就退出
+	EmailFilterJob *service.EmailFilterJob
+	// 异步短信的后台重试
+	SmsJob *async.Service
+	// 有的缓存方案有后台任务
+	Cache *CacheBackend
+}
+
+func InitUserPurgeJob(repo repository.UserRepository) *service.UserPurgeJob {
+	return service.NewUserPurgeJob(repo, conf.Account.DeletedRetention, conf.Account.PurgeInterval)
+}
+
+func InitEmailFilterJob(repo repository.UserRepository) *service.EmailFilterJob {
+	return service.NewEmailFilterJob(repo, time.Minute)
+}
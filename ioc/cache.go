@@ -3,28 +3,204 @@
  * @Date: 2023-09-15 10:58:25
  * @LastEditors: p_hanxichen
  * @FilePath: /go/src/webook/ioc/cache.go
- * @Description:
+ * @Description: 按配置选择缓存方案
  *
  * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
  */
 package ioc
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/coocood/freecache"
 	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+	"github.com/gz4z2b/go-webook/internal/web"
+	"github.com/gz4z2b/go-webook/pkg/bloom"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
 	"github.com/redis/go-redis/v9"
 )
 
-func InitCache() redis.Cmdable {
-	return redis.NewClient(&redis.Options{
+// CacheBackend 一套缓存方案，所有依赖缓存的组件从这里取，同一个进程里不会混用两套
+type CacheBackend struct {
+	User          cache.UserCache
+	Code          cache.CodeCache
+	Session       cache.SessionCache
+	PasswordReset cache.PasswordResetCache
+	LoginAttempt  cache.LoginAttemptCache
+	SmsLimiter    ratelimit.Limiter
+	NewLimiter    ratelimit.NewLimiterFunc
+	EmailFilter   bloom.Filter
+	// 就绪检查要探测的缓存依赖，数据库不在这里
+	HealthChecks map[string]web.HealthCheck
+	// 后台任务，比如订阅其他实例的缓存失效消息
+	jobs []func(ctx context.Context)
+}
+
+/**
+ * @description: 启动后台任务，ctx 取消后退出
+ * @param {context.Context} ctx
+ * @return {*}
+ */
+func (b *CacheBackend) Start(ctx context.Context) {
+	for _, job := range b.jobs {
+		go job(ctx)
+	}
+}
+
+// CacheProvider 按配置创建一套缓存，依赖连不上要返回错误，启动时就失败
+type CacheProvider func(c conf.CacheConf) (*CacheBackend, error)
+
+var cacheProviders = map[string]CacheProvider{}
+
+func init() {
+	RegisterCacheProvider("redis", newRedisCacheBackend)
+	RegisterCacheProvider("two_level", newTwoLevelCacheBackend)
+	RegisterCacheProvider("memory", newMemoryCacheBackend)
+	RegisterCacheProvider("none", newNoneCacheBackend)
+}
+
+/**
+ * @description: 注册缓存方案，名字就是配置里 cache.backend 的值，重名直接 panic
+ * @param {string} name
+ * @param {CacheProvider} provider
+ * @return {*}
+ */
+func RegisterCacheProvider(name string, provider CacheProvider) {
+	if _, ok := cacheProviders[name]; ok {
+		panic(fmt.Sprintf("缓存方案 %s 重复注册", name))
+	}
+	cacheProviders[name] = provider
+}
+
+/**
+ * @description: 按 conf.Cache.Backend 创建缓存
+ * @return {*CacheBackend, error}
+ */
+func InitCacheBackend() (*CacheBackend, error) {
+	provider, ok := cacheProviders[conf.Cache.Backend]
+	if !ok {
+		names := make([]string, 0, len(cacheProviders))
+		for name := range cacheProviders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("不支持的缓存方案 %s，可选 %s", conf.Cache.Backend, strings.Join(names, "、"))
+	}
+	return provider(conf.Cache)
+}
+
+func InitCache() (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", conf.Redis.Host, conf.Redis.Port),
-		Password: conf.Redis.Password, // no password set
-		DB:       conf.Redis.Db,       // use default DB
+		Password: conf.Redis.Password,
+		DB:       conf.Redis.Db,
 	})
+	// 和数据库一样，启动时连不上直接失败，不要等到第一个请求才发现配错了
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis %s 连不上: %w", client.Options().Addr, err)
+	}
+	return client, nil
 }
 
-func InitMemoryCache() *freecache.Cache {
-	return freecache.NewCache(10 * 1024 * 1034)
+func InitMemoryCache(c conf.CacheConf) *freecache.Cache {
+	return freecache.NewCache(c.LocalSize)
+}
+
+// newRedisCacheBackend 多个实例共用redis
+func newRedisCacheBackend(c conf.CacheConf) (*CacheBackend, error) {
+	cmd, err := InitCache()
+	if err != nil {
+		return nil, err
+	}
+	backend := newRedisStateBackend(cmd, cache.NewUserRedisCache(cmd))
+	backend.EmailFilter = newRedisEmailFilter(c.EmailFilter, cmd)
+	return backend, nil
+}
+
+// newTwoLevelCacheBackend 用户数据在本地再放一份，写了之后通过redis广播让其他实例删掉本地副本
+func newTwoLevelCacheBackend(c conf.CacheConf) (*CacheBackend, error) {
+	client, err := InitCache()
+	if err != nil {
+		return nil, err
+	}
+	user := cache.NewUserTwoLevelCache(InitMemoryCache(c), c.LocalExpiretion, client)
+	backend := newRedisStateBackend(client, user)
+	backend.EmailFilter = newRedisEmailFilter(c.EmailFilter, client)
+	backend.jobs = append(backend.jobs, user.Subscribe)
+	return backend, nil
+}
+
+// newMemoryCacheBackend 全部放本地，不依赖redis，验证码、会话都不共享，只能跑一个实例
+func newMemoryCacheBackend(c conf.CacheConf) (*CacheBackend, error) {
+	client := InitMemoryCache(c)
+	// 作废记录、验证码、登录失败次数被用户数据挤掉的话，退出登录的token又能用了、登录锁定提前解除，各自单独一块
+	sessions := freecache.NewCache(c.SessionLocalSize)
+	codes := freecache.NewCache(c.CodeLocalSize)
+	attempts := freecache.NewCache(c.LoginAttemptLocalSize)
+	return &CacheBackend{
+		User:          cache.NewUserMemoryCache(client),
+		Code:          cache.NewCodeMemoryCache(codes),
+		Session:       cache.NewSessionMemoryCache(sessions),
+		PasswordReset: cache.NewPasswordResetMemoryCache(client),
+		LoginAttempt:  cache.NewLoginAttemptMemoryCache(attempts),
+		SmsLimiter:    InitMemorySmsLimiter(),
+		NewLimiter:    InitMemoryRateLimiter(),
+		EmailFilter:   newLocalEmailFilter(c.EmailFilter),
+		HealthChecks:  map[string]web.HealthCheck{},
+	}, nil
+}
+
+// newNoneCacheBackend 用户数据每次查库，验证码、会话这些没有缓存就不能用的状态还是放redis，也不用布隆过滤器
+func newNoneCacheBackend(c conf.CacheConf) (*CacheBackend, error) {
+	cmd, err := InitCache()
+	if err != nil {
+		return nil, err
+	}
+	return newRedisStateBackend(cmd, cache.NewUserNopCache()), nil
+}
+
+// newRedisEmailFilter 多个实例共用，一个实例注册的邮箱其他实例马上能查到
+func newRedisEmailFilter(c conf.BloomConf, cmd redis.Cmdable) bloom.Filter {
+	if c.Capacity == 0 {
+		return bloom.NewNopFilter()
+	}
+	return bloom.NewRedisFilter(cmd, "webook:user:emailfilter", c.Capacity, c.FalsePositive)
+}
+
+func newLocalEmailFilter(c conf.BloomConf) bloom.Filter {
+	if c.Capacity == 0 {
+		return bloom.NewNopFilter()
+	}
+	return bloom.NewLocalFilter(c.Capacity, c.FalsePositive)
+}
+
+/**
+ * @description: 用户缓存之外的都放redis
+ * @param {redis.Cmdable} cmd
+ * @param {cache.UserCache} user
+ * @return {*CacheBackend}
+ */
+func newRedisStateBackend(cmd redis.Cmdable, user cache.UserCache) *CacheBackend {
+	return &CacheBackend{
+		User:          user,
+		Code:          cache.NewCodeRedisCache(cmd),
+		Session:       cache.NewSessionRedisCache(cmd),
+		PasswordReset: cache.NewPasswordResetRedisCache(cmd),
+		LoginAttempt:  cache.NewLoginAttemptRedisCache(cmd),
+		SmsLimiter:    InitSmsLimiter(cmd),
+		NewLimiter:    InitRateLimiter(cmd),
+		EmailFilter:   bloom.NewNopFilter(),
+		HealthChecks: map[string]web.HealthCheck{
+			"redis": func(ctx context.Context) error {
+				return cmd.Ping(ctx).Err()
+			},
+		},
+	}
 }
@@ -0,0 +1,88 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 15:40:21
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/cache_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/repository/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitCacheBackend(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		wantErr string
+	}{
+		{
+			name:    "本地缓存",
+			backend: "memory",
+		},
+		{
+			name:    "没有这个方案",
+			backend: "memcached",
+			wantErr: "不支持的缓存方案 memcached，可选 memory、none、redis、two_level",
+		},
+	}
+	origin := conf.Cache
+	defer func() {
+		conf.Cache = origin
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf.Cache = conf.CacheConf{Backend: tt.backend, LocalSize: 1024 * 1024, SessionLocalSize: 1024 * 1024,
+				CodeLocalSize: 1024 * 1024, LoginAttemptLocalSize: 1024 * 1024}
+			backend, err := InitCacheBackend()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, backend.User)
+			assert.NotNil(t, backend.Session)
+			assert.NotNil(t, backend.NewLimiter)
+		})
+	}
+}
+
+func TestNewMemoryCacheBackend_StateIsolated(t *testing.T) {
+	backend, err := newMemoryCacheBackend(conf.CacheConf{LocalSize: 512 * 1024, SessionLocalSize: 512 * 1024,
+		CodeLocalSize: 512 * 1024, LoginAttemptLocalSize: 512 * 1024})
+	assert.NoError(t, err)
+	ctx := context.Background()
+	assert.NoError(t, backend.Session.Revoke(ctx, "ssid-1", time.Hour))
+	assert.NoError(t, backend.Code.Set(ctx, "login", "13800138000", "123456"))
+	_, err = backend.LoginAttempt.Incr(ctx, "account:1", time.Now(), time.Hour)
+	assert.NoError(t, err)
+
+	// 用户数据把本地缓存写满好几遍，作废记录、验证码、失败次数都还在
+	for i := uint64(1); i <= 20000; i++ {
+		assert.NoError(t, backend.User.SetUser(ctx, dao.User{Id: i}))
+	}
+	revoked, err := backend.Session.IsRevoked(ctx, "ssid-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	ok, err := backend.Code.Verify(ctx, "login", "13800138000", "123456")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	failures, _, err := backend.LoginAttempt.Get(ctx, "account:1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures)
+}
+
+func TestRegisterCacheProvider(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterCacheProvider("redis", newRedisCacheBackend)
+	})
+}
@@ -0,0 +1,43 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 18:48:05
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/email.go
+ * @Description: 初始化邮件服务
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	"github.com/gz4z2b/go-webook/internal/service"
+	"github.com/gz4z2b/go-webook/internal/service/email"
+	"github.com/gz4z2b/go-webook/internal/service/email/memory"
+	"github.com/gz4z2b/go-webook/internal/service/email/smtp"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+)
+
+func InitEmailService() email.Service {
+	if conf.Email.Backend == "log" {
+		return memory.NewService()
+	}
+	return smtp.NewService(conf.Email.Host, conf.Email.Port, conf.Email.Username, conf.Email.Password, conf.Email.From)
+}
+
+// InitEmailThrottle 同一个邮箱同一种邮件 conf.Auth.EmailSendInterval 内只发一封，计数跟着缓存方案走
+func InitEmailThrottle(newLimiter ratelimit.NewLimiterFunc) *service.EmailThrottle {
+	return service.NewEmailThrottle(newLimiter(conf.Auth.EmailSendInterval, 1))
+}
+
+func InitPasswordService(userRepo repository.UserRepository, resetRepo repository.PasswordResetRepository,
+	attemptRepo repository.LoginAttemptRepository, emailSvc email.Service, throttle *service.EmailThrottle) service.PasswordService {
+	return service.NewPasswordService(userRepo, resetRepo, attemptRepo, emailSvc, throttle, conf.Keys.ResetPasswordKey,
+		conf.Auth.ResetPasswordUrl, conf.Auth.ResetPasswordExpiretion)
+}
+
+func InitEmailVerifyService(userRepo repository.UserRepository, emailSvc email.Service, throttle *service.EmailThrottle) service.EmailVerifyService {
+	return service.NewEmailVerifyService(userRepo, emailSvc, throttle, conf.Keys.VerifyEmailKey,
+		conf.Auth.VerifyEmailUrl, conf.Auth.VerifyEmailExpiretion)
+}
@@ -0,0 +1,39 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 09:40:18
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/health.go
+ * @Description: 就绪检查要探测的依赖
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"context"
+
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/web"
+	"gorm.io/gorm"
+)
+
+// InitHealthHandler 数据库之外还要探测缓存方案用到的依赖
+func InitHealthHandler(db *gorm.DB, backend *CacheBackend) *web.HealthHandler {
+	checks := map[string]web.HealthCheck{
+		"mysql": pingDb(db),
+	}
+	for name, check := range backend.HealthChecks {
+		checks[name] = check
+	}
+	return web.NewHealthHandler(checks, conf.Server.ReadyTimeout)
+}
+
+func pingDb(db *gorm.DB) web.HealthCheck {
+	return func(ctx context.Context) error {
+		sqlDb, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDb.PingContext(ctx)
+	}
+}
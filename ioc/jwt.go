@@ -0,0 +1,30 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 22:30:41
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/jwt.go
+ * @Description: 登录token初始化
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"fmt"
+
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
+)
+
+func InitJWTHandler(sessionCache cache.SessionCache) (ijwt.Handler, error) {
+	accessKeys, err := ijwt.NewKeySetFromConf(conf.Jwt.AccessKeys)
+	if err != nil {
+		return nil, fmt.Errorf("短token签名key初始化失败: %w", err)
+	}
+	refreshKeys, err := ijwt.NewKeySetFromConf(conf.Jwt.RefreshKeys)
+	if err != nil {
+		return nil, fmt.Errorf("长token签名key初始化失败: %w", err)
+	}
+	return ijwt.NewJWTHandler(sessionCache, accessKeys, refreshKeys), nil
+}
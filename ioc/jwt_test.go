@@ -0,0 +1,61 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 22:18:06
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/jwt_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coocood/freecache"
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/repository/cache"
+	"github.com/gz4z2b/go-webook/internal/web/ijwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitJWTHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		jwt     conf.JwtConf
+		wantErr bool
+	}{
+		{
+			name: "正常",
+			jwt: conf.JwtConf{
+				AccessKeys:  []conf.JwtKeyConf{{Id: "access-v1", Alg: "HS512", Key: "access-key-0123456789abcdef0123456789abcdef"}},
+				RefreshKeys: []conf.JwtKeyConf{{Id: "refresh-v1", Alg: "HS512", Key: "refresh-key-0123456789abcdef0123456789abcdef"}},
+			},
+		},
+		{
+			name: "key不对返回错误不panic",
+			jwt: conf.JwtConf{
+				AccessKeys:  []conf.JwtKeyConf{{Id: "access-v1", Alg: "RS256", Key: "not a pem"}},
+				RefreshKeys: []conf.JwtKeyConf{{Id: "refresh-v1", Alg: "HS512", Key: "refresh-key-0123456789abcdef0123456789abcdef"}},
+			},
+			wantErr: true,
+		},
+	}
+	origin := conf.Jwt
+	defer func() {
+		conf.Jwt = origin
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf.Jwt = tt.jwt
+			hdl, err := InitJWTHandler(cache.NewSessionMemoryCache(freecache.NewCache(512 * 1024)))
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ijwt.ErrKeyInvalid))
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, hdl)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 22:52:31
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/login.go
+ * @Description: 初始化登录防爆破
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	"github.com/gz4z2b/go-webook/internal/service"
+)
+
+func InitLoginAttemptService(repo repository.LoginAttemptRepository) service.LoginAttemptService {
+	svc := service.NewLoginAttemptService(repo, loginPolicy(conf.LoginLimit))
+	conf.OnReload(func(old *conf.Config, cur *conf.Config) {
+		if old.LoginLimit != cur.LoginLimit {
+			svc.UpdatePolicy(loginPolicy(cur.LoginLimit))
+		}
+	})
+	return svc
+}
+
+func loginPolicy(c conf.LoginLimitConf) service.LoginPolicy {
+	return service.LoginPolicy{
+		DelayAfter:         c.DelayAfter,
+		BaseDelay:          c.BaseDelay,
+		MaxDelay:           c.MaxDelay,
+		AccountMaxFailures: c.AccountMaxFailures,
+		IpMaxFailures:      c.IpMaxFailures,
+		Lockout:            c.Lockout,
+		Window:             c.Window,
+	}
+}
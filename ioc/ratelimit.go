@@ -0,0 +1,34 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 23:52:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/ratelimit.go
+ * @Description: 接口限流器初始化
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"time"
+
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// InitRateLimiter 多个实例共用redis里的窗口，redis 不可用时各自用本地令牌桶
+func InitRateLimiter(cmd redis.Cmdable) ratelimit.NewLimiterFunc {
+	return func(window time.Duration, threshold int) ratelimit.Limiter {
+		return ratelimit.NewFallbackLimiter(
+			ratelimit.NewRedisSlidingWindowLimiter(cmd, window, threshold),
+			ratelimit.NewLocalTokenBucketLimiter(window, threshold),
+			conf.RateLimit.FallbackCooldown,
+		)
+	}
+}
+
+// InitMemoryRateLimiter 不依赖redis的部署方式用单机窗口
+func InitMemoryRateLimiter() ratelimit.NewLimiterFunc {
+	return ratelimit.NewLocalSlidingWindowLimiter
+}
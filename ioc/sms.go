@@ -0,0 +1,85 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 11:20:48
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/ioc/sms.go
+ * @Description: 短信服务初始化
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ioc
+
+import (
+	"net/http"
+
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/internal/repository"
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	"github.com/gz4z2b/go-webook/internal/service/sms/aliyun"
+	"github.com/gz4z2b/go-webook/internal/service/sms/async"
+	"github.com/gz4z2b/go-webook/internal/service/sms/failover"
+	"github.com/gz4z2b/go-webook/internal/service/sms/memory"
+	"github.com/gz4z2b/go-webook/internal/service/sms/tencent"
+	"github.com/gz4z2b/go-webook/pkg/ratelimit"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	tencentsms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
+)
+
+// InitSmsService 后台重试由 main 调 Start 启动
+func InitSmsService(repo repository.AsyncSmsRepository, limiter ratelimit.Limiter) *async.Service {
+	return async.NewService(initProviderSmsService(), repo, limiter, conf.Sms.RetryMax)
+}
+
+// InitSmsLimiter 多个实例共用redis里的窗口
+func InitSmsLimiter(cmd redis.Cmdable) ratelimit.Limiter {
+	return ratelimit.NewRedisSlidingWindowLimiter(cmd, conf.Sms.LimitWindow, conf.Sms.LimitThreshold)
+}
+
+// InitMemorySmsLimiter 不依赖redis的部署方式用单机窗口
+func InitMemorySmsLimiter() ratelimit.Limiter {
+	return ratelimit.NewLocalSlidingWindowLimiter(conf.Sms.LimitWindow, conf.Sms.LimitThreshold)
+}
+
+func initProviderSmsService() sms.Service {
+	if conf.Sms.Backend == "log" {
+		return memory.NewService()
+	}
+	var svcs []sms.Service
+	if conf.TencentSms.SecretId != "" {
+		svcs = append(svcs, initTencentSmsService())
+	}
+	if conf.AliyunSms.AccessKeyId != "" {
+		svcs = append(svcs, initAliyunSmsService())
+	}
+	// conf.Validate 保证了至少有一家
+	if len(svcs) == 1 {
+		return svcs[0]
+	}
+	if conf.Sms.Strategy == "timeout_failover" {
+		return failover.NewTimeoutFailoverService(svcs, conf.Sms.Threshold, conf.Sms.Timeout)
+	}
+	return failover.NewFailoverService(svcs, conf.Sms.Timeout)
+}
+
+func initTencentSmsService() sms.Service {
+	credential := common.NewCredential(conf.TencentSms.SecretId, conf.TencentSms.SecretKey)
+	client, err := tencentsms.NewClient(credential, conf.TencentSms.Region, profile.NewClientProfile())
+	if err != nil {
+		panic("腾讯云短信初始化失败")
+	}
+	return tencent.NewService(client, conf.TencentSms.AppId, conf.TencentSms.SignName)
+}
+
+func initAliyunSmsService() sms.Service {
+	templates := make(map[string]aliyun.Template, len(conf.AliyunSms.Templates))
+	for tpl, template := range conf.AliyunSms.Templates {
+		templates[tpl] = aliyun.Template{
+			Code:   template.Code,
+			Params: template.Params,
+		}
+	}
+	return aliyun.NewService(http.DefaultClient, conf.AliyunSms.AccessKeyId, conf.AliyunSms.AccessKeySecret,
+		conf.AliyunSms.SignName, templates)
+}
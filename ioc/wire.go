@@ -12,43 +12,35 @@
 package ioc
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
 	"github.com/gz4z2b/go-webook/internal/repository"
-	"github.com/gz4z2b/go-webook/internal/repository/cache"
 	"github.com/gz4z2b/go-webook/internal/repository/dao"
 	"github.com/gz4z2b/go-webook/internal/service"
+	"github.com/gz4z2b/go-webook/internal/service/sms"
+	"github.com/gz4z2b/go-webook/internal/service/sms/async"
 	"github.com/gz4z2b/go-webook/internal/web"
 )
 
-func InitWebService() *gin.Engine {
+// InitWebService 缓存用哪套由 conf.Cache.Backend 决定，配错了或者依赖连不上返回错误
+func InitWebService() (*App, error) {
 	wire.Build(
 		// db层
-		InitDb, InitCache,
-		cache.NewUserRedisCache, dao.NewUseMysqlDAO,
+		InitDb, InitCacheBackend,
+		wire.FieldsOf(new(*CacheBackend), "User", "Code", "Session", "PasswordReset", "LoginAttempt",
+			"SmsLimiter", "NewLimiter", "EmailFilter"),
+		dao.NewUseMysqlDAO, dao.NewAsyncSmsMysqlDAO,
 		// repository
-		repository.NewCachedUserRepository,
+		repository.NewCachedUserRepository, repository.NewCachedCodeRepository, repository.NewAsyncSmsRepository,
+		repository.NewCachedPasswordResetRepository, repository.NewCachedLoginAttemptRepository,
 		// service
-		service.NewUserService,
+		InitSmsService, wire.Bind(new(sms.Service), new(*async.Service)),
+		InitEmailService, InitEmailThrottle, InitPasswordService, InitEmailVerifyService, InitLoginAttemptService,
+		service.NewUserService, service.NewCodeService,
+		InitUserPurgeJob, InitEmailFilterJob,
 		// web
-		web.NewUserHandler,
+		InitJWTHandler, web.NewUserHandler, InitHealthHandler,
 		web.InitWebService, web.InitUserMidleware,
+		wire.Struct(new(App), "*"),
 	)
-	return new(gin.Engine)
-}
-
-func InitDownCacheWebService() *gin.Engine {
-	wire.Build(
-		// db层
-		InitDb, InitMemoryCache,
-		cache.NewUserMemoryCache, dao.NewUseMysqlDAO,
-		// repository
-		repository.NewCachedUserRepository,
-		// service
-		service.NewUserService,
-		// web
-		web.NewUserHandler,
-		web.InitWebService, web.InitUserMidleware,
-	)
-	return new(gin.Engine)
+	return new(App), nil
 }
@@ -7,9 +7,7 @@
 package ioc
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/gz4z2b/go-webook/internal/repository"
-	"github.com/gz4z2b/go-webook/internal/repository/cache"
 	"github.com/gz4z2b/go-webook/internal/repository/dao"
 	"github.com/gz4z2b/go-webook/internal/service"
 	"github.com/gz4z2b/go-webook/internal/web"
@@ -17,28 +15,53 @@ import (
 
 // Injectors from wire.go:
 
-func InitWebService() *gin.Engine {
+// InitWebService 缓存用哪套由 conf.Cache.Backend 决定，配错了或者依赖连不上返回错误
+func InitWebService() (*App, error) {
 	db := InitDb()
 	userDAO := dao.NewUseMysqlDAO(db)
-	cmdable := InitCache()
-	userCache := cache.NewUserRedisCache(cmdable)
-	userRepository := repository.NewCachedUserRepository(userDAO, userCache)
+	cacheBackend, err := InitCacheBackend()
+	if err != nil {
+		return nil, err
+	}
+	userCache := cacheBackend.User
+	filter := cacheBackend.EmailFilter
+	userRepository := repository.NewCachedUserRepository(userDAO, userCache, filter)
 	userService := service.NewUserService(userRepository)
-	userHandler := web.NewUserHandler(userService)
-	v := web.InitUserMidleware()
-	engine := web.InitWebService(userHandler, v)
-	return engine
-}
-
-func InitDownCacheWebService() *gin.Engine {
-	db := InitDb()
-	userDAO := dao.NewUseMysqlDAO(db)
-	freecacheCache := InitMemoryCache()
-	userCache := cache.NewUserMemoryCache(freecacheCache)
-	userRepository := repository.NewCachedUserRepository(userDAO, userCache)
-	userService := service.NewUserService(userRepository)
-	userHandler := web.NewUserHandler(userService)
-	v := web.InitUserMidleware()
-	engine := web.InitWebService(userHandler, v)
-	return engine
+	codeCache := cacheBackend.Code
+	codeRepository := repository.NewCachedCodeRepository(codeCache)
+	asyncSmsDAO := dao.NewAsyncSmsMysqlDAO(db)
+	asyncSmsRepository := repository.NewAsyncSmsRepository(asyncSmsDAO)
+	limiter := cacheBackend.SmsLimiter
+	asyncService := InitSmsService(asyncSmsRepository, limiter)
+	emailService := InitEmailService()
+	codeService := service.NewCodeService(codeRepository, asyncService, emailService)
+	passwordResetCache := cacheBackend.PasswordReset
+	passwordResetRepository := repository.NewCachedPasswordResetRepository(passwordResetCache)
+	loginAttemptCache := cacheBackend.LoginAttempt
+	loginAttemptRepository := repository.NewCachedLoginAttemptRepository(loginAttemptCache)
+	newLimiterFunc := cacheBackend.NewLimiter
+	emailThrottle := InitEmailThrottle(newLimiterFunc)
+	passwordService := InitPasswordService(userRepository, passwordResetRepository, loginAttemptRepository, emailService, emailThrottle)
+	emailVerifyService := InitEmailVerifyService(userRepository, emailService, emailThrottle)
+	loginAttemptService := InitLoginAttemptService(loginAttemptRepository)
+	sessionCache := cacheBackend.Session
+	handler, err := InitJWTHandler(sessionCache)
+	if err != nil {
+		return nil, err
+	}
+	userHandler := web.NewUserHandler(userService, codeService, passwordService, emailVerifyService, loginAttemptService, handler)
+	healthHandler := InitHealthHandler(db, cacheBackend)
+	v := web.InitUserMidleware(handler, newLimiterFunc)
+	engine := web.InitWebService(userHandler, healthHandler, v)
+	userPurgeJob := InitUserPurgeJob(userRepository)
+	emailFilterJob := InitEmailFilterJob(userRepository)
+	app := &App{
+		Server:         engine,
+		Health:         healthHandler,
+		PurgeJob:       userPurgeJob,
+		EmailFilterJob: emailFilterJob,
+		SmsJob:         asyncService,
+		Cache:          cacheBackend,
+	}
+	return app, nil
 }
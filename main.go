@@ -9,9 +9,64 @@
  */
 package main
 
-import "github.com/gz4z2b/go-webook/ioc"
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gz4z2b/go-webook/conf"
+	"github.com/gz4z2b/go-webook/ioc"
+)
 
 func main() {
-	server := ioc.InitDownCacheWebService()
-	server.Run(":8080")
+	loader, err := conf.Init(os.Args[1:])
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	app, err := ioc.InitWebService()
+	if err != nil {
+		log.Fatalf("初始化服务失败: %v", err)
+	}
+
+	// k8s 滚动发布时发 SIGTERM，本地 ctrl+c 是 SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	go loader.Watch(ctx)
+	go app.PurgeJob.Start(ctx)
+	go app.EmailFilterJob.Start(ctx)
+	go app.SmsJob.Start(ctx)
+	app.Cache.Start(ctx)
+
+	server := &http.Server{
+		Addr:    conf.Server.Addr,
+		Handler: app.Server,
+	}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("服务启动失败: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	// 恢复默认的信号处理，停机卡住了再按一次 ctrl+c 能直接退出
+	stop()
+	log.Println("开始停机")
+
+	// 先让就绪检查失败，等 k8s 把这个实例从 service 里摘掉，不然摘掉之前还会有新请求打进来
+	app.Health.Drain()
+	time.Sleep(conf.Server.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("停机超时，还有请求没处理完: %v", err)
+		return
+	}
+	log.Println("停机完成")
 }
@@ -0,0 +1,53 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 20:14:02
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/bloom/hash.go
+ * @Description: 位数、哈希函数个数和每个元素对应的位
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+/**
+ * @description: 按预计元素个数和误判率算位数和哈希函数个数
+ * @param {uint64} capacity 预计元素个数，超过之后误判率会变高
+ * @param {float64} falsePositive 比如 0.01
+ * @return {uint64, uint64} 位数和哈希函数个数
+ */
+func Size(capacity uint64, falsePositive float64) (uint64, uint64) {
+	n := float64(capacity)
+	bits := math.Ceil(-n * math.Log(falsePositive) / (math.Ln2 * math.Ln2))
+	hashes := math.Round(bits / n * math.Ln2)
+	if hashes < 1 {
+		hashes = 1
+	}
+	return uint64(bits), uint64(hashes)
+}
+
+/**
+ * @description: 两个哈希组合出 hashes 个位置，第0位留给就绪标记，位置从1开始
+ * @param {string} item
+ * @param {uint64} bits
+ * @param {uint64} hashes
+ * @return {[]uint64}
+ */
+func locations(item string, bits uint64, hashes uint64) []uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(item))
+	h1 := h.Sum64()
+	h = fnv.New64()
+	_, _ = h.Write([]byte(item))
+	// 奇数才不会在 bits 是偶数时只落在一半的位上
+	h2 := h.Sum64() | 1
+	res := make([]uint64, hashes)
+	for i := uint64(0); i < hashes; i++ {
+		res[i] = 1 + (h1+i*h2)%bits
+	}
+	return res
+}
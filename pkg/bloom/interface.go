@@ -0,0 +1,25 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 20:10:36
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/bloom/interface.go
+ * @Description: 布隆过滤器接口
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package bloom
+
+import "context"
+
+type Filter interface {
+	// Add 加进去之后 MightContain 一定返回 true，加不了删
+	Add(ctx context.Context, items ...string) error
+	// MightContain 返回 false 表示一定没加过；MarkReady 之前一律返回 true
+	MightContain(ctx context.Context, item string) (bool, error)
+	// MarkReady 全量数据都加进去之后调用，之后才会判定不存在
+	MarkReady(ctx context.Context) error
+	// MarkNotReady 有数据没加进去时调用，回到 MarkReady 之前的状态，等重新全量加一遍
+	MarkNotReady(ctx context.Context) error
+	// Ready 返回 false 表示要重新全量加一遍再 MarkReady
+	Ready(ctx context.Context) (bool, error)
+}
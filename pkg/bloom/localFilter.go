@@ -0,0 +1,78 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 20:30:15
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/bloom/localFilter.go
+ * @Description: 本地内存的布隆过滤器，只能跑一个实例的时候用
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package bloom
+
+import (
+	"context"
+	"sync"
+)
+
+type LocalFilter struct {
+	lock sync.RWMutex
+	// 第0位不用，和 redis 的位置保持一致
+	words  []uint64
+	bits   uint64
+	hashes uint64
+	ready  bool
+}
+
+func NewLocalFilter(capacity uint64, falsePositive float64) Filter {
+	bits, hashes := Size(capacity, falsePositive)
+	return &LocalFilter{
+		words:  make([]uint64, bits/64+1),
+		bits:   bits,
+		hashes: hashes,
+	}
+}
+
+func (f *LocalFilter) Add(ctx context.Context, items ...string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, item := range items {
+		for _, loc := range locations(item, f.bits, f.hashes) {
+			f.words[loc/64] |= 1 << (loc % 64)
+		}
+	}
+	return nil
+}
+
+func (f *LocalFilter) MightContain(ctx context.Context, item string) (bool, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if !f.ready {
+		return true, nil
+	}
+	for _, loc := range locations(item, f.bits, f.hashes) {
+		if f.words[loc/64]&(1<<(loc%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (f *LocalFilter) MarkReady(ctx context.Context) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.ready = true
+	return nil
+}
+
+func (f *LocalFilter) MarkNotReady(ctx context.Context) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.ready = false
+	return nil
+}
+
+func (f *LocalFilter) Ready(ctx context.Context) (bool, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.ready, nil
+}
@@ -0,0 +1,101 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 20:41:09
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/bloom/localFilter_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSize(t *testing.T) {
+	bits, hashes := Size(1000000, 0.01)
+	// 一百万个元素 1% 误判率大约 1.2MB、7个哈希
+	assert.Equal(t, uint64(9585059), bits)
+	assert.Equal(t, uint64(7), hashes)
+}
+
+func TestLocalFilter_MightContain(t *testing.T) {
+	tests := []struct {
+		name  string
+		ready bool
+		// 就绪之后又清掉标记
+		notReady bool
+		item     string
+		want     bool
+	}{
+		{
+			name:  "加过的",
+			ready: true,
+			item:  "user-1@163.com",
+			want:  true,
+		},
+		{
+			name:  "没加过的",
+			ready: true,
+			item:  "nobody@163.com",
+			want:  false,
+		},
+		{
+			name: "还没就绪",
+			item: "nobody@163.com",
+			want: true,
+		},
+		{
+			name:     "清掉就绪标记",
+			ready:    true,
+			notReady: true,
+			item:     "nobody@163.com",
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := NewLocalFilter(1000, 0.01)
+			for i := 0; i < 100; i++ {
+				assert.NoError(t, f.Add(ctx, fmt.Sprintf("user-%d@163.com", i)))
+			}
+			if tt.ready {
+				assert.NoError(t, f.MarkReady(ctx))
+			}
+			if tt.notReady {
+				assert.NoError(t, f.MarkNotReady(ctx))
+			}
+			ready, err := f.Ready(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ready && !tt.notReady, ready)
+			got, err := f.MightContain(ctx, tt.item)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLocalFilter_FalsePositive(t *testing.T) {
+	ctx := context.Background()
+	f := NewLocalFilter(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		_ = f.Add(ctx, fmt.Sprintf("user-%d@163.com", i))
+	}
+	_ = f.MarkReady(ctx)
+	misses := 0
+	for i := 0; i < 10000; i++ {
+		ok, _ := f.MightContain(ctx, fmt.Sprintf("user-%d@163.com", i))
+		assert.True(t, ok)
+		if ok, _ = f.MightContain(ctx, fmt.Sprintf("other-%d@qq.com", i)); ok {
+			misses++
+		}
+	}
+	// 装满之后误判率应该在 1% 附近
+	assert.Less(t, misses, 200)
+}
@@ -0,0 +1,6 @@
+-- ARGV 是所有要置1的位
+local key = KEYS[1]
+for i = 1, #ARGV do
+    redis.call('SETBIT', key, ARGV[i], 1)
+end
+return 0
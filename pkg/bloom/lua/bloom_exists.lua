@@ -0,0 +1,11 @@
+-- 第0位是就绪标记，没就绪一律当作可能存在
+local key = KEYS[1]
+if redis.call('GETBIT', key, 0) == 0 then
+    return 1
+end
+for i = 1, #ARGV do
+    if redis.call('GETBIT', key, ARGV[i]) == 0 then
+        return 0
+    end
+end
+return 1
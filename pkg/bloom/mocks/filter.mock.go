@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./pkg/bloom/interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./pkg/bloom/interface.go -package=bloommocks -destination=./pkg/bloom/mocks/filter.mock.go
+//
+// Package bloommocks is a generated GoMock package.
+package bloommocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFilter is a mock of Filter interface.
+type MockFilter struct {
+	ctrl     *gomock.Controller
+	recorder *MockFilterMockRecorder
+}
+
+// MockFilterMockRecorder is the mock recorder for MockFilter.
+type MockFilterMockRecorder struct {
+	mock *MockFilter
+}
+
+// NewMockFilter creates a new mock instance.
+func NewMockFilter(ctrl *gomock.Controller) *MockFilter {
+	mock := &MockFilter{ctrl: ctrl}
+	mock.recorder = &MockFilterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFilter) EXPECT() *MockFilterMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockFilter) Add(ctx context.Context, items ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range items {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Add", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockFilterMockRecorder) Add(ctx any, items ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, items...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockFilter)(nil).Add), varargs...)
+}
+
+// MarkNotReady mocks base method.
+func (m *MockFilter) MarkNotReady(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotReady", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotReady indicates an expected call of MarkNotReady.
+func (mr *MockFilterMockRecorder) MarkNotReady(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotReady", reflect.TypeOf((*MockFilter)(nil).MarkNotReady), ctx)
+}
+
+// MarkReady mocks base method.
+func (m *MockFilter) MarkReady(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkReady", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkReady indicates an expected call of MarkReady.
+func (mr *MockFilterMockRecorder) MarkReady(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkReady", reflect.TypeOf((*MockFilter)(nil).MarkReady), ctx)
+}
+
+// MightContain mocks base method.
+func (m *MockFilter) MightContain(ctx context.Context, item string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MightContain", ctx, item)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MightContain indicates an expected call of MightContain.
+func (mr *MockFilterMockRecorder) MightContain(ctx, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MightContain", reflect.TypeOf((*MockFilter)(nil).MightContain), ctx, item)
+}
+
+// Ready mocks base method.
+func (m *MockFilter) Ready(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ready", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Ready indicates an expected call of Ready.
+func (mr *MockFilterMockRecorder) Ready(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ready", reflect.TypeOf((*MockFilter)(nil).Ready), ctx)
+}
@@ -0,0 +1,40 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 20:33:40
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/bloom/nopFilter.go
+ * @Description: 不过滤，关掉布隆过滤器的时候用
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package bloom
+
+import "context"
+
+// NopFilter 一律当作可能存在
+type NopFilter struct{}
+
+func NewNopFilter() Filter {
+	return NopFilter{}
+}
+
+func (NopFilter) Add(ctx context.Context, items ...string) error {
+	return nil
+}
+
+func (NopFilter) MightContain(ctx context.Context, item string) (bool, error) {
+	return true, nil
+}
+
+func (NopFilter) MarkReady(ctx context.Context) error {
+	return nil
+}
+
+func (NopFilter) MarkNotReady(ctx context.Context) error {
+	return nil
+}
+
+// Ready 没有要建的，一直是好的
+func (NopFilter) Ready(ctx context.Context) (bool, error) {
+	return true, nil
+}
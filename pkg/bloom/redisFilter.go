@@ -0,0 +1,93 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 20:21:47
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/bloom/redisFilter.go
+ * @Description: 基于redis位图的布隆过滤器，多个实例共用
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package bloom
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/bloom_add.lua
+var luaBloomAdd string
+
+//go:embed lua/bloom_exists.lua
+var luaBloomExists string
+
+// RedisFilter 就绪标记和数据在同一个key的第0位，key 被淘汰了自然变回没就绪，不会误判不存在
+type RedisFilter struct {
+	cmd    redis.Cmdable
+	key    string
+	bits   uint64
+	hashes uint64
+}
+
+/**
+ * @description: 创建redis布隆过滤器
+ * @param {redis.Cmdable} cmd
+ * @param {string} key 会带上位数和哈希函数个数，改了容量或误判率就换一个新key重新建
+ * @param {uint64} capacity
+ * @param {float64} falsePositive
+ * @return {Filter}
+ */
+func NewRedisFilter(cmd redis.Cmdable, key string, capacity uint64, falsePositive float64) Filter {
+	bits, hashes := Size(capacity, falsePositive)
+	return &RedisFilter{
+		cmd:    cmd,
+		key:    fmt.Sprintf("%s:%d:%d", key, bits, hashes),
+		bits:   bits,
+		hashes: hashes,
+	}
+}
+
+func (f *RedisFilter) Add(ctx context.Context, items ...string) error {
+	if len(items) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(items)*int(f.hashes))
+	for _, item := range items {
+		for _, loc := range locations(item, f.bits, f.hashes) {
+			args = append(args, loc)
+		}
+	}
+	return f.cmd.Eval(ctx, luaBloomAdd, []string{f.key}, args...).Err()
+}
+
+func (f *RedisFilter) MightContain(ctx context.Context, item string) (bool, error) {
+	locs := locations(item, f.bits, f.hashes)
+	args := make([]any, 0, len(locs))
+	for _, loc := range locs {
+		args = append(args, loc)
+	}
+	res, err := f.cmd.Eval(ctx, luaBloomExists, []string{f.key}, args...).Int()
+	if err != nil {
+		return true, err
+	}
+	return res == 1, nil
+}
+
+func (f *RedisFilter) MarkReady(ctx context.Context) error {
+	return f.cmd.SetBit(ctx, f.key, 0, 1).Err()
+}
+
+// MarkNotReady 只清就绪标记，已经加进去的位留着，所有实例马上都不再判定不存在
+func (f *RedisFilter) MarkNotReady(ctx context.Context) error {
+	return f.cmd.SetBit(ctx, f.key, 0, 0).Err()
+}
+
+func (f *RedisFilter) Ready(ctx context.Context) (bool, error) {
+	bit, err := f.cmd.GetBit(ctx, f.key, 0).Result()
+	if err != nil {
+		return false, err
+	}
+	return bit == 1, nil
+}
@@ -0,0 +1,132 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 20:48:33
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/bloom/redisFilter_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package bloom
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	redismocks "github.com/gz4z2b/go-webook/internal/repository/cache/mocks/redismocks"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRedisFilter_MightContain(t *testing.T) {
+	const key = "webook:user:email:bloom:9585059:7"
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) redis.Cmdable
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "可能存在",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(1))
+				mock.EXPECT().Eval(gomock.Any(), luaBloomExists, []string{key}, gomock.Any(), gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			want: true,
+		},
+		{
+			name: "一定不存在",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(0))
+				mock.EXPECT().Eval(gomock.Any(), luaBloomExists, []string{key}, gomock.Any(), gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			want: false,
+		},
+		{
+			name: "缓存炸了当作可能存在",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetErr(errors.New("缓存炸了"))
+				mock.EXPECT().Eval(gomock.Any(), luaBloomExists, gomock.Any(), gomock.Any(), gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			want:    true,
+			wantErr: errors.New("缓存炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			f := NewRedisFilter(tt.mock(ctrl), "webook:user:email:bloom", 1000000, 0.01)
+			got, err := f.MightContain(context.Background(), "gz4z2b@163.com")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRedisFilter_Add(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mock := redismocks.NewMockCmdable(ctrl)
+	// 两个元素各7个位，和本地过滤器算出来的位置一样
+	var args []any
+	for _, item := range []string{"a@163.com", "b@163.com"} {
+		for _, loc := range locations(item, 9585059, 7) {
+			args = append(args, loc)
+		}
+	}
+	mock.EXPECT().Eval(gomock.Any(), luaBloomAdd, []string{"webook:user:email:bloom:9585059:7"}, args...).
+		Return(redis.NewCmd(context.Background()))
+	mock.EXPECT().SetBit(gomock.Any(), "webook:user:email:bloom:9585059:7", int64(0), 1).
+		Return(redis.NewIntCmd(context.Background()))
+
+	f := NewRedisFilter(mock, "webook:user:email:bloom", 1000000, 0.01)
+	assert.NoError(t, f.Add(context.Background(), "a@163.com", "b@163.com"))
+	assert.NoError(t, f.Add(context.Background()))
+	assert.NoError(t, f.MarkReady(context.Background()))
+}
+
+func TestRedisFilter_Ready(t *testing.T) {
+	const key = "webook:user:email:bloom:9585059:7"
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mock := redismocks.NewMockCmdable(ctrl)
+	set := redis.NewIntCmd(context.Background())
+	set.SetVal(1)
+	// key 被淘汰了 GETBIT 返回 0，当作没建好
+	unset := redis.NewIntCmd(context.Background())
+	unset.SetVal(0)
+	failed := redis.NewIntCmd(context.Background())
+	failed.SetErr(errors.New("缓存炸了"))
+	gomock.InOrder(
+		mock.EXPECT().GetBit(gomock.Any(), key, int64(0)).Return(set),
+		mock.EXPECT().SetBit(gomock.Any(), key, int64(0), 0).Return(redis.NewIntCmd(context.Background())),
+		mock.EXPECT().GetBit(gomock.Any(), key, int64(0)).Return(unset),
+		mock.EXPECT().GetBit(gomock.Any(), key, int64(0)).Return(failed),
+	)
+
+	f := NewRedisFilter(mock, "webook:user:email:bloom", 1000000, 0.01)
+	ready, err := f.Ready(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	assert.NoError(t, f.MarkNotReady(context.Background()))
+	ready, err = f.Ready(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, ready)
+	_, err = f.Ready(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 23:31:07
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/fallback.go
+ * @Description: 主限流器出错时切到备用限流器
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// FallbackLimiter 一般是 redis 做主、本地做备，redis 挂了每个实例各自限流，总量会放大到实例数倍，总比不限好
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	// 主限流器出错之后多久内直接用备用的，免得每个请求都等一次 redis 超时
+	cooldown time.Duration
+	// 最近一次出错的时间，纳秒
+	failedAt atomic.Int64
+	now      func() time.Time
+}
+
+func NewFallbackLimiter(primary Limiter, fallback Limiter, cooldown time.Duration) Limiter {
+	return &FallbackLimiter{
+		primary:  primary,
+		fallback: fallback,
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+}
+
+/**
+ * @description: 判断key是否触发限流
+ * @param {context.Context} ctx
+ * @param {string} key
+ * @return {bool, error}
+ */
+func (l *FallbackLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	now := l.now()
+	if failedAt := l.failedAt.Load(); failedAt != 0 && now.Sub(time.Unix(0, failedAt)) < l.cooldown {
+		return l.fallback.Limit(ctx, key)
+	}
+	limited, err := l.primary.Limit(ctx, key)
+	if err == nil {
+		return limited, nil
+	}
+	log.Printf("限流器出错，%s 内改用备用限流器: %v", l.cooldown, err)
+	l.failedAt.Store(now.UnixNano())
+	return l.fallback.Limit(ctx, key)
+}
@@ -0,0 +1,61 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-19 00:04:35
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/fallback_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	limitmocks "github.com/gz4z2b/go-webook/pkg/ratelimit/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFallbackLimiter_Limit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := limitmocks.NewMockLimiter(ctrl)
+	fallback := limitmocks.NewMockLimiter(ctrl)
+	now := time.UnixMilli(1700000000000)
+	limiter := NewFallbackLimiter(primary, fallback, time.Second*5).(*FallbackLimiter)
+	limiter.now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+
+	// 主限流器正常就不走备用的
+	primary.EXPECT().Limit(gomock.Any(), "a").Return(true, nil)
+	limited, err := limiter.Limit(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, limited)
+
+	// 出错切到备用的
+	primary.EXPECT().Limit(gomock.Any(), "a").Return(false, errors.New("redis 连不上"))
+	fallback.EXPECT().Limit(gomock.Any(), "a").Return(true, nil)
+	limited, err = limiter.Limit(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, limited)
+
+	// 冷却期内不再试主限流器
+	fallback.EXPECT().Limit(gomock.Any(), "a").Return(false, nil)
+	limited, err = limiter.Limit(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, limited)
+
+	// 冷却期过了再试主限流器
+	now = now.Add(time.Second * 5)
+	primary.EXPECT().Limit(gomock.Any(), "a").Return(false, nil)
+	limited, err = limiter.Limit(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, limited)
+}
@@ -0,0 +1,23 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:40:22
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/interface.go
+ * @Description: 限流器接口
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+type Limiter interface {
+	// Limit 返回 true 表示 key 已经触发限流
+	Limit(ctx context.Context, key string) (bool, error)
+}
+
+// NewLimiterFunc 按窗口和阈值创建限流器，一套存储上可以建多个不同规则的限流器
+type NewLimiterFunc func(window time.Duration, threshold int) Limiter
@@ -0,0 +1,60 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:52:36
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/localSlidingWindow.go
+ * @Description: 单机内存滑动窗口限流，给不依赖redis的部署方式用
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type LocalSlidingWindowLimiter struct {
+	lock sync.Mutex
+	// key 到窗口内请求时间的映射，按时间先后排列
+	requests  map[string][]time.Time
+	window    time.Duration
+	threshold int
+	now       func() time.Time
+}
+
+func NewLocalSlidingWindowLimiter(window time.Duration, threshold int) Limiter {
+	return &LocalSlidingWindowLimiter{
+		requests:  make(map[string][]time.Time),
+		window:    window,
+		threshold: threshold,
+		now:       time.Now,
+	}
+}
+
+/**
+ * @description: 判断key是否触发限流，没触发的话占用窗口内的一个名额
+ * @param {context.Context} ctx
+ * @param {string} key
+ * @return {bool, error}
+ */
+func (l *LocalSlidingWindowLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	start := now.Add(-l.window)
+	requests := l.requests[key]
+	i := 0
+	for i < len(requests) && !requests[i].After(start) {
+		i++
+	}
+	requests = requests[i:]
+	if len(requests) >= l.threshold {
+		l.requests[key] = requests
+		return true, nil
+	}
+	l.requests[key] = append(requests, now)
+	return false, nil
+}
@@ -0,0 +1,46 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 19:26:03
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/localSlidingWindow_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalSlidingWindowLimiter_Limit(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	limiter := NewLocalSlidingWindowLimiter(time.Second, 2).(*LocalSlidingWindowLimiter)
+	limiter.now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		limited, err := limiter.Limit(ctx, "a")
+		assert.NoError(t, err)
+		assert.False(t, limited)
+	}
+	// 窗口内第三个请求被限流，别的key不受影响
+	limited, _ := limiter.Limit(ctx, "a")
+	assert.True(t, limited)
+	limited, _ = limiter.Limit(ctx, "b")
+	assert.False(t, limited)
+
+	// 窗口滑过去之后恢复
+	now = now.Add(time.Second - time.Millisecond)
+	limited, _ = limiter.Limit(ctx, "a")
+	assert.True(t, limited)
+	now = now.Add(time.Millisecond)
+	limited, _ = limiter.Limit(ctx, "a")
+	assert.False(t, limited)
+}
@@ -0,0 +1,93 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 23:24:51
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/localTokenBucket.go
+ * @Description: 单机内存令牌桶限流，redis不可用时兜底
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// LocalTokenBucketLimiter 桶容量是 threshold，每个 window 匀速补满一桶，
+// 和滑动窗口相比允许的突发一样大，但是不用给每个请求记时间
+type LocalTokenBucketLimiter struct {
+	lock    sync.Mutex
+	buckets map[string]*tokenBucket
+	// 每纳秒补多少个令牌
+	rate     float64
+	capacity float64
+	window   time.Duration
+	// 上次清理满桶的时间
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLocalTokenBucketLimiter(window time.Duration, threshold int) Limiter {
+	return &LocalTokenBucketLimiter{
+		buckets:  make(map[string]*tokenBucket),
+		rate:     float64(threshold) / float64(window),
+		capacity: float64(threshold),
+		window:   window,
+		now:      time.Now,
+	}
+}
+
+/**
+ * @description: 判断key是否触发限流，没触发的话拿走一个令牌
+ * @param {context.Context} ctx
+ * @param {string} key
+ * @return {bool, error}
+ */
+func (l *LocalTokenBucketLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.capacity, last: now}
+		l.buckets[key] = bucket
+	}
+	l.refill(bucket, now)
+	if bucket.tokens < 1 {
+		return true, nil
+	}
+	bucket.tokens--
+	return false, nil
+}
+
+func (l *LocalTokenBucketLimiter) refill(bucket *tokenBucket, now time.Time) {
+	bucket.tokens += float64(now.Sub(bucket.last)) * l.rate
+	if bucket.tokens > l.capacity {
+		bucket.tokens = l.capacity
+	}
+	bucket.last = now
+}
+
+// sweep 按IP之类的key限流时key会越来越多，每个窗口清一次已经补满的桶，补满的桶和新建的没区别
+func (l *LocalTokenBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		l.refill(bucket, now)
+		if bucket.tokens >= l.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 23:58:02
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/localTokenBucket_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalTokenBucketLimiter_Limit(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	limiter := NewLocalTokenBucketLimiter(time.Second, 2).(*LocalTokenBucketLimiter)
+	limiter.now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		limited, err := limiter.Limit(ctx, "a")
+		assert.NoError(t, err)
+		assert.False(t, limited)
+	}
+	// 一桶用完被限流，别的key不受影响
+	limited, _ := limiter.Limit(ctx, "a")
+	assert.True(t, limited)
+	limited, _ = limiter.Limit(ctx, "b")
+	assert.False(t, limited)
+
+	// 半个窗口补回一个令牌
+	now = now.Add(time.Millisecond * 499)
+	limited, _ = limiter.Limit(ctx, "a")
+	assert.True(t, limited)
+	now = now.Add(time.Millisecond)
+	limited, _ = limiter.Limit(ctx, "a")
+	assert.False(t, limited)
+	limited, _ = limiter.Limit(ctx, "a")
+	assert.True(t, limited)
+
+	// 补满的桶会被清掉
+	now = now.Add(time.Second * 2)
+	limited, _ = limiter.Limit(ctx, "c")
+	assert.False(t, limited)
+	assert.Len(t, limiter.buckets, 1)
+}
@@ -0,0 +1,17 @@
+-- 有序集合实现的滑动窗口，score 是请求时间（毫秒）
+local key = KEYS[1]
+local window = tonumber(ARGV[1])
+local threshold = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local member = ARGV[4]
+
+-- 先把窗口外的请求清掉
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local cnt = redis.call('ZCARD', key)
+if cnt >= threshold then
+    -- 触发限流
+    return 1
+end
+redis.call('ZADD', key, now, member)
+redis.call('PEXPIRE', key, window)
+return 0
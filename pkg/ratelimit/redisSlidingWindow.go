@@ -0,0 +1,59 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 17:44:10
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/redisSlidingWindow.go
+ * @Description: 基于redis有序集合的滑动窗口限流，多个实例共享同一个窗口
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"math/rand"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/slide_window.lua
+var luaSlideWindow string
+
+type RedisSlidingWindowLimiter struct {
+	cmd redis.Cmdable
+	// 窗口大小
+	window time.Duration
+	// 窗口内允许的请求数
+	threshold int
+	now       func() time.Time
+}
+
+func NewRedisSlidingWindowLimiter(cmd redis.Cmdable, window time.Duration, threshold int) Limiter {
+	return &RedisSlidingWindowLimiter{
+		cmd:       cmd,
+		window:    window,
+		threshold: threshold,
+		now:       time.Now,
+	}
+}
+
+/**
+ * @description: 判断key是否触发限流，没触发的话占用窗口内的一个名额
+ * @param {context.Context} ctx
+ * @param {string} key
+ * @return {bool, error}
+ */
+func (l *RedisSlidingWindowLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	now := l.now().UnixMilli()
+	// 同一毫秒可能有多个请求，成员名带上随机数避免互相覆盖
+	member := fmt.Sprintf("%d:%d", now, rand.Int63())
+	res, err := l.cmd.Eval(ctx, luaSlideWindow, []string{key},
+		l.window.Milliseconds(), l.threshold, now, member).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
@@ -0,0 +1,84 @@
+/*
+ * @Author: p_hanxichen
+ * @Date: 2026-10-18 19:20:18
+ * @LastEditors: p_hanxichen
+ * @FilePath: /go/src/webook/pkg/ratelimit/redisSlidingWindow_test.go
+ * @Description:
+ *
+ * Copyright (c) 2023 by gdtengnan, All Rights Reserved.
+ */
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redismocks "github.com/gz4z2b/go-webook/internal/repository/cache/mocks/redismocks"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRedisSlidingWindowLimiter_Limit(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	tests := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) redis.Cmdable
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "没触发限流",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(0))
+				mock.EXPECT().Eval(gomock.Any(), luaSlideWindow, []string{"webook:sms:limit"},
+					int64(1000), 100, now.UnixMilli(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			want: false,
+		},
+		{
+			name: "触发限流",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(1))
+				mock.EXPECT().Eval(gomock.Any(), luaSlideWindow, gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			want: true,
+		},
+		{
+			name: "缓存炸了",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				mock := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetErr(errors.New("缓存炸了"))
+				mock.EXPECT().Eval(gomock.Any(), luaSlideWindow, gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(cmd)
+				return mock
+			},
+			want:    false,
+			wantErr: errors.New("缓存炸了"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			limiter := NewRedisSlidingWindowLimiter(tt.mock(ctrl), time.Second, 100).(*RedisSlidingWindowLimiter)
+			limiter.now = func() time.Time {
+				return now
+			}
+			limited, err := limiter.Limit(context.Background(), "webook:sms:limit")
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, limited)
+		})
+	}
+}
@@ -8,6 +8,8 @@ CREATE TABLE `t_user_profile` (
   `nickname` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '昵称',
   `birthday` bigint unsigned NOT NULL DEFAULT '0' COMMENT '生日',
   `description` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '个人简介',
+  `birthday_visibility` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '生日可见性 0仅自己 1公开',
+  `email_visibility` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '邮箱可见性 0仅自己 1公开',
   `createtime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '创建时间',
   `updatetime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '更新时间',
   `deletetime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '删除时间',
@@ -17,11 +19,29 @@ CREATE TABLE `t_user_profile` (
 
 CREATE TABLE `t_user` (
   `id` int NOT NULL AUTO_INCREMENT COMMENT 'ID',
-  `email` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '邮箱',
+  `email` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT '邮箱',
+  `phone` varchar(32) DEFAULT NULL COMMENT '手机号',
   `password` varchar(255) NOT NULL DEFAULT '' COMMENT '密码',
+  `status` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '0正常 1待验证邮箱 2禁用 3已注销',
   `createtime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '创建时间',
   `updatetime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '更新时间',
   `deletetime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '删除时间',
   PRIMARY KEY (`id`),
-  UNIQUE KEY `uniq_email` (`email`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户';
\ No newline at end of file
+  UNIQUE KEY `uniq_email` (`email`, `deletetime`),
+  UNIQUE KEY `uniq_phone` (`phone`, `deletetime`),
+  KEY `idx_deletetime` (`deletetime`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户';
+
+CREATE TABLE `t_async_sms` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `config` text NOT NULL COMMENT '号码、模板、参数',
+  `retry_cnt` int NOT NULL DEFAULT '0' COMMENT '已重试次数',
+  `retry_max` int NOT NULL DEFAULT '0' COMMENT '最多重试次数',
+  `status` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '0待发送 1发送中 2成功 3失败',
+  `next_retry_time` bigint NOT NULL DEFAULT '0' COMMENT '下次重试时间',
+  `version` bigint NOT NULL DEFAULT '0' COMMENT '乐观锁版本号',
+  `createtime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '创建时间',
+  `updatetime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_status_next_retry_time` (`status`, `next_retry_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='异步短信队列';
@@ -0,0 +1,73 @@
+-- 已经在跑的库升级到手机号登录、邮箱验证、注销账号这一版的表结构，新库直接用 init.sql 不用跑这个
+-- 每一步都先查 information_schema，跑到一半失败了改完问题整个重跑就行
+-- 要在新版本发布之前跑，旧版本的代码在新表结构上照常能用
+
+use webook;
+
+-- 手机号登录，邮箱注册和手机号登录的用户各自只有一项，没有的那项存 NULL
+SET @sql = IF((SELECT COUNT(*) FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_user' AND COLUMN_NAME = 'email' AND IS_NULLABLE = 'NO') > 0,
+  "ALTER TABLE `t_user` MODIFY `email` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT '邮箱'",
+  'SELECT 1');
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+UPDATE `t_user` SET `email` = NULL WHERE `email` = '';
+
+SET @sql = IF((SELECT COUNT(*) FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_user' AND COLUMN_NAME = 'phone') = 0,
+  "ALTER TABLE `t_user` ADD COLUMN `phone` varchar(32) DEFAULT NULL COMMENT '手机号' AFTER `email`",
+  'SELECT 1');
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+-- 老用户都按正常处理，不要求补验证邮箱
+SET @sql = IF((SELECT COUNT(*) FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_user' AND COLUMN_NAME = 'status') = 0,
+  "ALTER TABLE `t_user` ADD COLUMN `status` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '0正常 1待验证邮箱 2禁用 3已注销' AFTER `password`",
+  'SELECT 1');
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+-- 唯一索引带上 deletetime，注销之后同一个邮箱、手机号可以重新注册；旧的 uniq_email 只有 email 一列，删了重建
+SET @sql = IF((SELECT COUNT(*) FROM information_schema.STATISTICS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_user' AND INDEX_NAME = 'uniq_email') = 1,
+  'ALTER TABLE `t_user` DROP INDEX `uniq_email`, ADD UNIQUE KEY `uniq_email` (`email`, `deletetime`)',
+  'SELECT 1');
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+SET @sql = IF((SELECT COUNT(*) FROM information_schema.STATISTICS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_user' AND INDEX_NAME = 'uniq_phone') = 0,
+  'ALTER TABLE `t_user` ADD UNIQUE KEY `uniq_phone` (`phone`, `deletetime`)',
+  'SELECT 1');
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+-- 定时清理已注销的账号按 deletetime 扫
+SET @sql = IF((SELECT COUNT(*) FROM information_schema.STATISTICS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_user' AND INDEX_NAME = 'idx_deletetime') = 0,
+  'ALTER TABLE `t_user` ADD KEY `idx_deletetime` (`deletetime`)',
+  'SELECT 1');
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+-- 资料里生日、邮箱的可见性
+SET @sql = IF((SELECT COUNT(*) FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_user_profile' AND COLUMN_NAME = 'birthday_visibility') = 0,
+  "ALTER TABLE `t_user_profile` ADD COLUMN `birthday_visibility` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '生日可见性 0仅自己 1公开' AFTER `description`",
+  'SELECT 1');
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+SET @sql = IF((SELECT COUNT(*) FROM information_schema.COLUMNS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 't_user_profile' AND COLUMN_NAME = 'email_visibility') = 0,
+  "ALTER TABLE `t_user_profile` ADD COLUMN `email_visibility` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '邮箱可见性 0仅自己 1公开' AFTER `birthday_visibility`",
+  'SELECT 1');
+PREPARE stmt FROM @sql; EXECUTE stmt; DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS `t_async_sms` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',
+  `config` text NOT NULL COMMENT '号码、模板、参数',
+  `retry_cnt` int NOT NULL DEFAULT '0' COMMENT '已重试次数',
+  `retry_max` int NOT NULL DEFAULT '0' COMMENT '最多重试次数',
+  `status` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '0待发送 1发送中 2成功 3失败',
+  `next_retry_time` bigint NOT NULL DEFAULT '0' COMMENT '下次重试时间',
+  `version` bigint NOT NULL DEFAULT '0' COMMENT '乐观锁版本号',
+  `createtime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '创建时间',
+  `updatetime` bigint unsigned NOT NULL DEFAULT '0' COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_status_next_retry_time` (`status`, `next_retry_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='异步短信队列';